@@ -0,0 +1,67 @@
+package contracts
+
+import (
+	"context"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Void ใช้เป็น Output ของ Activity ที่ไม่มีผลลัพธ์
+type Void struct{}
+
+// ActivityDef ผูกชื่อ Activity + Task Queue เข้ากับชนิดของ Input (I) และ Output (O)
+//
+// ห้ามใส่ "." ในชื่อ: ตอน Replay Temporal เทียบชื่อ Activity เฉพาะส่วนหลัง "." ตัวสุดท้าย
+// ถ้าชื่อใหม่ลงท้ายเหมือนชื่อเก่า การเปลี่ยนจะไม่ถูกจับว่าเป็น Non-determinism
+type ActivityDef[I any, O any] struct {
+	Name  string
+	Queue string
+}
+
+// Execute เรียก Activity จาก Workflow แล้วรอผล
+// Activity Options (Timeout / Retry) มาจาก ctx แต่ Task Queue ถูกบังคับตาม Def เสมอ
+func (d ActivityDef[I, O]) Execute(ctx workflow.Context, in I) (O, error) {
+	var out O
+	ctx = workflow.WithTaskQueue(ctx, d.Queue)
+	err := workflow.ExecuteActivity(ctx, d.Name, in).Get(ctx, &out)
+	return out, err
+}
+
+// Register ลงทะเบียน Implementation ใน Worker (Signature ต้องตรงกับ Def)
+// ถ้า Step Policy ของฝั่ง Workflow ตั้ง HeartbeatTimeout ไว้ จะส่ง Heartbeat ให้เองระหว่างทำงาน
+func (d ActivityDef[I, O]) Register(r worker.ActivityRegistry, fn func(context.Context, I) (O, error)) {
+	r.RegisterActivityWithOptions(func(ctx context.Context, in I) (O, error) {
+		defer keepAlive(ctx)()
+		return fn(ctx, in)
+	}, activity.RegisterOptions{Name: d.Name})
+}
+
+// keepAlive ส่ง Heartbeat เป็นระยะจนกว่าจะเรียก stop
+// Worker ตายกลางทาง -> Temporal รู้ภายใน HeartbeatTimeout แทนที่จะรอจนครบ StartToCloseTimeout
+// และ Activity จะได้รับการ Cancel (ctx.Done) ผ่าน Heartbeat ด้วย
+func keepAlive(ctx context.Context) (stop func()) {
+	timeout := activity.GetInfo(ctx).HeartbeatTimeout
+	if timeout <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(timeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				activity.RecordHeartbeat(ctx)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,9 @@
+// Package contracts คือสัญญากลางระหว่าง Orchestrator กับ Worker (Inventory / Payment)
+//
+// ชื่อ Activity, Task Queue และ Input/Output อยู่ที่นี่ที่เดียว
+// ทั้งฝั่งที่เรียก (Workflow) และฝั่งที่ Register (Worker) ใช้ ActivityDef ตัวเดียวกัน
+// ถ้า Signature ไม่ตรงกัน Compile จะไม่ผ่าน แทนที่จะไปพังตอน Runtime
+//
+// ที่นี่มีแค่ Type กลาง: Code ที่ต้องใช้ MongoDB / NATS (Change Stream, Outbox) อยู่ที่ Module infra
+// เพื่อไม่ให้ทุกคนที่ Import contracts ต้องลาก Driver พวกนั้นไปด้วย
+package contracts
@@ -0,0 +1,10 @@
+package contracts
+
+// Type ของ ApplicationError ที่ Worker ส่งกลับ
+// ใช้ใส่ใน NonRetryableErrorTypes ของ Step Policy ฝั่ง Orchestrator (ไม่ต้อง Retry Error ที่ลองใหม่ก็ไม่หาย)
+const (
+	ErrTypeOutOfStock        = "OutOfStock"          // Hard Check ไม่ผ่าน
+	ErrTypeConcurrency       = "ConcurrencyConflict" // Version ชน -> Retry แล้วมักจะผ่าน
+	ErrTypeInsufficientFunds = "InsufficientFunds"   // Gateway ปฏิเสธ
+	ErrTypeNoReservation     = "NoReservation"       // Commit Order ที่ไม่ได้จองไว้ (หรือคืนไปแล้ว)
+)
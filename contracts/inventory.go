@@ -0,0 +1,49 @@
+package contracts
+
+// ReserveStock จองสต็อก (Hard Check) -> StockReserved
+var ReserveStock = ActivityDef[ReserveStockInput, StockOutput]{
+	Name:  "ReserveStockV2",
+	Queue: QueueInventory,
+}
+
+// ReleaseStock คืนสต็อก (Compensate) -> StockReleased
+var ReleaseStock = ActivityDef[ReleaseStockInput, StockOutput]{
+	Name:  "ReleaseStockV2",
+	Queue: QueueInventory,
+}
+
+// CommitStock ยืนยันการขายหลังตัดเงินสำเร็จ -> StockCommitted
+var CommitStock = ActivityDef[CommitStockInput, StockOutput]{
+	Name:  "CommitStock",
+	Queue: QueueInventory,
+}
+
+type ReserveStockInput struct {
+	OrderID   string `json:"order_id"` // ใช้กันจองซ้ำเมื่อ Activity ถูก Retry
+	ProductID string `json:"product_id"`
+	Qty       int    `json:"qty"`
+}
+
+type ReleaseStockInput struct {
+	OrderID   string `json:"order_id"` // ใช้กันคืนซ้ำเมื่อ Activity ถูก Retry
+	ProductID string `json:"product_id"`
+	Qty       int    `json:"qty"`
+}
+
+// CommitStockInput ไม่มี Qty: Inventory Commit เท่ากับยอดที่จองให้ Order นี้ไว้เสมอ
+type CommitStockInput struct {
+	OrderID   string `json:"order_id"`
+	ProductID string `json:"product_id"`
+}
+
+// StockOutput คือ Version ของ Stream สินค้าหลังเขียน Event (ใช้เป็น Consistency Token ได้)
+type StockOutput struct {
+	Version int `json:"version"`
+}
+
+// Deprecated: ชื่อ Activity แบบ Positional Arguments (productID string, qty int, orderID string)
+// ยังต้อง Register ไว้ให้ Workflow ที่เริ่มก่อนมี Contract นี้ จนกว่าจะไม่มีตัวไหนค้างแล้ว
+const (
+	LegacyReserveStock = "ReserveStock"
+	LegacyReleaseStock = "ReleaseStock"
+)
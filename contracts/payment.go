@@ -0,0 +1,16 @@
+package contracts
+
+// ProcessPayment ตัดเงิน -> PaymentProcessed
+var ProcessPayment = ActivityDef[ProcessPaymentInput, Void]{
+	Name:  "ProcessPaymentV2",
+	Queue: QueuePayment,
+}
+
+type ProcessPaymentInput struct {
+	OrderID string `json:"order_id"` // Idempotency Key ฝั่ง Gateway
+	Amount  int    `json:"amount"`
+}
+
+// Deprecated: ชื่อ Activity แบบ Positional Arguments (orderID string, amount int)
+// ยังต้อง Register ไว้ให้ Workflow ที่เริ่มก่อนมี Contract นี้ จนกว่าจะไม่มีตัวไหนค้างแล้ว
+const LegacyProcessPayment = "ProcessPayment"
@@ -0,0 +1,8 @@
+package contracts
+
+// ชื่อ Task Queue ของแต่ละ Service
+const (
+	QueueOrder     = "order-queue"     // Orchestrator (Workflow + Activity ของ Saga เอง)
+	QueueInventory = "inventory-queue" // Inventory Worker
+	QueuePayment   = "payment-queue"   // Payment Worker
+)
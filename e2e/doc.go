@@ -0,0 +1,3 @@
+// Package e2e คือ End-to-end Test ที่ยิงเข้า Stack จริง (docker compose)
+// Test ทั้งหมดอยู่หลัง Build Tag "e2e" เพื่อไม่ให้ go test ./... ปกติต้องพึ่ง Service ภายนอก
+package e2e
@@ -0,0 +1,194 @@
+//go:build e2e
+
+// End-to-end: ยิง Order เดียวกันเข้า Orchestrator 2 ตัว (Orchestration / Choreography)
+// แล้วเช็คว่าผลสุดท้ายเหมือนกัน
+//
+//	SAGA_MODE=choreography docker compose --profile choreography up -d --build
+//	cd e2e && go test -tags e2e -v ./...
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// finalState คือสิ่งที่ทั้งสองโหมดต้องได้ตรงกัน
+type finalState struct {
+	Status     string // สถานะ Order จาก GET /orders/:order_id
+	StockDelta int    // สต็อกเปลี่ยนไปเท่าไหร่ (Replay จาก events)
+	Captured   int    // ยอดที่ตัดเงินสำเร็จ (PaymentProcessed)
+}
+
+func TestSagaModesReachSameFinalState(t *testing.T) {
+	modes := map[string]string{
+		"orchestration": getEnv("E2E_ORCHESTRATION_URL", "http://localhost:8080"),
+		"choreography":  getEnv("E2E_CHOREOGRAPHY_URL", "http://localhost:8081"),
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(getEnv("MONGO_URI", "mongodb://localhost:27017/?directConnection=true")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(ctx)
+	db := client.Database("shop_db")
+
+	scenarios := []struct {
+		name      string
+		productID string
+		qty       int
+		want      finalState
+	}{
+		// ราคา 10000 -> ตัดเงินผ่าน
+		{"success", "iphone-15", 1, finalState{Status: "COMPLETED", StockDelta: -1, Captured: 10000}},
+		// ราคา 45000 -> ตัดเงินไม่ผ่าน ต้องคืนของ
+		{"payment_failed", "macbook-pro", 1, finalState{Status: "FAILED", StockDelta: 0, Captured: 0}},
+	}
+
+	for _, sc := range scenarios {
+		t.Run(sc.name, func(t *testing.T) {
+			results := map[string]finalState{}
+
+			// รันทีละโหมด (ไม่พร้อมกัน) เพื่อให้วัด StockDelta ของสินค้าได้ตรง
+			for _, mode := range []string{"orchestration", "choreography"} {
+				orderID := fmt.Sprintf("e2e-%s-%s-%d", sc.name, mode, time.Now().UnixNano())
+				before := waitStockStable(t, db, sc.productID)
+
+				placeOrder(t, modes[mode], orderID, sc.productID, sc.qty)
+				status := waitOrderTerminal(t, modes[mode], orderID)
+				after := waitStockStable(t, db, sc.productID)
+
+				results[mode] = finalState{
+					Status:     status,
+					StockDelta: after - before,
+					Captured:   capturedAmount(t, db, orderID),
+				}
+				t.Logf("%s: order=%s %+v", mode, orderID, results[mode])
+			}
+
+			if results["orchestration"] != results["choreography"] {
+				t.Fatalf("modes disagree: orchestration=%+v choreography=%+v", results["orchestration"], results["choreography"])
+			}
+			if results["orchestration"] != sc.want {
+				t.Fatalf("final state = %+v, want %+v", results["orchestration"], sc.want)
+			}
+		})
+	}
+}
+
+func placeOrder(t *testing.T, baseURL, orderID, productID string, qty int) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"order_id": orderID, "product_id": productID, "qty": qty})
+	resp, err := http.Post(baseURL+"/orders", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST /orders: status %d", resp.StatusCode)
+	}
+}
+
+// waitOrderTerminal Poll GET /orders/:order_id จนกว่า Order จะจบ
+func waitOrderTerminal(t *testing.T, baseURL, orderID string) string {
+	t.Helper()
+	deadline := time.Now().Add(60 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(baseURL + "/orders/" + orderID)
+		if err == nil {
+			var order struct {
+				Status string `json:"status"`
+			}
+			json.NewDecoder(resp.Body).Decode(&order)
+			resp.Body.Close()
+
+			switch order.Status {
+			case "COMPLETED", "FAILED", "CANCELLED", "TIMED_OUT":
+				return order.Status
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	t.Fatalf("order %s did not finish in time", orderID)
+	return ""
+}
+
+// waitStockStable รอจน Stream ของสินค้าเงียบ (Compensate ใน Choreography อาจตามมาหลัง Order จบ)
+// แล้วคืนสต็อกปัจจุบันจากการ Replay events
+func waitStockStable(t *testing.T, db *mongo.Database, productID string) int {
+	t.Helper()
+	last, stable := stock(t, db, productID), 0
+	for stable < 3 {
+		time.Sleep(time.Second)
+		current := stock(t, db, productID)
+		if current == last {
+			stable++
+		} else {
+			last, stable = current, 0
+		}
+	}
+	return last
+}
+
+func stock(t *testing.T, db *mongo.Database, productID string) int {
+	t.Helper()
+	cursor, err := db.Collection("events").Find(context.Background(), bson.M{"stream_id": productID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []struct {
+		Type string `bson:"type"`
+		Qty  int    `bson:"qty"`
+	}
+	if err := cursor.All(context.Background(), &events); err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for _, evt := range events {
+		switch evt.Type {
+		case "StockAdded", "StockReleased":
+			total += evt.Qty
+		case "StockReserved":
+			total -= evt.Qty
+		}
+	}
+	return total
+}
+
+func capturedAmount(t *testing.T, db *mongo.Database, orderID string) int {
+	t.Helper()
+	cursor, err := db.Collection("payment_events").Find(context.Background(), bson.M{"order_id": orderID, "type": "PaymentProcessed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payments []struct {
+		Amount int `bson:"amount"`
+	}
+	if err := cursor.All(context.Background(), &payments); err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for _, p := range payments {
+		total += p.Amount
+	}
+	return total
+}
+
+func getEnv(key, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,108 @@
+package choreography
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"external-orchestrator/app"
+	"external-orchestrator/core"
+)
+
+// ชื่อ Event ของ Service อื่นที่ Tracker ฟังอยู่
+const (
+	inventoryStockReserved     = "StockReserved"
+	inventoryReservationFailed = "StockReservationRejected"
+	paymentProcessed           = "PaymentProcessed"
+	paymentFailed              = "PaymentFailed"
+)
+
+// OrderTracker คือฝั่ง Orchestrator ของ Choreography Saga
+// ไม่ได้สั่งใคร แค่ฟังผลจาก Inventory / Payment แล้วบันทึกลง Order Stream
+// เพื่อให้ GET /orders/:order_id ตอบได้เหมือนโหมด Orchestration
+type OrderTracker struct {
+	Orders *app.OrderService
+}
+
+func NewOrderTracker(orders *app.OrderService) *OrderTracker {
+	return &OrderTracker{Orders: orders}
+}
+
+// OnInventoryEvent รับ fullDocument จาก events (Inventory Event Store)
+func (t *OrderTracker) OnInventoryEvent(ctx context.Context, doc bson.Raw) error {
+	var evt struct {
+		OrderID string `bson:"order_id"`
+		Type    string `bson:"type"`
+	}
+	if err := bson.Unmarshal(doc, &evt); err != nil {
+		return err
+	}
+
+	switch evt.Type {
+	case inventoryStockReserved:
+		return t.record(ctx, evt.OrderID, core.EventStockReserved, "")
+	case inventoryReservationFailed:
+		return t.record(ctx, evt.OrderID, core.EventOrderFailed, "out of stock")
+	}
+	return nil
+}
+
+// OnPaymentEvent รับ fullDocument จาก payment_events
+func (t *OrderTracker) OnPaymentEvent(ctx context.Context, doc bson.Raw) error {
+	var evt struct {
+		OrderID string `bson:"order_id"`
+		Type    string `bson:"type"`
+		Amount  int    `bson:"amount"`
+		Reason  string `bson:"reason"`
+	}
+	if err := bson.Unmarshal(doc, &evt); err != nil {
+		return err
+	}
+
+	switch evt.Type {
+	case paymentProcessed:
+		// Change Stream คนละ Collection ไม่รับประกันลำดับ -> PaymentProcessed อาจมาก่อน StockReserved
+		// แต่ Payment ตัดเงินหลังจองของสำเร็จเท่านั้น จึงบันทึก StockReserved ให้ก่อนได้เลย
+		for _, eventType := range []string{core.EventStockReserved, core.EventPaymentCaptured, core.EventOrderCompleted} {
+			if err := t.record(ctx, evt.OrderID, eventType, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	case paymentFailed:
+		return t.record(ctx, evt.OrderID, core.EventOrderFailed, evt.Reason)
+	}
+	return nil
+}
+
+// record บันทึกเฉพาะ Order ที่อยู่ในโหมด Choreography
+func (t *OrderTracker) record(ctx context.Context, orderID, eventType, reason string) error {
+	if orderID == "" {
+		return nil
+	}
+	agg, err := t.Orders.Load(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if agg.Mode != core.SagaModeChoreography {
+		return nil
+	}
+
+	event := core.OrderEvent{StreamID: orderID, Type: eventType, Reason: reason}
+	if eventType == core.EventPaymentCaptured {
+		event.Amount = agg.Amount
+	}
+
+	err = t.Orders.Record(ctx, event)
+	if errors.Is(err, core.ErrInvalidOrderTransition) {
+		// Order จบไปแล้ว (เช่น Failed ไปก่อน) -> ไม่ต้องทำอะไร
+		log.Printf("⚠️ Ignored %s for order %s: %v", eventType, orderID, err)
+		return nil
+	}
+	if err == nil {
+		log.Printf("📝 Order %s -> %s", orderID, eventType)
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdminToken ให้ผ่านเฉพาะคำขอที่มี "Authorization: Bearer <token>" ตรงกับ ADMIN_TOKEN
+// ไม่ได้ตั้ง token = ปิด Route กลุ่มนี้ทั้งหมด (ไม่เปิดให้ใครก็แก้ราคาได้โดยไม่ตั้งใจ)
+func RequireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin API disabled: ADMIN_TOKEN is not set"})
+			return
+		}
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin token required"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireAdminTokenGuardsCatalogWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		token      string // ADMIN_TOKEN ของ Service
+		header     string
+		wantStatus int
+	}{
+		{name: "valid token", token: "s3cret", header: "Bearer s3cret", wantStatus: http.StatusAccepted},
+		{name: "missing header", token: "s3cret", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: "s3cret", header: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "not bearer", token: "s3cret", header: "s3cret", wantStatus: http.StatusUnauthorized},
+		{name: "token not configured", header: "Bearer ", wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Group("/products", RequireAdminToken(tt.token)).PUT("/:product_id/price", func(c *gin.Context) {
+				c.Status(http.StatusAccepted)
+			})
+
+			req := httptest.NewRequest(http.MethodPut, "/products/airpods/price", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
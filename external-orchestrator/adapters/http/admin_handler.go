@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.temporal.io/sdk/client"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+)
+
+// AdminHandler สำหรับ Operator จัดการ Saga ที่ Compensate ไม่ผ่าน
+type AdminHandler struct {
+	Interventions  ports.InterventionRepository
+	TemporalClient client.Client
+}
+
+func NewAdminHandler(interventions ports.InterventionRepository, tClient client.Client) *AdminHandler {
+	return &AdminHandler{Interventions: interventions, TemporalClient: tClient}
+}
+
+// GET /admin/sagas/stuck -> รายการ Saga ที่รอคนมาจัดการ
+func (h *AdminHandler) ListStuckSagas(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	items, err := h.Interventions.ListOpen(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "List stuck sagas failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sagas": items})
+}
+
+// POST /admin/sagas/:order_id/retry
+func (h *AdminHandler) Retry(c *gin.Context) { h.signal(c, core.ActionRetry) }
+
+// POST /admin/sagas/:order_id/force-release
+func (h *AdminHandler) ForceRelease(c *gin.Context) { h.signal(c, core.ActionForceRelease) }
+
+// POST /admin/sagas/:order_id/resolve
+func (h *AdminHandler) Resolve(c *gin.Context) { h.signal(c, core.ActionResolve) }
+
+func (h *AdminHandler) signal(c *gin.Context, action string) {
+	orderID := c.Param("order_id")
+
+	// Body เป็น Optional (ใส่ชื่อ Operator / หมายเหตุ)
+	var body struct {
+		Operator string `json:"operator"`
+		Note     string `json:"note"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Body"})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// ส่ง Signal ได้เฉพาะเคสที่ยังเปิดอยู่เท่านั้น
+	intervention, err := h.Interventions.Get(ctx, orderID)
+	switch {
+	case errors.Is(err, core.ErrInterventionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "No intervention for this order"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Load intervention failed"})
+		return
+	case intervention.Status != core.InterventionOpen:
+		c.JSON(http.StatusConflict, gin.H{"error": "Intervention is already " + strings.ToLower(intervention.Status)})
+		return
+	}
+
+	signal := core.InterventionSignal{Action: action, Operator: body.Operator, Note: body.Note}
+	err = h.TemporalClient.SignalWorkflow(ctx, intervention.WorkflowID, "", core.SignalIntervention, signal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to signal workflow"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":     "Signal sent",
+		"order_id":    orderID,
+		"workflow_id": intervention.WorkflowID,
+		"action":      action,
+	})
+}
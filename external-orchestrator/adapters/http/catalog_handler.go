@@ -0,0 +1,116 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+)
+
+// CatalogHandler จัดการ Product Catalog (ราคาสินค้าฝั่ง Server)
+type CatalogHandler struct {
+	Catalog ports.CatalogRepository
+	Views   ports.CatalogViewRepository
+}
+
+func NewCatalogHandler(catalog ports.CatalogRepository, views ports.CatalogViewRepository) *CatalogHandler {
+	return &CatalogHandler{Catalog: catalog, Views: views}
+}
+
+// POST /products -> ProductCreated
+func (h *CatalogHandler) CreateProduct(c *gin.Context) {
+	var body struct {
+		ProductID string `json:"product_id"`
+		Name      string `json:"name"`
+		Price     int    `json:"price"`
+	}
+	if err := c.BindJSON(&body); err != nil || body.ProductID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Body"})
+		return
+	}
+
+	h.execute(c, body.ProductID, func(agg *core.ProductAggregate) (core.CatalogEvent, error) {
+		return agg.Create(body.Name, body.Price)
+	})
+}
+
+// PUT /products/:product_id/price -> PriceChanged
+func (h *CatalogHandler) ChangePrice(c *gin.Context) {
+	var body struct {
+		Price int `json:"price"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Body"})
+		return
+	}
+
+	h.execute(c, c.Param("product_id"), func(agg *core.ProductAggregate) (core.CatalogEvent, error) {
+		return agg.ChangePrice(body.Price)
+	})
+}
+
+// GET /products/:product_id -> ราคาจาก Read Model
+func (h *CatalogHandler) GetProduct(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	view, err := h.Views.GetCatalogView(ctx, c.Param("product_id"))
+	if err != nil {
+		if errors.Is(err, core.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Load product failed"})
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// execute: Replay -> ให้ Aggregate ตัดสินใจ -> Append (Version ชน = มีคนแก้ตัดหน้า)
+func (h *CatalogHandler) execute(c *gin.Context, productID string, decide func(*core.ProductAggregate) (core.CatalogEvent, error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := h.Catalog.GetEvents(ctx, productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Load product failed"})
+		return
+	}
+	agg := core.NewProductAggregate(productID)
+	agg.Replay(events)
+
+	event, err := decide(agg)
+	switch {
+	case errors.Is(err, core.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, core.ErrProductExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Catalog.AppendEvent(ctx, event); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "concurrency error: please retry"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Save product failed"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"product_id": productID,
+		"event":      event.Type,
+		"price":      event.Price,
+		"version":    event.Version,
+	})
+}
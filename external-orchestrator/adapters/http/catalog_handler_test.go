@@ -0,0 +1,68 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"external-orchestrator/core"
+)
+
+type fakeCatalogEvents struct {
+	events []core.CatalogEvent
+}
+
+func (f *fakeCatalogEvents) GetEvents(ctx context.Context, productID string) ([]core.CatalogEvent, error) {
+	var out []core.CatalogEvent
+	for _, e := range f.events {
+		if e.StreamID == productID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeCatalogEvents) AppendEvent(ctx context.Context, event core.CatalogEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func TestCatalogHandlerMapsAggregateErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantEvents int // จำนวน Event ใน Store หลังคำขอ (เริ่มที่ 1 = airpods ถูกสร้างแล้ว)
+	}{
+		{name: "create", method: http.MethodPost, path: "/products", body: `{"product_id": "ipad", "name": "iPad", "price": 19900}`, wantStatus: http.StatusAccepted, wantEvents: 2},
+		{name: "create duplicate", method: http.MethodPost, path: "/products", body: `{"product_id": "airpods", "name": "AirPods", "price": 5990}`, wantStatus: http.StatusConflict, wantEvents: 1},
+		{name: "create with zero price", method: http.MethodPost, path: "/products", body: `{"product_id": "ipad", "name": "iPad", "price": 0}`, wantStatus: http.StatusBadRequest, wantEvents: 1},
+		{name: "change price", method: http.MethodPut, path: "/products/airpods/price", body: `{"price": 4990}`, wantStatus: http.StatusAccepted, wantEvents: 2},
+		{name: "change price of unknown product", method: http.MethodPut, path: "/products/ipad/price", body: `{"price": 4990}`, wantStatus: http.StatusNotFound, wantEvents: 1},
+		{name: "change price to negative", method: http.MethodPut, path: "/products/airpods/price", body: `{"price": -1}`, wantStatus: http.StatusBadRequest, wantEvents: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeCatalogEvents{events: []core.CatalogEvent{
+				{StreamID: "airpods", Type: core.EventProductCreated, Name: "AirPods", Price: 5990, Version: 1},
+			}}
+			h := NewCatalogHandler(store, fakeCatalogViews{})
+			r := gin.New()
+			r.POST("/products", h.CreateProduct)
+			r.PUT("/products/:product_id/price", h.ChangePrice)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus || len(store.events) != tt.wantEvents {
+				t.Fatalf("status = %d with %d events, want %d with %d (body %s)", w.Code, len(store.events), tt.wantStatus, tt.wantEvents, w.Body)
+			}
+		})
+	}
+}
@@ -2,62 +2,145 @@ package http
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.temporal.io/sdk/client"
 
+	"contracts"
+	"external-orchestrator/app"
 	"external-orchestrator/core"
 	"external-orchestrator/ports"
 )
 
 type OrderHandler struct {
-	Repo           ports.ProductRepository
+	Stock          *app.SoftStockCheck // Soft Check จาก Read Model (รองรับ Read-your-writes)
+	Catalog        ports.CatalogViewRepository
+	Orders         *app.OrderService
+	Inventory      ports.InventoryEventReader // ใช้ออก Consistency Token ให้ Order
 	TemporalClient client.Client
+	SagaMode       string           // orchestration = Start Workflow, choreography = วาง OrderPlaced ลง Stream
+	SagaOptions    core.SagaOptions // ส่งเข้า Workflow ทุกครั้ง (เช่น เส้นตายของ Order)
 }
 
-func NewOrderHandler(repo ports.ProductRepository, tClient client.Client) *OrderHandler {
-	return &OrderHandler{Repo: repo, TemporalClient: tClient}
+func NewOrderHandler(stock *app.SoftStockCheck, catalog ports.CatalogViewRepository, orders *app.OrderService, inventory ports.InventoryEventReader, tClient client.Client, sagaMode string, sagaOpts core.SagaOptions) *OrderHandler {
+	return &OrderHandler{Stock: stock, Catalog: catalog, Orders: orders, Inventory: inventory, TemporalClient: tClient, SagaMode: sagaMode, SagaOptions: sagaOpts}
 }
 
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
-	var req core.CreateOrderRequest
-	if err := c.BindJSON(&req); err != nil {
+	var body struct {
+		core.CreateOrderRequest
+		// Read-your-writes (Optional): Version ของ Stream สินค้าที่ Soft Check ต้องเห็นเป็นอย่างน้อย
+		MinVersion       int    `json:"min_version"`
+		ConsistencyToken string `json:"consistency_token"` // "<product_id>@<version>" จาก GET /orders/:order_id
+	}
+	if err := c.BindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Body"})
 		return
 	}
+	req := body.CreateOrderRequest
+	if req.Qty <= 0 || req.Qty > core.MaxOrderQty {
+		c.JSON(http.StatusBadRequest, gin.H{"error": core.ErrInvalidQty.Error()})
+		return
+	}
+
+	minVersion := body.MinVersion
+	if body.ConsistencyToken != "" {
+		token, err := core.ParseConsistencyToken(body.ConsistencyToken)
+		if err != nil || token.ProductID != req.ProductID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": core.ErrInvalidConsistencyToken.Error()})
+			return
+		}
+		minVersion = max(minVersion, token.Version)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// --- 0. PRICING (Server-side) ---
+	// ห้ามเชื่อยอดเงินจาก Client: คำนวณจากราคาใน Catalog x จำนวน
+	price, err := h.Catalog.GetCatalogView(ctx, req.ProductID)
+	if err != nil {
+		if errors.Is(err, core.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Load price failed"})
+		return
+	}
+	total, err := price.Total(req.Qty)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// amount จาก Client เป็นแค่ "ยอดที่คาดไว้" (Optional) ถ้าส่งมาต้องตรงกับราคาจริง
+	if req.Amount != 0 && req.Amount != total {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":         "Amount does not match current price",
+			"expected":      total,
+			"unit_price":    price.Price,
+			"price_version": price.LastVersion,
+		})
+		return
+	}
+	req.Amount = total
+
 	// --- 1. SOFT CHECK (Read Model) ---
-	product, err := h.Repo.GetProductView(ctx, req.ProductID)
-	fmt.Println("pruduct:", product)
+	product, stockSource, err := h.Stock.Read(ctx, req.ProductID, minVersion)
 	if err != nil {
+		if errors.Is(err, core.ErrStaleReadModel) {
+			// Projector ยังตามไม่ทัน Version ที่ Client เคยเห็น -> ให้ลองใหม่
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error(), "min_version": minVersion})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Check stock failed"})
 		return
 	}
 
-	// ถ้าของใน Read Model หมด -> Fail Fast
-	if product.AvailableStock < req.Qty {
+	// ถ้าของใน Read Model หมด -> Fail Fast (ถ้า Policy สั่งข้าม product จะเป็น nil)
+	if product != nil && product.AvailableStock < req.Qty {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":         "Out of stock (Soft check)",
 			"current_stock": product.AvailableStock,
+			"stock_version": product.LastVersion,
+			"stock_source":  stockSource,
+		})
+		return
+	}
+
+	// --- 2a. CHOREOGRAPHY: แค่วาง OrderPlaced ที่เหลือ Inventory / Payment จะรับต่อเอง ---
+	if h.SagaMode == core.SagaModeChoreography {
+		if err := h.Orders.Place(ctx, req, core.SagaModeChoreography); err != nil {
+			if errors.Is(err, core.ErrOrderExists) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to place order"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":      "Order placed",
+			"amount":       req.Amount,
+			"order_id":     req.OrderID,
+			"mode":         core.SagaModeChoreography,
+			"stock_source": stockSource,
 		})
 		return
 	}
 
-	// --- 2. START TEMPORAL WORKFLOW ---
+	// --- 2b. START TEMPORAL WORKFLOW ---
 	workflowOptions := client.StartWorkflowOptions{
-		ID:        "order-" + req.OrderID, // Business ID
-		TaskQueue: "order-queue",          // ชื่อคิวที่ Worker จะมารับงาน
+		ID:        core.OrderWorkflowID(req.OrderID), // Business ID
+		TaskQueue: contracts.QueueOrder,              // ชื่อคิวที่ Worker จะมารับงาน
 	}
 
 	// สั่งรัน Workflow ชื่อ "OrderSagaWorkflow"
-	// ส่งข้อมูล req เข้าไปประมวลผลต่อ
-	we, err := h.TemporalClient.ExecuteWorkflow(context.Background(), workflowOptions, "OrderSagaWorkflow", req)
+	// ส่งข้อมูล req + Config ของ Saga เข้าไปประมวลผลต่อ
+	we, err := h.TemporalClient.ExecuteWorkflow(context.Background(), workflowOptions, "OrderSagaWorkflow", req, h.SagaOptions)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start workflow"})
 		return
@@ -65,8 +148,70 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 
 	// ตอบกลับ 202 Accepted (รับเรื่องแล้ว)
 	c.JSON(http.StatusAccepted, gin.H{
-		"message":     "Order processing started",
-		"workflow_id": we.GetID(),
-		"run_id":      we.GetRunID(),
+		"message":      "Order processing started",
+		"amount":       req.Amount,
+		"workflow_id":  we.GetID(),
+		"run_id":       we.GetRunID(),
+		"stock_source": stockSource,
+	})
+}
+
+// GET /orders/:order_id -> สถานะของ Order จากการ Replay Order Stream
+func (h *OrderHandler) GetOrder(c *gin.Context) {
+	orderID := c.Param("order_id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	order, err := h.Orders.Load(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Load order failed"})
+		return
+	}
+	if order.LastVersion == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	// Consistency Token = Event ล่าสุดที่ Order นี้เขียนลง Stream สินค้า (จอง/คืน)
+	// Client ส่งกลับมากับ Order ถัดไปเพื่อให้ Soft Check เห็นผลของ Order นี้แน่ๆ
+	token, err := h.Inventory.LastTokenForOrder(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Load consistency token failed"})
+		return
+	}
+	resp := struct {
+		*core.OrderAggregate
+		ConsistencyToken string `json:"consistency_token,omitempty"`
+	}{OrderAggregate: order}
+	if token != nil {
+		resp.ConsistencyToken = token.String()
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DELETE /orders/:order_id -> ขอยกเลิก Order (Saga จะ Compensate แล้วบันทึก OrderCancelled)
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	orderID := c.Param("order_id")
+
+	// Choreography ไม่มีใครถือ State ของ Saga ไว้ให้สั่งยกเลิก
+	if h.SagaMode == core.SagaModeChoreography {
+		c.JSON(http.StatusConflict, gin.H{"error": "cancel is only supported in orchestration mode"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := h.TemporalClient.CancelWorkflow(ctx, core.OrderWorkflowID(orderID), "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel workflow"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  "Order cancellation requested",
+		"order_id": orderID,
 	})
 }
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"external-orchestrator/app"
+	"external-orchestrator/core"
+)
+
+type fakeCatalogViews map[string]core.CatalogView
+
+func (f fakeCatalogViews) GetCatalogView(ctx context.Context, productID string) (*core.CatalogView, error) {
+	view, ok := f[productID]
+	if !ok {
+		return nil, core.ErrProductNotFound
+	}
+	return &view, nil
+}
+
+type fakeProductViews struct{}
+
+func (fakeProductViews) GetProductView(ctx context.Context, productID string, minVersion int) (*core.ProductView, error) {
+	return &core.ProductView{ProductID: productID, AvailableStock: 100, LastVersion: 1}, nil
+}
+
+type fakeOrderStream struct {
+	events []core.OrderEvent
+}
+
+func (f *fakeOrderStream) GetEvents(ctx context.Context, orderID string) ([]core.OrderEvent, error) {
+	var out []core.OrderEvent
+	for _, e := range f.events {
+		if e.StreamID == orderID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOrderStream) AppendEvent(ctx context.Context, event core.OrderEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func TestCreateOrderPricesFromCatalog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantAmount int // ยอดที่ Order ถูกวางด้วย (0 = ต้องไม่มี Order)
+	}{
+		{name: "amount omitted uses the catalog total", body: `{"order_id": "ORD-1", "product_id": "airpods", "qty": 3}`, wantStatus: http.StatusAccepted, wantAmount: 14970},
+		{name: "matching amount", body: `{"order_id": "ORD-1", "product_id": "airpods", "qty": 2, "amount": 9980}`, wantStatus: http.StatusAccepted, wantAmount: 9980},
+		{name: "client amount below the price", body: `{"order_id": "ORD-1", "product_id": "airpods", "qty": 2, "amount": 1}`, wantStatus: http.StatusConflict},
+		{name: "unknown product", body: `{"order_id": "ORD-1", "product_id": "ipod", "qty": 1}`, wantStatus: http.StatusNotFound},
+		{name: "qty above the maximum", body: `{"order_id": "ORD-1", "product_id": "airpods", "qty": 10001}`, wantStatus: http.StatusBadRequest},
+		{name: "total overflows", body: `{"order_id": "ORD-1", "product_id": "gold-bar", "qty": 10000}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := &fakeOrderStream{}
+			catalog := fakeCatalogViews{
+				"airpods":  {ProductID: "airpods", Price: 4990, LastVersion: 2},
+				"gold-bar": {ProductID: "gold-bar", Price: math.MaxInt / 1000, LastVersion: 1},
+			}
+			h := NewOrderHandler(app.NewSoftStockCheck(fakeProductViews{}, nil, core.StaleViewWait, 0), catalog,
+				app.NewOrderService(orders), nil, nil, core.SagaModeChoreography, core.SagaOptions{})
+			r := gin.New()
+			r.POST("/orders", h.CreateOrder)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body)
+			}
+
+			if tt.wantAmount == 0 {
+				if len(orders.events) != 0 {
+					t.Fatalf("order placed with %+v, want none", orders.events)
+				}
+				return
+			}
+			var resp struct {
+				Amount int `json:"amount"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(orders.events) != 1 || orders.events[0].Amount != tt.wantAmount || resp.Amount != tt.wantAmount {
+				t.Fatalf("placed %+v, response amount %d; want amount %d", orders.events, resp.Amount, tt.wantAmount)
+			}
+		})
+	}
+}
+
+func TestCreateOrderConflictReportsCurrentTotal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	catalog := fakeCatalogViews{"airpods": {ProductID: "airpods", Price: 4990, LastVersion: 2}}
+	h := NewOrderHandler(app.NewSoftStockCheck(fakeProductViews{}, nil, core.StaleViewWait, 0), catalog,
+		app.NewOrderService(&fakeOrderStream{}), nil, nil, core.SagaModeChoreography, core.SagaOptions{})
+	r := gin.New()
+	r.POST("/orders", h.CreateOrder)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders",
+		strings.NewReader(`{"order_id": "ORD-1", "product_id": "airpods", "qty": 2, "amount": 11980}`)))
+
+	var resp struct {
+		Expected     int `json:"expected"`
+		UnitPrice    int `json:"unit_price"`
+		PriceVersion int `json:"price_version"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusConflict || resp.Expected != 9980 || resp.UnitPrice != 4990 || resp.PriceVersion != 2 {
+		t.Fatalf("status %d body %s, want 409 expected 9980 at 4990 (v.2)", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+)
+
+// OrderViewHandler ค้นหา Order จาก Read Model (orders_view) แทนการไล่อ่าน Event ดิบ
+type OrderViewHandler struct {
+	Orders ports.OrderViewRepository
+}
+
+func NewOrderViewHandler(orders ports.OrderViewRepository) *OrderViewHandler {
+	return &OrderViewHandler{Orders: orders}
+}
+
+// GET /orders?status=&product_id=&from=&to=&limit=&cursor=
+// from / to เป็น RFC3339 (ช่วง created_at: from <= t < to) เรียงใหม่สุดก่อน
+// next_cursor ว่าง = หน้าสุดท้าย
+func (h *OrderViewHandler) ListOrders(c *gin.Context) {
+	q, err := parseOrderQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// ขอเกินมา 1 ตัว เพื่อรู้ว่ามีหน้าถัดไปไหม
+	limit := q.Limit
+	q.Limit++
+	orders, err := h.Orders.ListOrders(ctx, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "List orders failed"})
+		return
+	}
+
+	nextCursor := ""
+	if len(orders) > limit {
+		orders = orders[:limit]
+		nextCursor = core.CursorAfter(orders[limit-1]).Encode()
+	}
+	c.JSON(http.StatusOK, gin.H{"orders": orders, "next_cursor": nextCursor})
+}
+
+func parseOrderQuery(c *gin.Context) (core.OrderQuery, error) {
+	q := core.OrderQuery{
+		Status:    strings.ToUpper(c.Query("status")),
+		ProductID: c.Query("product_id"),
+		Limit:     core.DefaultOrderPageSize,
+	}
+
+	var err error
+	if v := c.Query("from"); v != "" {
+		if q.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return q, errors.New("from must be RFC3339")
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if q.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return q, errors.New("to must be RFC3339")
+		}
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return q, errors.New("from must be before to")
+	}
+	if v := c.Query("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit < 1 || q.Limit > core.MaxOrderPageSize {
+			return q, errors.New("limit must be between 1 and " + strconv.Itoa(core.MaxOrderPageSize))
+		}
+	}
+	if v := c.Query("cursor"); v != "" {
+		if q.After, err = core.ParseOrderCursor(v); err != nil {
+			return q, err
+		}
+	}
+	return q, nil
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+)
+
+// SalesAnalyticsHandler อ่านยอดขายรายชั่วโมง / รายวันจาก Read Model (sales_analytics) แทนการไล่ Event Store
+type SalesAnalyticsHandler struct {
+	Sales ports.SalesAnalyticsRepository
+}
+
+func NewSalesAnalyticsHandler(sales ports.SalesAnalyticsRepository) *SalesAnalyticsHandler {
+	return &SalesAnalyticsHandler{Sales: sales}
+}
+
+// GET /products/:product_id/sales?granularity=hour|day&from=&to=
+// from / to เป็น RFC3339 หรือ YYYY-MM-DD (ต้นวันตาม UTC) ช่วง from <= bucket < to (to ไม่ส่ง = ตอนนี้)
+// ทุก Bucket ในช่วงถูกส่งกลับ (ไม่มียอด = 0) พร้อมผลรวม
+func (h *SalesAnalyticsHandler) GetSales(c *gin.Context) {
+	q, err := parseSalesQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	buckets, err := h.Sales.ListSalesBuckets(ctx, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Load sales analytics failed"})
+		return
+	}
+	c.JSON(http.StatusOK, core.FillSeries(q, buckets))
+}
+
+func parseSalesQuery(c *gin.Context) (core.SalesQuery, error) {
+	if c.Query("from") == "" {
+		return core.SalesQuery{}, errors.New("from is required")
+	}
+	from, err := parseSalesTime(c.Query("from"))
+	if err != nil {
+		return core.SalesQuery{}, errors.New("from must be RFC3339 or YYYY-MM-DD")
+	}
+	to := time.Now()
+	if v := c.Query("to"); v != "" {
+		if to, err = parseSalesTime(v); err != nil {
+			return core.SalesQuery{}, errors.New("to must be RFC3339 or YYYY-MM-DD")
+		}
+	}
+	return core.NewSalesQuery(c.Param("product_id"), c.Query("granularity"), from, to)
+}
+
+func parseSalesTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
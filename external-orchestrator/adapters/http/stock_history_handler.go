@@ -0,0 +1,82 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"external-orchestrator/app"
+	"external-orchestrator/core"
+)
+
+// StockHistoryHandler ดูยอดสต็อกย้อนหลัง (Replay events ถึงเวลาหรือ Version ที่ขอ)
+type StockHistoryHandler struct {
+	History *app.StockHistory
+}
+
+func NewStockHistoryHandler(history *app.StockHistory) *StockHistoryHandler {
+	return &StockHistoryHandler{History: history}
+}
+
+// GET /products/:product_id/stock?at=
+// at = RFC3339 / YYYY-MM-DD (สิ้นวันตาม UTC) / เลข Version (ไม่ส่ง = ล่าสุด)
+func (h *StockHistoryHandler) GetStock(c *gin.Context) {
+	at, err := core.ParsePointInTime(c.Query("at"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at: " + err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	snapshot, err := h.History.At(ctx, c.Param("product_id"), at)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// GET /products/:product_id/stock/diff?from=&to=
+// from / to รับรูปแบบเดียวกับ at (to ไม่ส่ง = ล่าสุด) ได้ยอดสองจุดพร้อม Event ที่อยู่ระหว่างกลาง
+func (h *StockHistoryHandler) DiffStock(c *gin.Context) {
+	if c.Query("from") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from is required"})
+		return
+	}
+	from, err := core.ParsePointInTime(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from: " + err.Error()})
+		return
+	}
+	to, err := core.ParsePointInTime(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to: " + err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	diff, err := h.History.Diff(ctx, c.Param("product_id"), from, to)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+func (h *StockHistoryHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, core.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case errors.Is(err, core.ErrInvalidStockRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Load stock history failed"})
+	}
+}
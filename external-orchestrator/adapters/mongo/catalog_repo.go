@@ -0,0 +1,70 @@
+package mongo
+
+import (
+	"context"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// --- Write Side: Event Store ของ Catalog ---
+
+type MongoCatalogRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoCatalogRepository(db *mongo.Database) ports.CatalogRepository {
+	return &MongoCatalogRepository{
+		Collection: db.Collection("catalog_events"),
+	}
+}
+
+func (r *MongoCatalogRepository) GetEvents(ctx context.Context, productID string) ([]core.CatalogEvent, error) {
+	filter := bson.M{"stream_id": productID}
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+
+	cursor, err := r.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []core.CatalogEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *MongoCatalogRepository) AppendEvent(ctx context.Context, event core.CatalogEvent) error {
+	_, err := r.Collection.InsertOne(ctx, event)
+	return err
+}
+
+// --- Read Side: catalog_view (Projector เป็นคนเขียน) ---
+
+type MongoCatalogViewRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoCatalogViewRepository(db *mongo.Database) ports.CatalogViewRepository {
+	return &MongoCatalogViewRepository{
+		Collection: db.Collection("catalog_view"),
+	}
+}
+
+func (r *MongoCatalogViewRepository) GetCatalogView(ctx context.Context, productID string) (*core.CatalogView, error) {
+	var view core.CatalogView
+
+	err := r.Collection.FindOne(ctx, bson.M{"product_id": productID}).Decode(&view)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, core.ErrProductNotFound
+		}
+		return nil, err
+	}
+	return &view, nil
+}
@@ -0,0 +1,88 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoInterventionRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoInterventionRepository(db *mongo.Database) ports.InterventionRepository {
+	return &MongoInterventionRepository{
+		Collection: db.Collection("saga_interventions"),
+	}
+}
+
+// Open ใช้ Upsert ด้วย OrderID เพื่อให้ Activity Retry ซ้ำได้โดยไม่เกิดเคสซ้อน
+func (r *MongoInterventionRepository) Open(ctx context.Context, in core.Intervention) error {
+	now := time.Now()
+	filter := bson.M{"_id": in.OrderID}
+	update := bson.M{
+		"$set": bson.M{
+			"workflow_id": in.WorkflowID,
+			"product_id":  in.ProductID,
+			"qty":         in.Qty,
+			"error":       in.Error,
+			"attempts":    in.Attempts,
+			"status":      core.InterventionOpen,
+			"updated_at":  now,
+		},
+		"$unset":       bson.M{"resolution": "", "operator": "", "resolved_at": ""},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+
+	_, err := r.Collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *MongoInterventionRepository) Resolve(ctx context.Context, orderID, resolution, operator string) error {
+	now := time.Now()
+	_, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": orderID},
+		bson.M{"$set": bson.M{
+			"status":      core.InterventionResolved,
+			"resolution":  resolution,
+			"operator":    operator,
+			"resolved_at": now,
+			"updated_at":  now,
+		}},
+	)
+	return err
+}
+
+func (r *MongoInterventionRepository) ListOpen(ctx context.Context) ([]core.Intervention, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}) // เคสเก่าสุดขึ้นก่อน
+
+	cursor, err := r.Collection.Find(ctx, bson.M{"status": core.InterventionOpen}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []core.Intervention{}
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *MongoInterventionRepository) Get(ctx context.Context, orderID string) (*core.Intervention, error) {
+	var item core.Intervention
+	err := r.Collection.FindOne(ctx, bson.M{"_id": orderID}).Decode(&item)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, core.ErrInterventionNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
@@ -0,0 +1,71 @@
+package mongo
+
+import (
+	"context"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoInventoryEventReader struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoInventoryEventReader(db *mongo.Database) ports.InventoryEventReader {
+	return &MongoInventoryEventReader{
+		Collection: db.Collection("events"), // อ่านอย่างเดียว (Inventory เป็นเจ้าของ)
+	}
+}
+
+func (r *MongoInventoryEventReader) ReplayProductView(ctx context.Context, productID string) (*core.ProductView, error) {
+	events, err := r.GetEventsUntil(ctx, productID, core.PointInTime{})
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, core.ErrProductNotFound
+	}
+
+	// กติกาเดียวกับ Projector (products_view)
+	view := core.ReplayStock(productID, events)
+	return &view, nil
+}
+
+func (r *MongoInventoryEventReader) GetEventsUntil(ctx context.Context, productID string, until core.PointInTime) ([]core.InventoryEvent, error) {
+	filter := bson.M{"stream_id": productID}
+	switch {
+	case until.Version > 0:
+		filter["version"] = bson.M{"$lte": until.Version}
+	case !until.At.IsZero():
+		filter["timestamp"] = bson.M{"$lte": until.At}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+	cursor, err := r.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var events []core.InventoryEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	// Filter ตามเวลาอาจได้ Event ที่ข้าม Version มา (Timestamp ย้อน) -> ตัดให้เหลือช่วงต่อเนื่องจากต้น Stream
+	return until.Until(events), nil
+}
+
+func (r *MongoInventoryEventReader) LastTokenForOrder(ctx context.Context, orderID string) (*core.ConsistencyToken, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+	var evt core.InventoryEvent
+	err := r.Collection.FindOne(ctx, bson.M{"order_id": orderID}, opts).Decode(&evt)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &core.ConsistencyToken{ProductID: evt.ProductID, Version: evt.Version}, nil
+}
@@ -0,0 +1,45 @@
+package mongo
+
+import (
+	"context"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoOrderRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoOrderRepository(db *mongo.Database) ports.OrderRepository {
+	return &MongoOrderRepository{
+		Collection: db.Collection("order_events"), // Event Store ของ Order
+	}
+}
+
+func (r *MongoOrderRepository) GetEvents(ctx context.Context, orderID string) ([]core.OrderEvent, error) {
+	filter := bson.M{"stream_id": orderID}
+	// Replay ต้องเรียงตาม Version เสมอ
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+
+	cursor, err := r.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []core.OrderEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *MongoOrderRepository) AppendEvent(ctx context.Context, event core.OrderEvent) error {
+	// Unique Index (stream_id + version) จะกันไม่ให้ 2 คนเขียน Version เดียวกัน
+	_, err := r.Collection.InsertOne(ctx, event)
+	return err
+}
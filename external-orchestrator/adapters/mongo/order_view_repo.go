@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"context"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoOrderViewRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoOrderViewRepository(db *mongo.Database) ports.OrderViewRepository {
+	return &MongoOrderViewRepository{
+		Collection: db.Collection("orders_view"), // อ่านจาก Read Model (Projector เป็นคนเขียน)
+	}
+}
+
+func (r *MongoOrderViewRepository) ListOrders(ctx context.Context, q core.OrderQuery) ([]core.OrderView, error) {
+	filter := bson.M{}
+	if q.Status != "" {
+		filter["status"] = q.Status
+	}
+	if q.ProductID != "" {
+		filter["items.product_id"] = q.ProductID
+	}
+	createdAt := bson.M{}
+	if !q.From.IsZero() {
+		createdAt["$gte"] = q.From
+	}
+	if !q.To.IsZero() {
+		createdAt["$lt"] = q.To
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+	// Keyset: ถัดจาก (created_at, order_id) ของตัวสุดท้ายในหน้าก่อน ตามลำดับใหม่ -> เก่า
+	if q.After != nil {
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": q.After.CreatedAt}},
+			bson.M{"created_at": q.After.CreatedAt, "order_id": bson.M{"$lt": q.After.OrderID}},
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "order_id", Value: -1}}).
+		SetLimit(int64(q.Limit))
+	cursor, err := r.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	orders := []core.OrderView{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
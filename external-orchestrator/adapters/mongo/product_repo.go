@@ -5,6 +5,7 @@ import (
 	"errors"
 	"external-orchestrator/core"
 	"external-orchestrator/ports"
+	"fmt"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -21,7 +22,7 @@ func NewMongoProductRepository(db *mongo.Database) ports.ProductRepository {
 	}
 }
 
-func (r *MongoProductRepository) GetProductView(ctx context.Context, productID string) (*core.ProductView, error) {
+func (r *MongoProductRepository) GetProductView(ctx context.Context, productID string, minVersion int) (*core.ProductView, error) {
 	var product core.ProductView
 
 	// Query หา ID สินค้า
@@ -29,10 +30,17 @@ func (r *MongoProductRepository) GetProductView(ctx context.Context, productID s
 
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
+			// ผู้เรียกรู้ว่าสินค้านี้มี Event แล้ว แต่ Projector ยังสร้าง View ไม่ทัน
+			if minVersion > 0 {
+				return nil, fmt.Errorf("%w: %s has no view yet, want v.%d", core.ErrStaleReadModel, productID, minVersion)
+			}
 			return nil, errors.New("product not found")
 		}
 		return nil, err
 	}
 
+	if product.LastVersion < minVersion {
+		return &product, fmt.Errorf("%w: %s at v.%d, want v.%d", core.ErrStaleReadModel, productID, product.LastVersion, minVersion)
+	}
 	return &product, nil
 }
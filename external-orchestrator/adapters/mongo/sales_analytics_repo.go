@@ -0,0 +1,38 @@
+package mongo
+
+import (
+	"context"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoSalesAnalyticsRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoSalesAnalyticsRepository(db *mongo.Database) ports.SalesAnalyticsRepository {
+	return &MongoSalesAnalyticsRepository{
+		Collection: db.Collection("sales_analytics"), // อ่านจาก Read Model (Projector เป็นคนเขียน)
+	}
+}
+
+func (r *MongoSalesAnalyticsRepository) ListSalesBuckets(ctx context.Context, q core.SalesQuery) ([]core.SalesBucket, error) {
+	filter := bson.M{
+		"product_id":  q.ProductID,
+		"granularity": q.Granularity,
+		"bucket":      bson.M{"$gte": q.From, "$lt": q.To},
+	}
+	cursor, err := r.Collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	buckets := []core.SalesBucket{}
+	if err := cursor.All(ctx, &buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
@@ -0,0 +1,42 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+
+	"go.temporal.io/sdk/temporal"
+
+	"external-orchestrator/app"
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+)
+
+// SagaActivities คือ Activity ฝั่ง Orchestrator เอง (รันบน contracts.QueueOrder)
+type SagaActivities struct {
+	Interventions ports.InterventionRepository
+	Orders        *app.OrderService
+}
+
+func NewSagaActivities(interventions ports.InterventionRepository, orders *app.OrderService) *SagaActivities {
+	return &SagaActivities{Interventions: interventions, Orders: orders}
+}
+
+// Activity: เปิดเคสเข้าคิว Manual Intervention (เรียกซ้ำได้ จะอัปเดตเคสเดิม)
+func (a *SagaActivities) OpenIntervention(ctx context.Context, intervention core.Intervention) error {
+	return a.Interventions.Open(ctx, intervention)
+}
+
+// Activity: ปิดเคสเมื่อคืนของสำเร็จ หรือ Operator ยืนยันว่าแก้เองแล้ว
+func (a *SagaActivities) ResolveIntervention(ctx context.Context, orderID, resolution, operator string) error {
+	return a.Interventions.Resolve(ctx, orderID, resolution, operator)
+}
+
+// Activity: บันทึก Event ลง Order Stream ตาม Step ของ Saga
+func (a *SagaActivities) RecordOrderEvent(ctx context.Context, event core.OrderEvent) error {
+	err := a.Orders.Record(ctx, event)
+	// ผิดลำดับ = Bug ไม่ต้อง Retry
+	if errors.Is(err, core.ErrInvalidOrderTransition) {
+		return temporal.NewNonRetryableApplicationError(err.Error(), "InvalidOrderTransition", err)
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+)
+
+var ErrConcurrency = errors.New("concurrency error: please retry")
+
+// OrderService คือ Use Case ของ Order Stream ที่ใช้ร่วมกันทั้ง Saga Activity (Orchestration)
+// และ OrderTracker / HTTP Handler (Choreography)
+type OrderService struct {
+	Orders ports.OrderRepository
+}
+
+func NewOrderService(orders ports.OrderRepository) *OrderService {
+	return &OrderService{Orders: orders}
+}
+
+// Load Replay Order Stream ออกมาเป็น Aggregate
+func (s *OrderService) Load(ctx context.Context, orderID string) (*core.OrderAggregate, error) {
+	events, err := s.Orders.GetEvents(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	agg := core.NewOrderAggregate(orderID)
+	agg.Replay(events)
+	return agg, nil
+}
+
+// Place เปิด Order Stream ใหม่ด้วย OrderPlaced (ใช้ตอนรับ Order ในโหมด Choreography)
+// ต่างจาก Record ตรงที่ Order ซ้ำถือเป็น Error (core.ErrOrderExists)
+func (s *OrderService) Place(ctx context.Context, req core.CreateOrderRequest, mode string) error {
+	agg, err := s.Load(ctx, req.OrderID)
+	if err != nil {
+		return err
+	}
+	if agg.LastVersion > 0 {
+		return core.ErrOrderExists
+	}
+
+	err = s.Orders.AppendEvent(ctx, core.OrderEvent{
+		Version:   1,
+		StreamID:  req.OrderID,
+		Type:      core.EventOrderPlaced,
+		ProductID: req.ProductID,
+		Qty:       req.Qty,
+		Amount:    req.Amount,
+		Mode:      mode,
+		Timestamp: time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return core.ErrOrderExists // มีคนวาง Order เดียวกันตัดหน้า
+	}
+	return err
+}
+
+// Record บันทึก Event ลง Order Stream
+// ถ้า Event ชนิดนี้เคยถูกบันทึกแล้วจะถือว่าสำเร็จ (Idempotent)
+// ผิดลำดับ -> core.ErrInvalidOrderTransition, Version ชน -> ErrConcurrency
+func (s *OrderService) Record(ctx context.Context, event core.OrderEvent) error {
+	// 1. Replay
+	agg, err := s.Load(ctx, event.StreamID)
+	if err != nil {
+		return err
+	}
+
+	// 2. Idempotency: ผู้เรียกอาจ Retry หลังเขียนสำเร็จไปแล้ว -> ถือว่าสำเร็จ
+	if agg.HasRecorded(event.Type) {
+		return nil
+	}
+
+	// 3. Validate Transition
+	if err := agg.CanApply(event.Type); err != nil {
+		return err
+	}
+
+	// 4. Append (Optimistic Concurrency ด้วย Version)
+	event.Version = agg.LastVersion + 1
+	event.Timestamp = time.Now()
+
+	if err := s.Orders.AppendEvent(ctx, event); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrConcurrency
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"external-orchestrator/core"
+)
+
+// fakeOrders คือ order_events ใน RAM ที่มี Unique Index (stream_id + version) เหมือนของจริง
+type fakeOrders struct {
+	events []core.OrderEvent
+	// racer: Event ที่ตัวอื่นเขียนแทรกเข้ามาหลังเรา Load แต่ก่อนเรา Append
+	racer *core.OrderEvent
+}
+
+func (f *fakeOrders) GetEvents(ctx context.Context, orderID string) ([]core.OrderEvent, error) {
+	var out []core.OrderEvent
+	for _, e := range f.events {
+		if e.StreamID == orderID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOrders) AppendEvent(ctx context.Context, event core.OrderEvent) error {
+	if f.racer != nil {
+		f.events = append(f.events, *f.racer)
+		f.racer = nil
+	}
+	for _, e := range f.events {
+		if e.StreamID == event.StreamID && e.Version == event.Version {
+			return mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "duplicate key"}}}
+		}
+	}
+	f.events = append(f.events, event)
+	return nil
+}
+
+func TestOrderServiceRecord(t *testing.T) {
+	ctx := context.Background()
+	orders := &fakeOrders{}
+	svc := NewOrderService(orders)
+
+	for _, typ := range []string{core.EventOrderPlaced, core.EventStockReserved} {
+		if err := svc.Record(ctx, core.OrderEvent{StreamID: "ORD-1", Type: typ}); err != nil {
+			t.Fatalf("record %s: %v", typ, err)
+		}
+	}
+
+	// Activity Retry หลังเขียนสำเร็จไปแล้ว -> สำเร็จโดยไม่เขียนเพิ่ม
+	if err := svc.Record(ctx, core.OrderEvent{StreamID: "ORD-1", Type: core.EventStockReserved}); err != nil {
+		t.Fatalf("repeated record: %v", err)
+	}
+	if len(orders.events) != 2 || orders.events[1].Version != 2 {
+		t.Fatalf("events = %+v, want 2 with versions 1..2", orders.events)
+	}
+
+	if err := svc.Record(ctx, core.OrderEvent{StreamID: "ORD-1", Type: core.EventOrderCompleted}); !errors.Is(err, core.ErrInvalidOrderTransition) {
+		t.Fatalf("completed before payment: err = %v, want ErrInvalidOrderTransition", err)
+	}
+
+	agg, err := svc.Load(ctx, "ORD-1")
+	if err != nil || agg.Status != core.OrderStatusStockReserved || agg.LastVersion != 2 {
+		t.Fatalf("load = %+v, %v", agg, err)
+	}
+}
+
+func TestOrderServiceRecordConcurrency(t *testing.T) {
+	orders := &fakeOrders{
+		events: []core.OrderEvent{{StreamID: "ORD-1", Version: 1, Type: core.EventOrderPlaced}},
+		racer:  &core.OrderEvent{StreamID: "ORD-1", Version: 2, Type: core.EventOrderCancelled},
+	}
+	svc := NewOrderService(orders)
+
+	err := svc.Record(context.Background(), core.OrderEvent{StreamID: "ORD-1", Type: core.EventStockReserved})
+	if !errors.Is(err, ErrConcurrency) {
+		t.Fatalf("err = %v, want ErrConcurrency", err)
+	}
+	if len(orders.events) != 2 || orders.events[1].Type != core.EventOrderCancelled {
+		t.Fatalf("losing write must not be stored: %+v", orders.events)
+	}
+}
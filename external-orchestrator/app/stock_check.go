@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+)
+
+// แหล่งที่มาของยอดสต็อกที่ใช้ทำ Soft Check
+const (
+	StockSourceReadModel  = "read_model"  // products_view
+	StockSourceEventStore = "event_store" // Replay จาก events
+	StockSourceSkipped    = "skipped"     // ไม่ได้เช็ค (ให้ Hard Check ตัดสิน)
+)
+
+// SoftStockCheck อ่านยอดสต็อกสำหรับ Soft Check แบบ Read-your-writes
+// ถ้าผู้เรียกส่ง minVersion มา และ Read Model ยังตามไม่ทัน จะทำตาม Policy
+type SoftStockCheck struct {
+	Views        ports.ProductRepository
+	Events       ports.InventoryEventReader
+	Policy       string        // core.StaleViewWait / StaleViewSkip / StaleViewReplay
+	WaitTimeout  time.Duration // ใช้กับ Policy "wait"
+	PollInterval time.Duration
+}
+
+func NewSoftStockCheck(views ports.ProductRepository, events ports.InventoryEventReader, policy string, waitTimeout time.Duration) *SoftStockCheck {
+	return &SoftStockCheck{
+		Views:        views,
+		Events:       events,
+		Policy:       policy,
+		WaitTimeout:  waitTimeout,
+		PollInterval: 100 * time.Millisecond,
+	}
+}
+
+// Read คืนยอดสต็อกพร้อมบอกว่ามาจากไหน (View = nil เมื่อ Source = skipped)
+func (c *SoftStockCheck) Read(ctx context.Context, productID string, minVersion int) (*core.ProductView, string, error) {
+	view, err := c.Views.GetProductView(ctx, productID, minVersion)
+	if !errors.Is(err, core.ErrStaleReadModel) {
+		return view, StockSourceReadModel, err
+	}
+
+	switch c.Policy {
+	case core.StaleViewSkip:
+		return nil, StockSourceSkipped, nil
+
+	case core.StaleViewReplay:
+		view, err := c.Events.ReplayProductView(ctx, productID)
+		return view, StockSourceEventStore, err
+
+	default: // core.StaleViewWait
+		waitCtx, cancel := context.WithTimeout(ctx, c.WaitTimeout)
+		defer cancel()
+		for {
+			select {
+			case <-waitCtx.Done():
+				return nil, StockSourceReadModel, err // ยังเป็น ErrStaleReadModel ตัวล่าสุด
+			case <-time.After(c.PollInterval):
+			}
+			view, err = c.Views.GetProductView(ctx, productID, minVersion)
+			if !errors.Is(err, core.ErrStaleReadModel) {
+				return view, StockSourceReadModel, err
+			}
+		}
+	}
+}
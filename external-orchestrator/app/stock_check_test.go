@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"external-orchestrator/core"
+)
+
+// fakeViews คือ products_view ที่ Projector ค่อยๆ ตามทัน (ขยับ 1 Version ทุกครั้งที่ถูกอ่าน)
+type fakeViews struct {
+	mu      sync.Mutex
+	view    core.ProductView
+	catchUp bool
+}
+
+func (f *fakeViews) GetProductView(ctx context.Context, productID string, minVersion int) (*core.ProductView, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	view := f.view
+	if f.catchUp {
+		f.view.LastVersion++
+	}
+	if view.LastVersion < minVersion {
+		return &view, core.ErrStaleReadModel
+	}
+	return &view, nil
+}
+
+type fakeEvents struct {
+	view   core.ProductView
+	events []core.InventoryEvent
+}
+
+func (f *fakeEvents) ReplayProductView(ctx context.Context, productID string) (*core.ProductView, error) {
+	view := f.view
+	return &view, nil
+}
+
+func (f *fakeEvents) GetEventsUntil(ctx context.Context, productID string, until core.PointInTime) ([]core.InventoryEvent, error) {
+	return until.Until(f.events), nil
+}
+
+func (f *fakeEvents) LastTokenForOrder(ctx context.Context, orderID string) (*core.ConsistencyToken, error) {
+	return nil, nil
+}
+
+func TestSoftStockCheckStaleViewPolicies(t *testing.T) {
+	stale := core.ProductView{ProductID: "iphone-15", AvailableStock: 100, LastVersion: 3}
+	fresh := core.ProductView{ProductID: "iphone-15", AvailableStock: 99, LastVersion: 5}
+
+	tests := []struct {
+		name       string
+		policy     string
+		catchUp    bool
+		wantSource string
+		wantStock  int
+		wantErr    error
+	}{
+		{"wait until projector catches up", core.StaleViewWait, true, StockSourceReadModel, 100, nil},
+		{"wait times out", core.StaleViewWait, false, StockSourceReadModel, 0, core.ErrStaleReadModel},
+		{"skip soft check", core.StaleViewSkip, false, StockSourceSkipped, 0, nil},
+		{"replay event store", core.StaleViewReplay, false, StockSourceEventStore, 99, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := NewSoftStockCheck(&fakeViews{view: stale, catchUp: tt.catchUp}, &fakeEvents{view: fresh}, tt.policy, 200*time.Millisecond)
+			check.PollInterval = 10 * time.Millisecond
+
+			view, source, err := check.Read(context.Background(), "iphone-15", 5)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if source != tt.wantSource {
+				t.Fatalf("source = %s, want %s", source, tt.wantSource)
+			}
+			stock := 0
+			if view != nil {
+				stock = view.AvailableStock
+			}
+			if stock != tt.wantStock {
+				t.Fatalf("stock = %d, want %d", stock, tt.wantStock)
+			}
+		})
+	}
+}
+
+func TestParseConsistencyToken(t *testing.T) {
+	token, err := core.ParseConsistencyToken("iphone-15@42")
+	if err != nil || token != (core.ConsistencyToken{ProductID: "iphone-15", Version: 42}) {
+		t.Fatalf("ParseConsistencyToken = %+v, %v", token, err)
+	}
+	for _, bad := range []string{"", "iphone-15", "@3", "iphone-15@x", "iphone-15@-1"} {
+		if _, err := core.ParseConsistencyToken(bad); !errors.Is(err, core.ErrInvalidConsistencyToken) {
+			t.Fatalf("ParseConsistencyToken(%q) err = %v", bad, err)
+		}
+	}
+}
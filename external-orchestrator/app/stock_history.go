@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+
+	"external-orchestrator/core"
+	"external-orchestrator/ports"
+)
+
+// StockHistory ตอบว่า "สต็อกเคยเป็นเท่าไหร่" ณ เวลาหรือ Version หนึ่ง
+// Replay จาก Event Store (events) ตรงๆ ไม่ใช้ products_view เพราะ Read Model เก็บแค่ยอดล่าสุด
+type StockHistory struct {
+	Events ports.InventoryEventReader
+}
+
+func NewStockHistory(events ports.InventoryEventReader) *StockHistory {
+	return &StockHistory{Events: events}
+}
+
+// At คืนยอด ณ point (สินค้าที่ยังไม่เกิด ณ จุดนั้นได้ยอด 0 ส่วนสินค้าที่ไม่เคยมีเลยได้ core.ErrProductNotFound)
+func (h *StockHistory) At(ctx context.Context, productID string, point core.PointInTime) (*core.StockSnapshot, error) {
+	events, err := h.Events.GetEventsUntil(ctx, productID, point)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.mustExist(ctx, productID, events); err != nil {
+		return nil, err
+	}
+	snapshot := core.SnapshotAt(productID, events, point)
+	return &snapshot, nil
+}
+
+// Diff คืนยอดที่ from และ to พร้อม Event ที่ทำให้ยอดเปลี่ยนระหว่างสองจุด
+func (h *StockHistory) Diff(ctx context.Context, productID string, from, to core.PointInTime) (*core.StockDiff, error) {
+	events, err := h.Events.GetEventsUntil(ctx, productID, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.mustExist(ctx, productID, events); err != nil {
+		return nil, err
+	}
+	diff, err := core.DiffStock(productID, events, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// mustExist แยก "ยังไม่มี ณ จุดนั้น" ออกจาก "ไม่มีสินค้านี้" (อ่านซ้ำเฉพาะกรณีไม่เจอ Event)
+func (h *StockHistory) mustExist(ctx context.Context, productID string, events []core.InventoryEvent) error {
+	if len(events) > 0 {
+		return nil
+	}
+	all, err := h.Events.GetEventsUntil(ctx, productID, core.PointInTime{})
+	if err != nil {
+		return err
+	}
+	if len(all) == 0 {
+		return core.ErrProductNotFound
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"external-orchestrator/core"
+)
+
+func TestStockHistoryTellsNotYetCreatedFromUnknown(t *testing.T) {
+	events := &fakeEvents{events: []core.InventoryEvent{
+		{ProductID: "iphone-15", Type: "StockAdded", Qty: 10, Version: 1, Timestamp: time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)},
+	}}
+	history := NewStockHistory(events)
+	august := core.PointInTime{At: time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC)}
+
+	// มีสินค้าแล้วแต่ยังไม่เกิด ณ จุดนั้น -> ยอด 0
+	got, err := history.At(context.Background(), "iphone-15", august)
+	if err != nil || got.AvailableStock != 0 || got.LastVersion != 0 {
+		t.Fatalf("At(august) = %+v, %v", got, err)
+	}
+
+	// ไม่เคยมี Event เลย -> 404
+	events.events = nil
+	if _, err := history.At(context.Background(), "ghost", august); !errors.Is(err, core.ErrProductNotFound) {
+		t.Fatalf("err = %v, want ErrProductNotFound", err)
+	}
+}
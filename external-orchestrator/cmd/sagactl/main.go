@@ -0,0 +1,187 @@
+// sagactl คือ CLI สำหรับ Operator จัดการ Saga ที่ค้างอยู่ในคิว Manual Intervention
+//
+//	sagactl list
+//	sagactl retry <order_id> [note]
+//	sagactl force-release <order_id> [note]
+//	sagactl resolve <order_id> [note]
+//	sagactl stock <product_id> [at]
+//	sagactl stock-diff <product_id> <from> [to]
+//
+// at / from / to = RFC3339, YYYY-MM-DD (สิ้นวันตาม UTC) หรือเลข Version
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"external-orchestrator/core"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	baseURL := strings.TrimRight(getEnv("ORCHESTRATOR_URL", "http://localhost:8080"), "/")
+	operator := getEnv("SAGACTL_OPERATOR", getEnv("USER", "cli"))
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+
+	switch cmd := os.Args[1]; cmd {
+	case "list":
+		listStuck(httpClient, baseURL)
+	case core.ActionRetry, core.ActionForceRelease, core.ActionResolve:
+		if len(os.Args) < 3 {
+			usage()
+		}
+		note := strings.Join(os.Args[3:], " ")
+		sendAction(httpClient, baseURL, cmd, os.Args[2], operator, note)
+	case "stock":
+		if len(os.Args) < 3 {
+			usage()
+		}
+		showStock(httpClient, baseURL, os.Args[2], argAt(3))
+	case "stock-diff":
+		if len(os.Args) < 4 {
+			usage()
+		}
+		showStockDiff(httpClient, baseURL, os.Args[2], os.Args[3], argAt(4))
+	default:
+		usage()
+	}
+}
+
+func listStuck(httpClient *http.Client, baseURL string) {
+	resp, err := httpClient.Get(baseURL + "/admin/sagas/stuck")
+	if err != nil {
+		log.Fatal("❌ Request failed: ", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Sagas []core.Intervention `json:"sagas"`
+	}
+	if err := decode(resp, &body); err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	if len(body.Sagas) == 0 {
+		fmt.Println("✅ No stuck sagas.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ORDER\tPRODUCT\tQTY\tATTEMPTS\tSINCE\tERROR")
+	for _, s := range body.Sagas {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\n",
+			s.OrderID, s.ProductID, s.Qty, s.Attempts, s.CreatedAt.Format(time.RFC3339), s.Error)
+	}
+	tw.Flush()
+}
+
+func sendAction(httpClient *http.Client, baseURL, action, orderID, operator, note string) {
+	payload, _ := json.Marshal(map[string]string{"operator": operator, "note": note})
+	url := fmt.Sprintf("%s/admin/sagas/%s/%s", baseURL, orderID, action)
+
+	resp, err := httpClient.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		log.Fatal("❌ Request failed: ", err)
+	}
+	defer resp.Body.Close()
+
+	var body map[string]interface{}
+	if err := decode(resp, &body); err != nil {
+		log.Fatal("❌ ", err)
+	}
+	fmt.Printf("📨 %s sent to %v\n", action, body["workflow_id"])
+}
+
+func showStock(httpClient *http.Client, baseURL, productID, at string) {
+	var snapshot core.StockSnapshot
+	getJSON(httpClient, fmt.Sprintf("%s/products/%s/stock?%s", baseURL, url.PathEscape(productID), url.Values{"at": {at}}.Encode()), &snapshot)
+
+	fmt.Printf("📦 %s at %s (v.%d)\n", productID, snapshot.Point, snapshot.LastVersion)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ON_HAND\tRESERVED\tCOMMITTED\tAVAILABLE")
+	fmt.Fprintf(tw, "%d\t%d\t%d\t%d\n", snapshot.OnHand, snapshot.Reserved, snapshot.Committed, snapshot.AvailableStock)
+	tw.Flush()
+}
+
+func showStockDiff(httpClient *http.Client, baseURL, productID, from, to string) {
+	var diff core.StockDiff
+	query := url.Values{"from": {from}, "to": {to}}.Encode()
+	getJSON(httpClient, fmt.Sprintf("%s/products/%s/stock/diff?%s", baseURL, url.PathEscape(productID), query), &diff)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tVERSION\tON_HAND\tRESERVED\tCOMMITTED\tAVAILABLE")
+	for _, s := range []core.StockSnapshot{diff.From, diff.To} {
+		fmt.Fprintf(tw, "%s\tv.%d\t%d\t%d\t%d\t%d\n", s.Point, s.LastVersion, s.OnHand, s.Reserved, s.Committed, s.AvailableStock)
+	}
+	c := diff.Change
+	fmt.Fprintf(tw, "change\t\t%+d\t%+d\t%+d\t%+d\n", c.OnHand, c.Reserved, c.Committed, c.AvailableStock)
+	tw.Flush()
+
+	if len(diff.Events) == 0 {
+		fmt.Println("\n✅ No events between the two points.")
+		return
+	}
+	fmt.Printf("\n📜 %d event(s):\n", len(diff.Events))
+	tw = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tTIME\tTYPE\tQTY\tORDER")
+	for _, e := range diff.Events {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\n", e.Version, e.Timestamp.Format(time.RFC3339), e.Type, e.Qty, e.OrderID)
+	}
+	tw.Flush()
+}
+
+func getJSON(httpClient *http.Client, target string, out interface{}) {
+	resp, err := httpClient.Get(target)
+	if err != nil {
+		log.Fatal("❌ Request failed: ", err)
+	}
+	defer resp.Body.Close()
+	if err := decode(resp, out); err != nil {
+		log.Fatal("❌ ", err)
+	}
+}
+
+// argAt คืน Argument ตำแหน่ง i (ไม่มี = "")
+func argAt(i int) string {
+	if len(os.Args) > i {
+		return os.Args[i]
+	}
+	return ""
+}
+
+// decode อ่าน Body แล้วแปลง Error ของ API ให้อ่านง่าย
+func decode(resp *http.Response, out interface{}) error {
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: sagactl list | retry <order_id> [note] | force-release <order_id> [note] | resolve <order_id> [note]")
+	fmt.Fprintln(os.Stderr, "       sagactl stock <product_id> [at] | stock-diff <product_id> <from> [to]")
+	os.Exit(2)
+}
+
+func getEnv(key, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"external-orchestrator/core"
+)
+
+// stepPolicyFile คือรูปแบบของไฟล์ SAGA_POLICY_FILE (JSON, ระยะเวลาเขียนแบบ "30s", "2m")
+type stepPolicyFile struct {
+	StartToCloseTimeout    string   `json:"start_to_close_timeout"`
+	HeartbeatTimeout       string   `json:"heartbeat_timeout"`
+	InitialInterval        string   `json:"initial_interval"`
+	BackoffCoefficient     float64  `json:"backoff_coefficient"`
+	MaximumInterval        string   `json:"maximum_interval"`
+	MaximumAttempts        int32    `json:"maximum_attempts"` // -1 = ไม่จำกัด
+	NonRetryableErrorTypes []string `json:"non_retryable_error_types"`
+}
+
+// loadStepPolicies อ่าน Policy ของแต่ละ Step จากไฟล์ (path ว่าง = ใช้ค่า Default ทั้งหมด)
+func loadStepPolicies(path string) (core.StepPolicies, error) {
+	var policies core.StepPolicies
+	if path == "" {
+		return policies, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return policies, err
+	}
+	var file struct {
+		Reserve      stepPolicyFile `json:"reserve"`
+		Payment      stepPolicyFile `json:"payment"`
+		Compensation stepPolicyFile `json:"compensation"`
+		Commit       stepPolicyFile `json:"commit"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields() // พิมพ์ชื่อ Field ผิด = Error ไม่ใช่เงียบแล้วใช้ค่า Default
+	if err := dec.Decode(&file); err != nil {
+		return policies, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if policies.Reserve, err = file.Reserve.toPolicy(); err != nil {
+		return policies, fmt.Errorf("%s: reserve: %w", path, err)
+	}
+	if policies.Payment, err = file.Payment.toPolicy(); err != nil {
+		return policies, fmt.Errorf("%s: payment: %w", path, err)
+	}
+	if policies.Compensation, err = file.Compensation.toPolicy(); err != nil {
+		return policies, fmt.Errorf("%s: compensation: %w", path, err)
+	}
+	if policies.Commit, err = file.Commit.toPolicy(); err != nil {
+		return policies, fmt.Errorf("%s: commit: %w", path, err)
+	}
+	return policies, policies.Validate()
+}
+
+func (f stepPolicyFile) toPolicy() (core.StepPolicy, error) {
+	p := core.StepPolicy{
+		BackoffCoefficient:     f.BackoffCoefficient,
+		MaximumAttempts:        f.MaximumAttempts,
+		NonRetryableErrorTypes: f.NonRetryableErrorTypes,
+	}
+	durations := []struct {
+		value string
+		dst   *time.Duration
+	}{
+		{f.StartToCloseTimeout, &p.StartToCloseTimeout},
+		{f.HeartbeatTimeout, &p.HeartbeatTimeout},
+		{f.InitialInterval, &p.InitialInterval},
+		{f.MaximumInterval, &p.MaximumInterval},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return p, err
+		}
+		*d.dst = v
+	}
+	return p, nil
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ชื่อ Event ของ Product Catalog
+const (
+	EventProductCreated = "ProductCreated"
+	EventPriceChanged   = "PriceChanged"
+)
+
+// MaxOrderQty จำกัดจำนวนชิ้นต่อ Order (qty ใหญ่มากทำให้ราคา x จำนวนล้น int แล้วเลี่ยงการเช็คยอดเงินได้)
+const MaxOrderQty = 10000
+
+var (
+	ErrProductExists   = errors.New("product already exists")
+	ErrProductNotFound = errors.New("product not found")
+	ErrInvalidPrice    = errors.New("price must be greater than zero")
+	ErrInvalidQty      = fmt.Errorf("qty must be between 1 and %d", MaxOrderQty)
+	ErrTotalOverflow   = errors.New("order total is too large")
+)
+
+// CatalogEvent โครงสร้าง Event ที่เก็บลง MongoDB (collection: catalog_events)
+type CatalogEvent struct {
+	ID        string    `bson:"_id,omitempty"`
+	Version   int       `bson:"version"`
+	StreamID  string    `bson:"stream_id"` // Product ID
+	Type      string    `bson:"type"`
+	Name      string    `bson:"name,omitempty"`
+	Price     int       `bson:"price"` // ราคาต่อชิ้น (บาท)
+	Timestamp time.Time `bson:"timestamp"`
+}
+
+// ProductAggregate คือข้อมูลสินค้าใน Catalog (สร้างจากการ Replay)
+type ProductAggregate struct {
+	ProductID   string
+	Name        string
+	Price       int
+	LastVersion int
+}
+
+func NewProductAggregate(productID string) *ProductAggregate {
+	return &ProductAggregate{ProductID: productID}
+}
+
+func (a *ProductAggregate) Apply(event CatalogEvent) {
+	switch event.Type {
+	case EventProductCreated:
+		a.Name = event.Name
+		a.Price = event.Price
+	case EventPriceChanged:
+		a.Price = event.Price
+	}
+	a.LastVersion = event.Version
+}
+
+func (a *ProductAggregate) Replay(events []CatalogEvent) {
+	for _, evt := range events {
+		a.Apply(evt)
+	}
+}
+
+// Create สร้าง Event ProductCreated (สินค้าต้องยังไม่เคยมี)
+func (a *ProductAggregate) Create(name string, price int) (CatalogEvent, error) {
+	if a.LastVersion > 0 {
+		return CatalogEvent{}, ErrProductExists
+	}
+	if price <= 0 {
+		return CatalogEvent{}, ErrInvalidPrice
+	}
+	return a.newEvent(EventProductCreated, name, price), nil
+}
+
+// ChangePrice สร้าง Event PriceChanged (สินค้าต้องมีอยู่แล้ว)
+func (a *ProductAggregate) ChangePrice(price int) (CatalogEvent, error) {
+	if a.LastVersion == 0 {
+		return CatalogEvent{}, ErrProductNotFound
+	}
+	if price <= 0 {
+		return CatalogEvent{}, ErrInvalidPrice
+	}
+	return a.newEvent(EventPriceChanged, "", price), nil
+}
+
+func (a *ProductAggregate) newEvent(eventType, name string, price int) CatalogEvent {
+	return CatalogEvent{
+		StreamID:  a.ProductID,
+		Type:      eventType,
+		Name:      name,
+		Price:     price,
+		Timestamp: time.Now(),
+		Version:   a.LastVersion + 1,
+	}
+}
+
+// CatalogView คือ Read Model ของราคาสินค้า (collection: catalog_view)
+type CatalogView struct {
+	ProductID   string `bson:"product_id" json:"product_id"`
+	Name        string `bson:"name" json:"name"`
+	Price       int    `bson:"price" json:"price"`
+	LastVersion int    `bson:"last_version" json:"version"`
+}
+
+// Total คือยอดเงินของ Order ที่คิดจากราคานี้ (ราคา x จำนวน) เช็คก่อนคูณว่าไม่ล้น int
+func (v CatalogView) Total(qty int) (int, error) {
+	if qty <= 0 || qty > MaxOrderQty {
+		return 0, ErrInvalidQty
+	}
+	if v.Price > math.MaxInt/qty {
+		return 0, ErrTotalOverflow
+	}
+	return v.Price * qty, nil
+}
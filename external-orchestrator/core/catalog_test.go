@@ -0,0 +1,67 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCatalogViewTotalRejectsOverflowingQuantities(t *testing.T) {
+	tests := []struct {
+		name    string
+		price   int
+		qty     int
+		want    int
+		wantErr error
+	}{
+		{name: "price times qty", price: 35000, qty: 3, want: 105000},
+		{name: "largest qty", price: 1, qty: MaxOrderQty, want: MaxOrderQty},
+		{name: "zero qty", price: 35000, qty: 0, wantErr: ErrInvalidQty},
+		{name: "qty above the maximum", price: 1, qty: MaxOrderQty + 1, wantErr: ErrInvalidQty},
+		{name: "huge qty that would wrap to a small total", price: 2, qty: math.MaxInt/2 + 2, wantErr: ErrInvalidQty},
+		{name: "price times qty overflows", price: math.MaxInt / 2, qty: 3, wantErr: ErrTotalOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CatalogView{Price: tt.price}.Total(tt.qty)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Fatalf("Total(%d) = %d, %v; want %d, %v", tt.qty, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProductAggregateCreateAndChangePriceRules(t *testing.T) {
+	created := CatalogEvent{StreamID: "airpods", Type: EventProductCreated, Name: "AirPods", Price: 5990, Version: 1}
+
+	tests := []struct {
+		name        string
+		history     []CatalogEvent
+		decide      func(*ProductAggregate) (CatalogEvent, error)
+		wantErr     error
+		wantType    string
+		wantVersion int
+	}{
+		{name: "create new product", decide: func(a *ProductAggregate) (CatalogEvent, error) { return a.Create("AirPods", 5990) }, wantType: EventProductCreated, wantVersion: 1},
+		{name: "create duplicate product", history: []CatalogEvent{created}, decide: func(a *ProductAggregate) (CatalogEvent, error) { return a.Create("AirPods", 5990) }, wantErr: ErrProductExists},
+		{name: "create with zero price", decide: func(a *ProductAggregate) (CatalogEvent, error) { return a.Create("AirPods", 0) }, wantErr: ErrInvalidPrice},
+		{name: "create with negative price", decide: func(a *ProductAggregate) (CatalogEvent, error) { return a.Create("AirPods", -1) }, wantErr: ErrInvalidPrice},
+		{name: "change price", history: []CatalogEvent{created}, decide: func(a *ProductAggregate) (CatalogEvent, error) { return a.ChangePrice(4990) }, wantType: EventPriceChanged, wantVersion: 2},
+		{name: "change price of unknown product", decide: func(a *ProductAggregate) (CatalogEvent, error) { return a.ChangePrice(4990) }, wantErr: ErrProductNotFound},
+		{name: "change price to zero", history: []CatalogEvent{created}, decide: func(a *ProductAggregate) (CatalogEvent, error) { return a.ChangePrice(0) }, wantErr: ErrInvalidPrice},
+		{name: "change price to negative", history: []CatalogEvent{created}, decide: func(a *ProductAggregate) (CatalogEvent, error) { return a.ChangePrice(-100) }, wantErr: ErrInvalidPrice},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg := NewProductAggregate("airpods")
+			agg.Replay(tt.history)
+			event, err := tt.decide(agg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if event.Type != tt.wantType || event.Version != tt.wantVersion || (err == nil && event.StreamID != "airpods") {
+				t.Fatalf("event = %+v, want %s v.%d", event, tt.wantType, tt.wantVersion)
+			}
+		})
+	}
+}
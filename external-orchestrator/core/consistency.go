@@ -0,0 +1,43 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// นโยบายเมื่อ Read Model (products_view) ยังตามไม่ทัน Version ที่ผู้เรียกต้องการ
+const (
+	StaleViewWait   = "wait"   // รอ Projector ตามทัน (หมดเวลา = ตอบ 503 ให้ลองใหม่)
+	StaleViewSkip   = "skip"   // ข้าม Soft Check ไปเลย (ให้ Hard Check ใน Inventory ตัดสิน)
+	StaleViewReplay = "replay" // Replay Event Store (events) เองแทน Read Model
+)
+
+var (
+	ErrStaleReadModel          = errors.New("read model is behind the requested version")
+	ErrInvalidConsistencyToken = errors.New("invalid consistency token")
+)
+
+// ConsistencyToken บอกว่าผู้เรียกเคยเห็น Stream ของสินค้านี้ถึง Version ไหนแล้ว
+// (ได้มาจากการเขียนครั้งก่อน เช่น GET /orders/:order_id หลังจองของ) รูปแบบ: "<product_id>@<version>"
+type ConsistencyToken struct {
+	ProductID string
+	Version   int
+}
+
+func (t ConsistencyToken) String() string {
+	return fmt.Sprintf("%s@%d", t.ProductID, t.Version)
+}
+
+func ParseConsistencyToken(s string) (ConsistencyToken, error) {
+	i := strings.LastIndex(s, "@")
+	if i <= 0 {
+		return ConsistencyToken{}, ErrInvalidConsistencyToken
+	}
+	version, err := strconv.Atoi(s[i+1:])
+	if err != nil || version < 0 {
+		return ConsistencyToken{}, ErrInvalidConsistencyToken
+	}
+	return ConsistencyToken{ProductID: s[:i], Version: version}, nil
+}
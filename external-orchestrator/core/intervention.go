@@ -0,0 +1,67 @@
+package core
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInterventionNotFound = ไม่มีเคสของ Order นี้ในคิว
+var ErrInterventionNotFound = errors.New("intervention not found")
+
+// สถานะของ Saga ที่เปิดให้ดูผ่าน Query
+const (
+	SagaStatusRunning        = "RUNNING"
+	SagaStatusCompensating   = "COMPENSATING"
+	SagaStatusNeedsAttention = "NEEDS_ATTENTION" // Compensate ไม่ผ่าน รอคนมาจัดการ
+	SagaStatusCompleted      = "COMPLETED"
+	SagaStatusFailed         = "FAILED"
+	SagaStatusTimedOut       = "TIMED_OUT" // เกินเส้นตายของ Order
+)
+
+// ชื่อ Signal / Query ที่ Admin ใช้คุยกับ Workflow
+const (
+	SignalIntervention = "intervention"
+	QuerySagaStatus    = "saga-status"
+)
+
+// คำสั่งที่ Operator ส่งเข้ามาได้
+const (
+	ActionRetry        = "retry"         // ลองคืนของใหม่อีกรอบด้วย Policy เดิม
+	ActionForceRelease = "force-release" // คืนของแบบ Retry ไม่จำกัดจนกว่าจะสำเร็จ
+	ActionResolve      = "resolve"       // Operator แก้ไขเองแล้ว ปิดเรื่องได้เลย
+)
+
+// สถานะของเคสในคิว Manual Intervention
+const (
+	InterventionOpen     = "OPEN"
+	InterventionResolved = "RESOLVED"
+)
+
+// InterventionSignal คือ payload ของ Signal ที่ Admin ส่งเข้า Workflow
+type InterventionSignal struct {
+	Action   string `json:"action"`
+	Operator string `json:"operator,omitempty"`
+	Note     string `json:"note,omitempty"`
+}
+
+// Intervention คือเคสที่ Compensation ล้มเหลว และต้องมีคนมาดู
+type Intervention struct {
+	OrderID    string     `bson:"_id" json:"order_id"`
+	WorkflowID string     `bson:"workflow_id" json:"workflow_id"`
+	ProductID  string     `bson:"product_id" json:"product_id"`
+	Qty        int        `bson:"qty" json:"qty"`
+	Error      string     `bson:"error" json:"error"`
+	Attempts   int        `bson:"attempts" json:"attempts"` // จำนวนรอบที่ Compensate ไม่ผ่าน
+	Status     string     `bson:"status" json:"status"`
+	Resolution string     `bson:"resolution,omitempty" json:"resolution,omitempty"`
+	Operator   string     `bson:"operator,omitempty" json:"operator,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`
+	ResolvedAt *time.Time `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+}
+
+// SagaState คือสิ่งที่ Query "saga-status" ตอบกลับ
+type SagaState struct {
+	Status    string `json:"status"`
+	LastError string `json:"last_error,omitempty"`
+}
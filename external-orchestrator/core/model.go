@@ -1,15 +1,73 @@
 package core
 
+import "time"
+
+// โหมดของ Saga (เลือกตอน Start Service ด้วย SAGA_MODE)
+const (
+	SagaModeOrchestration = "orchestration" // Temporal เป็นคนสั่ง (OrderSagaWorkflow)
+	SagaModeChoreography  = "choreography"  // แต่ละ Service ฟัง Event แล้วทำต่อเอง
+)
+
 // สิ่งที่ลูกค้าส่งมา
 type CreateOrderRequest struct {
 	OrderID   string `json:"order_id"`
 	ProductID string `json:"product_id"`
 	Qty       int    `json:"qty"`
-	Amount    int    `json:"amount"` // ยอดเงินที่ต้องตัด
+	Amount    int    `json:"amount"` // ยอดที่ Client คาดไว้ (Optional) -> Server จะแทนด้วยยอดที่คำนวณจาก Catalog
+}
+
+// SagaOptions คือ Config ของ Saga ที่ Server เป็นคนกำหนด (ไม่ได้มาจาก Client)
+type SagaOptions struct {
+	OrderDeadline time.Duration `json:"order_deadline"` // เส้นตายของทั้ง Order (0 = ไม่มี)
+	Steps         StepPolicies  `json:"steps"`          // Timeout / Retry ของแต่ละ Step (ไม่ส่งมา = ค่า Default)
 }
 
 // สิ่งที่เราอ่านจาก Read Model (MongoDB)
+// available_stock = on_hand - reserved (Soft Check ใช้ตัวนี้)
 type ProductView struct {
-	ProductID      string `bson:"product_id"`
-	AvailableStock int    `bson:"available_stock"`
+	ProductID      string         `bson:"product_id" json:"product_id"`
+	OnHand         int            `bson:"on_hand" json:"on_hand"`                 // ของที่อยู่ในคลังจริง (รวมที่ถูกจองไว้)
+	Reserved       int            `bson:"reserved" json:"reserved"`               // ถูกจองโดย Order ที่ยังไม่จบ
+	Committed      int            `bson:"committed" json:"committed"`             // ขายไปแล้ว (สะสม)
+	AvailableStock int            `bson:"available_stock" json:"available_stock"` // ขายได้อีกเท่าไหร่
+	Totals         map[string]int `bson:"totals" json:"totals"`                   // ยอดสะสมแยกตามเหตุผล (added / reserved / released / committed / rejected)
+	LastVersion    int            `bson:"last_version" json:"last_version"`       // Version ล่าสุดของ Stream สินค้าที่ Projector ทำไปแล้ว
+	UpdatedAt      time.Time      `bson:"updated_at" json:"updated_at"`           // เวลาของ Event ล่าสุด
+}
+
+// Apply ใส่ Event ของ Inventory 1 ตัว (กติกาเดียวกับ Projector: products_view)
+func (v *ProductView) Apply(eventType string, qty, version int, at time.Time) {
+	var reason string
+	switch eventType {
+	case "StockAdded":
+		reason = "added"
+		v.OnHand += qty
+	case "StockReserved":
+		reason = "reserved"
+		v.Reserved += qty
+	case "StockReleased":
+		reason = "released"
+		v.Reserved -= qty
+	case "StockCommitted":
+		reason = "committed"
+		v.Reserved -= qty
+		v.OnHand -= qty
+		v.Committed += qty
+	case "StockReservationRejected":
+		reason = "rejected"
+	}
+	if reason != "" {
+		if v.Totals == nil {
+			v.Totals = map[string]int{}
+		}
+		v.Totals[reason] += qty
+	}
+	v.AvailableStock = v.OnHand - v.Reserved
+	v.LastVersion = version
+	v.UpdatedAt = at
+}
+
+// OrderWorkflowID คือ Business ID ของ Workflow (1 Order = 1 Workflow)
+func OrderWorkflowID(orderID string) string {
+	return "order-" + orderID
 }
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ชื่อ Event ของ Order Stream (Constants) เพื่อป้องกันการพิมพ์ผิด
+const (
+	EventOrderPlaced     = "OrderPlaced"
+	EventStockReserved   = "StockReserved"
+	EventPaymentCaptured = "PaymentCaptured"
+	EventOrderCompleted  = "OrderCompleted"
+	EventOrderFailed     = "OrderFailed"
+	EventOrderCancelled  = "OrderCancelled"
+	EventOrderTimedOut   = "OrderTimedOut" // เกินเส้นตายของ Order
+)
+
+// สถานะของ Order ที่ได้จากการ Replay
+const (
+	OrderStatusPlaced        = "PLACED"
+	OrderStatusStockReserved = "STOCK_RESERVED"
+	OrderStatusPaid          = "PAID"
+	OrderStatusCompleted     = "COMPLETED"
+	OrderStatusFailed        = "FAILED"
+	OrderStatusCancelled     = "CANCELLED"
+	OrderStatusTimedOut      = "TIMED_OUT"
+)
+
+var (
+	ErrInvalidOrderTransition = errors.New("invalid order transition")
+	ErrOrderExists            = errors.New("order already exists")
+)
+
+// OrderEvent โครงสร้าง Event ที่เก็บลง MongoDB (collection: order_events)
+type OrderEvent struct {
+	ID        string    `bson:"_id,omitempty"`
+	Version   int       `bson:"version"`
+	StreamID  string    `bson:"stream_id"` // Order ID
+	Type      string    `bson:"type"`
+	ProductID string    `bson:"product_id,omitempty"`
+	Qty       int       `bson:"qty,omitempty"`
+	Amount    int       `bson:"amount,omitempty"`
+	Reason    string    `bson:"reason,omitempty"` // สาเหตุที่ Order ล้ม/ถูกยกเลิก
+	Mode      string    `bson:"mode,omitempty"`   // โหมด Saga ที่คุม Order นี้ (มีเฉพาะ OrderPlaced)
+	Timestamp time.Time `bson:"timestamp"`
+}
+
+// OrderAggregate คือตัวแทนของ Order 1 ใบใน RAM (สร้างจากการ Replay Order Stream)
+type OrderAggregate struct {
+	OrderID       string    `json:"order_id"`
+	ProductID     string    `json:"product_id"`
+	Qty           int       `json:"qty"`
+	Amount        int       `json:"amount"`
+	Status        string    `json:"status"`
+	Mode          string    `json:"mode,omitempty"` // orchestration / choreography
+	FailureReason string    `json:"failure_reason,omitempty"`
+	PlacedAt      time.Time `json:"placed_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	LastVersion   int       `json:"version"` // ใช้เก็บ version ล่าสุดของ Event Sourcing
+
+	recorded map[string]bool // Event Type ที่เคยเกิดแล้ว (ใช้กัน Activity Retry เขียนซ้ำ)
+}
+
+// สร้าง Aggregate เปล่าๆ
+func NewOrderAggregate(orderID string) *OrderAggregate {
+	return &OrderAggregate{
+		OrderID:  orderID,
+		recorded: map[string]bool{},
+	}
+}
+
+// ฟังก์ชัน Replay: รับ Event เข้ามา 1 ตัว แล้วอัปเดตสถานะตัวเอง
+func (a *OrderAggregate) Apply(event OrderEvent) {
+	switch event.Type {
+	case EventOrderPlaced:
+		a.ProductID = event.ProductID
+		a.Qty = event.Qty
+		a.Amount = event.Amount
+		a.Mode = event.Mode
+		a.Status = OrderStatusPlaced
+		a.PlacedAt = event.Timestamp
+	case EventStockReserved:
+		a.Status = OrderStatusStockReserved
+	case EventPaymentCaptured:
+		a.Status = OrderStatusPaid
+	case EventOrderCompleted:
+		a.Status = OrderStatusCompleted
+	case EventOrderFailed:
+		a.Status = OrderStatusFailed
+		a.FailureReason = event.Reason
+	case EventOrderCancelled:
+		a.Status = OrderStatusCancelled
+		a.FailureReason = event.Reason
+	case EventOrderTimedOut:
+		a.Status = OrderStatusTimedOut
+		a.FailureReason = event.Reason
+	}
+	a.recorded[event.Type] = true
+	a.UpdatedAt = event.Timestamp
+	a.LastVersion = event.Version
+}
+
+// Replay ทีเดียวหลายตัว
+func (a *OrderAggregate) Replay(events []OrderEvent) {
+	for _, evt := range events {
+		a.Apply(evt)
+	}
+}
+
+// HasRecorded บอกว่า Event ชนิดนี้เคยถูกบันทึกใน Stream แล้วหรือยัง
+func (a *OrderAggregate) HasRecorded(eventType string) bool {
+	return a.recorded[eventType]
+}
+
+// CanApply ตรวจว่า Event นี้เกิดต่อจากสถานะปัจจุบันได้ไหม (State Machine ของ Order)
+func (a *OrderAggregate) CanApply(eventType string) error {
+	allowed := false
+	switch eventType {
+	case EventOrderPlaced:
+		allowed = a.LastVersion == 0
+	case EventStockReserved:
+		allowed = a.Status == OrderStatusPlaced
+	case EventPaymentCaptured:
+		allowed = a.Status == OrderStatusStockReserved
+	case EventOrderCompleted:
+		allowed = a.Status == OrderStatusPaid
+	case EventOrderFailed, EventOrderCancelled, EventOrderTimedOut:
+		allowed = a.Status == OrderStatusPlaced || a.Status == OrderStatusStockReserved
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidOrderTransition, a.Status, eventType)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// orderAt สร้าง Aggregate ที่ผ่าน Event ตามลำดับมาแล้ว
+func orderAt(types ...string) *OrderAggregate {
+	agg := NewOrderAggregate("ORD-1")
+	for i, t := range types {
+		agg.Apply(OrderEvent{StreamID: "ORD-1", Version: i + 1, Type: t})
+	}
+	return agg
+}
+
+func TestOrderAggregateCanApply(t *testing.T) {
+	placed := []string{EventOrderPlaced}
+	reserved := []string{EventOrderPlaced, EventStockReserved}
+	paid := []string{EventOrderPlaced, EventStockReserved, EventPaymentCaptured}
+	completed := append(append([]string{}, paid...), EventOrderCompleted)
+
+	tests := []struct {
+		name    string
+		history []string
+		next    string
+		ok      bool
+	}{
+		{"new order is placed", nil, EventOrderPlaced, true},
+		{"new order cannot reserve", nil, EventStockReserved, false},
+		{"placed twice", placed, EventOrderPlaced, false},
+		{"placed -> reserved", placed, EventStockReserved, true},
+		{"placed -> failed", placed, EventOrderFailed, true},
+		{"placed -> paid skips reserve", placed, EventPaymentCaptured, false},
+		{"reserved -> paid", reserved, EventPaymentCaptured, true},
+		{"reserved -> timed out", reserved, EventOrderTimedOut, true},
+		{"reserved -> cancelled", reserved, EventOrderCancelled, true},
+		{"reserved -> completed skips payment", reserved, EventOrderCompleted, false},
+		{"paid -> completed", paid, EventOrderCompleted, true},
+		{"paid cannot fail", paid, EventOrderFailed, false},
+		{"completed is final", completed, EventOrderCancelled, false},
+		{"failed is final", []string{EventOrderPlaced, EventOrderFailed}, EventStockReserved, false},
+		{"unknown type", placed, "OrderShipped", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := orderAt(tt.history...).CanApply(tt.next)
+			if tt.ok && err != nil {
+				t.Fatalf("CanApply(%s) = %v, want nil", tt.next, err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidOrderTransition) {
+				t.Fatalf("CanApply(%s) = %v, want ErrInvalidOrderTransition", tt.next, err)
+			}
+		})
+	}
+}
+
+func TestOrderAggregateReplay(t *testing.T) {
+	placedAt := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	events := []OrderEvent{
+		{Version: 1, Type: EventOrderPlaced, ProductID: "iphone-15", Qty: 2, Amount: 20000, Mode: SagaModeOrchestration, Timestamp: placedAt},
+		{Version: 2, Type: EventStockReserved, Timestamp: placedAt.Add(time.Second)},
+		{Version: 3, Type: EventOrderTimedOut, Reason: "order deadline exceeded", Timestamp: placedAt.Add(time.Minute)},
+	}
+
+	agg := NewOrderAggregate("ORD-1")
+	agg.Replay(events)
+
+	if agg.Status != OrderStatusTimedOut || agg.FailureReason != "order deadline exceeded" {
+		t.Fatalf("status = %s (%q)", agg.Status, agg.FailureReason)
+	}
+	if agg.ProductID != "iphone-15" || agg.Qty != 2 || agg.Amount != 20000 || agg.Mode != SagaModeOrchestration {
+		t.Fatalf("order details not restored: %+v", agg)
+	}
+	if agg.LastVersion != 3 || !agg.PlacedAt.Equal(placedAt) || !agg.UpdatedAt.Equal(placedAt.Add(time.Minute)) {
+		t.Fatalf("version/timestamps = %d %v %v", agg.LastVersion, agg.PlacedAt, agg.UpdatedAt)
+	}
+	if !agg.HasRecorded(EventStockReserved) || agg.HasRecorded(EventPaymentCaptured) {
+		t.Fatal("recorded event types not tracked")
+	}
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ขนาดหน้าของ GET /orders
+const (
+	DefaultOrderPageSize = 50
+	MaxOrderPageSize     = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// OrderView คือ Read Model ของ Order (collection: orders_view, Projector เป็นคนเขียน)
+// รวม Event ของ Inventory + Payment ตาม order_id เวลาแต่ละขั้นเป็น nil ถ้ายังไม่เกิด
+type OrderView struct {
+	OrderID         string      `bson:"order_id" json:"order_id"`
+	Status          string      `bson:"status" json:"status"`
+	Items           []OrderItem `bson:"items" json:"items"`
+	Amount          int         `bson:"amount" json:"amount"`
+	PaymentStatus   string      `bson:"payment_status,omitempty" json:"payment_status,omitempty"` // SUCCESS / FAILED
+	FailureReason   string      `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	CreatedAt       time.Time   `bson:"created_at" json:"created_at"` // เวลาของ Event แรกที่เห็น
+	UpdatedAt       time.Time   `bson:"updated_at" json:"updated_at"`
+	ReservedAt      *time.Time  `bson:"reserved_at,omitempty" json:"reserved_at,omitempty"`
+	RejectedAt      *time.Time  `bson:"rejected_at,omitempty" json:"rejected_at,omitempty"`
+	PaidAt          *time.Time  `bson:"paid_at,omitempty" json:"paid_at,omitempty"`
+	PaymentFailedAt *time.Time  `bson:"payment_failed_at,omitempty" json:"payment_failed_at,omitempty"`
+	ReleasedAt      *time.Time  `bson:"released_at,omitempty" json:"released_at,omitempty"`
+	CommittedAt     *time.Time  `bson:"committed_at,omitempty" json:"committed_at,omitempty"`
+}
+
+type OrderItem struct {
+	ProductID string `bson:"product_id" json:"product_id"`
+	Qty       int    `bson:"qty" json:"qty"`
+}
+
+// OrderQuery คือเงื่อนไขของ GET /orders (Field ว่าง = ไม่กรอง) เรียงใหม่สุดก่อน
+type OrderQuery struct {
+	Status    string
+	ProductID string
+	From      time.Time    // created_at >= From
+	To        time.Time    // created_at < To
+	Limit     int          // 1..MaxOrderPageSize
+	After     *OrderCursor // หน้าถัดไป: ต่อจาก Order ตัวสุดท้ายของหน้าก่อน
+}
+
+// OrderCursor ชี้ Order ตัวสุดท้ายของหน้า (Keyset Pagination: ไม่ข้าม/ไม่ซ้ำแม้มี Order ใหม่เข้ามาระหว่างเปิดหน้า)
+type OrderCursor struct {
+	CreatedAt time.Time
+	OrderID   string
+}
+
+func CursorAfter(order OrderView) *OrderCursor {
+	return &OrderCursor{CreatedAt: order.CreatedAt, OrderID: order.OrderID}
+}
+
+// Encode คืน Cursor แบบ Opaque ให้ Client ส่งกลับมาตรงๆ
+func (c OrderCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.OrderID))
+}
+
+func ParseOrderCursor(s string) (*OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	at, orderID, ok := strings.Cut(string(raw), "|")
+	if !ok || orderID == "" {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &OrderCursor{CreatedAt: createdAt, OrderID: orderID}, nil
+}
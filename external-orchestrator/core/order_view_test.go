@@ -0,0 +1,27 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOrderCursorRoundTrip(t *testing.T) {
+	order := OrderView{OrderID: "ORD-042", CreatedAt: time.Date(2026, 3, 1, 10, 30, 0, 123000000, time.UTC)}
+
+	got, err := ParseOrderCursor(CursorAfter(order).Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.OrderID != order.OrderID || !got.CreatedAt.Equal(order.CreatedAt) {
+		t.Fatalf("cursor = %+v, want %s@%s", got, order.OrderID, order.CreatedAt)
+	}
+}
+
+func TestParseOrderCursorRejectsGarbage(t *testing.T) {
+	for _, s := range []string{"not-base64!", "bm8tc2VwYXJhdG9y", "MjAyNi0wMy0wMXw"} {
+		if _, err := ParseOrderCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("ParseOrderCursor(%q) err = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ช่วงเวลาของ Bucket ใน sales_analytics (ตาม UTC)
+const (
+	GranularityHour = "hour"
+	GranularityDay  = "day"
+)
+
+// MaxSalesBuckets จำกัดจำนวนจุดต่อคำขอ (31 วันแบบรายชั่วโมง / ~2 ปีแบบรายวัน)
+const MaxSalesBuckets = 744
+
+var (
+	ErrInvalidGranularity = errors.New("granularity must be hour or day")
+	ErrInvalidSalesRange  = errors.New("from must be before to")
+	ErrSalesRangeTooLarge = fmt.Errorf("range covers more than %d buckets", MaxSalesBuckets)
+)
+
+// SalesCounters คือยอดสะสมของสินค้าในช่วงเวลาหนึ่ง (Projector เขียนจาก events + payment_events)
+type SalesCounters struct {
+	Reserved       int `bson:"reserved" json:"reserved"`
+	Released       int `bson:"released" json:"released"`
+	Sold           int `bson:"sold" json:"sold"` // StockCommitted
+	Rejected       int `bson:"rejected" json:"rejected"`
+	OrdersPaid     int `bson:"orders_paid" json:"orders_paid"`
+	PaymentsFailed int `bson:"payments_failed" json:"payments_failed"`
+	Revenue        int `bson:"revenue" json:"revenue"`
+}
+
+func (c *SalesCounters) Add(o SalesCounters) {
+	c.Reserved += o.Reserved
+	c.Released += o.Released
+	c.Sold += o.Sold
+	c.Rejected += o.Rejected
+	c.OrdersPaid += o.OrdersPaid
+	c.PaymentsFailed += o.PaymentsFailed
+	c.Revenue += o.Revenue
+}
+
+// SalesBucket คือยอดของ 1 ช่วง เริ่มที่ Start (รวม) ยาว 1 ชั่วโมง / 1 วัน
+type SalesBucket struct {
+	Start         time.Time `bson:"bucket" json:"start"`
+	SalesCounters `bson:",inline"`
+}
+
+// SalesQuery คือช่วงที่ขอ: From <= Bucket < To (ปัดลงเป็นต้น Bucket แล้ว)
+type SalesQuery struct {
+	ProductID   string
+	Granularity string
+	From        time.Time
+	To          time.Time
+}
+
+// NewSalesQuery ตรวจช่วงแล้วปัด from ลงเป็นต้น Bucket, to ขึ้นเป็นต้น Bucket ถัดไป (ถ้าไม่ได้อยู่ต้น Bucket พอดี)
+func NewSalesQuery(productID, granularity string, from, to time.Time) (SalesQuery, error) {
+	if granularity == "" {
+		granularity = GranularityHour
+	}
+	if granularity != GranularityHour && granularity != GranularityDay {
+		return SalesQuery{}, ErrInvalidGranularity
+	}
+	if !from.Before(to) {
+		return SalesQuery{}, ErrInvalidSalesRange
+	}
+
+	q := SalesQuery{ProductID: productID, Granularity: granularity, From: bucketStart(granularity, from)}
+	q.To = bucketStart(granularity, to)
+	if q.To.Before(to) {
+		q.To = q.next(q.To)
+	}
+	if q.count() > MaxSalesBuckets {
+		return SalesQuery{}, ErrSalesRangeTooLarge
+	}
+	return q, nil
+}
+
+func bucketStart(granularity string, t time.Time) time.Time {
+	t = t.UTC()
+	if granularity == GranularityDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+func (q SalesQuery) next(t time.Time) time.Time {
+	if q.Granularity == GranularityDay {
+		return t.AddDate(0, 0, 1)
+	}
+	return t.Add(time.Hour)
+}
+
+// count ใช้หารตรงๆ ได้เพราะวันตาม UTC ยาว 24 ชั่วโมงเสมอ
+func (q SalesQuery) count() int {
+	if q.Granularity == GranularityDay {
+		return int(q.To.Sub(q.From) / (24 * time.Hour))
+	}
+	return int(q.To.Sub(q.From) / time.Hour)
+}
+
+// SalesSeries คือยอดทุก Bucket ในช่วงที่ขอ (ไม่มียอด = 0) พร้อมผลรวม
+type SalesSeries struct {
+	ProductID   string        `json:"product_id"`
+	Granularity string        `json:"granularity"`
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	Buckets     []SalesBucket `json:"buckets"`
+	Total       SalesCounters `json:"total"`
+}
+
+// FillSeries เรียง Bucket ที่มีตามเวลาแล้วเติม Bucket ที่ไม่มีเป็นศูนย์ (Bucket นอกช่วงถูกทิ้ง)
+func FillSeries(q SalesQuery, buckets []SalesBucket) SalesSeries {
+	byStart := make(map[int64]SalesCounters, len(buckets))
+	for _, b := range buckets {
+		byStart[b.Start.Unix()] = b.SalesCounters
+	}
+
+	series := SalesSeries{ProductID: q.ProductID, Granularity: q.Granularity, From: q.From, To: q.To, Buckets: []SalesBucket{}}
+	for t := q.From; t.Before(q.To); t = q.next(t) {
+		counters := byStart[t.Unix()]
+		series.Buckets = append(series.Buckets, SalesBucket{Start: t, SalesCounters: counters})
+		series.Total.Add(counters)
+	}
+	return series
+}
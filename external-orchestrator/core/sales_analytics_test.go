@@ -0,0 +1,68 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewSalesQueryAlignsRangeToBuckets(t *testing.T) {
+	from := time.Date(2026, 3, 1, 10, 20, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 1, 13, 5, 0, 0, time.UTC)
+
+	q, err := NewSalesQuery("iphone-15", "", from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Granularity != GranularityHour || !q.From.Equal(from.Truncate(time.Hour)) || q.To.Hour() != 14 {
+		t.Fatalf("query = %+v, want hour 10:00..14:00", q)
+	}
+
+	q, err = NewSalesQuery("iphone-15", GranularityDay, from, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.From.Day() != 1 || q.From.Hour() != 0 || q.To.Day() != 3 { // to อยู่ต้นวันพอดี -> ไม่ปัดขึ้น
+		t.Fatalf("query = %+v, want day 03-01..03-03", q)
+	}
+}
+
+func TestNewSalesQueryRejectsBadInput(t *testing.T) {
+	at := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		granularity string
+		from, to    time.Time
+		want        error
+	}{
+		{"minute", at, at.Add(time.Hour), ErrInvalidGranularity},
+		{GranularityHour, at, at, ErrInvalidSalesRange},
+		{GranularityHour, at, at.AddDate(0, 0, 32), ErrSalesRangeTooLarge},
+	}
+	for _, c := range cases {
+		if _, err := NewSalesQuery("iphone-15", c.granularity, c.from, c.to); !errors.Is(err, c.want) {
+			t.Fatalf("%s %s..%s: err = %v, want %v", c.granularity, c.from, c.to, err, c.want)
+		}
+	}
+}
+
+func TestFillSeriesZeroFillsAndTotals(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	q, _ := NewSalesQuery("iphone-15", GranularityHour, from, from.Add(4*time.Hour))
+
+	series := FillSeries(q, []SalesBucket{
+		{Start: from.Add(2 * time.Hour), SalesCounters: SalesCounters{Reserved: 3, Sold: 2, Revenue: 70000, OrdersPaid: 2}},
+		{Start: from, SalesCounters: SalesCounters{Reserved: 1, Released: 1, PaymentsFailed: 1}},
+		{Start: from.Add(5 * time.Hour), SalesCounters: SalesCounters{Sold: 99}}, // นอกช่วง
+	})
+
+	if len(series.Buckets) != 4 {
+		t.Fatalf("buckets = %d, want 4", len(series.Buckets))
+	}
+	if series.Buckets[0].Reserved != 1 || series.Buckets[1] != (SalesBucket{Start: from.Add(time.Hour)}) || series.Buckets[2].Sold != 2 {
+		t.Fatalf("buckets = %+v", series.Buckets)
+	}
+	want := SalesCounters{Reserved: 4, Released: 1, Sold: 2, OrdersPaid: 2, PaymentsFailed: 1, Revenue: 70000}
+	if series.Total != want {
+		t.Fatalf("total = %+v, want %+v", series.Total, want)
+	}
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// UnlimitedAttempts ใช้ใน StepPolicy.MaximumAttempts = Retry ไปเรื่อยๆ จนกว่าจะสำเร็จ
+// (0 ใช้ไม่ได้ เพราะหมายถึง "ไม่ได้ตั้ง" -> ใช้ค่า Default)
+const UnlimitedAttempts int32 = -1
+
+// DefaultStartToCloseTimeout คือ StartToClose ของ Step ที่ไม่ได้ตั้ง start_to_close_timeout
+const DefaultStartToCloseTimeout = time.Minute
+
+// StepPolicy คือ Timeout + Retry ของ Step หนึ่งใน Saga
+// Field ไหนเป็น Zero Value = ใช้ค่า Default ของ Step นั้น
+type StepPolicy struct {
+	StartToCloseTimeout time.Duration `json:"start_to_close_timeout,omitempty"`
+	HeartbeatTimeout    time.Duration `json:"heartbeat_timeout,omitempty"` // 0 = ไม่ใช้ Heartbeat
+	InitialInterval     time.Duration `json:"initial_interval,omitempty"`
+	BackoffCoefficient  float64       `json:"backoff_coefficient,omitempty"`
+	MaximumInterval     time.Duration `json:"maximum_interval,omitempty"`
+	MaximumAttempts     int32         `json:"maximum_attempts,omitempty"` // UnlimitedAttempts = ไม่จำกัด
+	// Type ของ Error ที่ Retry ไปก็ไม่หาย (เช่น contracts.ErrTypeOutOfStock) -> Fail ทันที
+	NonRetryableErrorTypes []string `json:"non_retryable_error_types,omitempty"`
+}
+
+// StepPolicies แยก Policy ตาม Step ของ Saga
+type StepPolicies struct {
+	Reserve      StepPolicy `json:"reserve"`
+	Payment      StepPolicy `json:"payment"`
+	Compensation StepPolicy `json:"compensation"` // คืนของ (รวมตอน Operator สั่ง Retry)
+	Commit       StepPolicy `json:"commit"`       // ยืนยันการขายหลังตัดเงินสำเร็จ
+}
+
+// Validate ตรวจค่าที่ Temporal จะไม่ยอมรับ (เรียกตอนโหลด Config ก่อนส่งเข้า Workflow)
+func (p StepPolicies) Validate() error {
+	steps := []struct {
+		name   string
+		policy StepPolicy
+	}{{"reserve", p.Reserve}, {"payment", p.Payment}, {"compensation", p.Compensation}, {"commit", p.Commit}}
+	for _, step := range steps {
+		if err := step.policy.validate(); err != nil {
+			return fmt.Errorf("step %s: %w", step.name, err)
+		}
+	}
+	return nil
+}
+
+func (p StepPolicy) validate() error {
+	// Heartbeat ต้องเทียบกับ StartToClose ที่จะใช้จริง (ไม่ได้ตั้ง = ค่า Default)
+	startToClose := p.StartToCloseTimeout
+	if startToClose == 0 {
+		startToClose = DefaultStartToCloseTimeout
+	}
+	switch {
+	case p.StartToCloseTimeout < 0, p.HeartbeatTimeout < 0, p.InitialInterval < 0, p.MaximumInterval < 0:
+		return fmt.Errorf("durations must not be negative")
+	case p.BackoffCoefficient != 0 && p.BackoffCoefficient < 1:
+		return fmt.Errorf("backoff_coefficient must be >= 1, got %v", p.BackoffCoefficient)
+	case p.MaximumAttempts < UnlimitedAttempts:
+		return fmt.Errorf("maximum_attempts must be >= 1 or %d (unlimited), got %d", UnlimitedAttempts, p.MaximumAttempts)
+	case p.HeartbeatTimeout > 0 && p.HeartbeatTimeout >= startToClose:
+		return fmt.Errorf("heartbeat_timeout (%s) must be shorter than start_to_close_timeout (%s)", p.HeartbeatTimeout, startToClose)
+	}
+	return nil
+}
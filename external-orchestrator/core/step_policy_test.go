@@ -0,0 +1,30 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStepPoliciesValidateHeartbeatAgainstEffectiveStartToClose(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  StepPolicy
+		wantErr bool
+	}{
+		{name: "defaults"},
+		{name: "heartbeat shorter than the default start-to-close", policy: StepPolicy{HeartbeatTimeout: 10 * time.Second}},
+		{name: "heartbeat only, longer than the default start-to-close", policy: StepPolicy{HeartbeatTimeout: 5 * time.Minute}, wantErr: true},
+		{name: "heartbeat equal to the default start-to-close", policy: StepPolicy{HeartbeatTimeout: DefaultStartToCloseTimeout}, wantErr: true},
+		{name: "heartbeat shorter than an explicit start-to-close", policy: StepPolicy{StartToCloseTimeout: 10 * time.Minute, HeartbeatTimeout: 5 * time.Minute}},
+		{name: "heartbeat longer than an explicit start-to-close", policy: StepPolicy{StartToCloseTimeout: 30 * time.Second, HeartbeatTimeout: 45 * time.Second}, wantErr: true},
+		{name: "negative duration", policy: StepPolicy{InitialInterval: -time.Second}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := StepPolicies{Payment: tt.policy}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidPointInTime = errors.New("point in time must be RFC3339, a date (YYYY-MM-DD) or a version number")
+	ErrInvalidStockRange  = errors.New("from must not be after to")
+)
+
+// InventoryEvent คือ Event 1 ตัวใน Stream สินค้า (collection events ของ Inventory)
+type InventoryEvent struct {
+	ProductID string    `bson:"stream_id" json:"product_id"`
+	OrderID   string    `bson:"order_id,omitempty" json:"order_id,omitempty"`
+	Type      string    `bson:"type" json:"type"`
+	Qty       int       `bson:"qty" json:"qty"`
+	Version   int       `bson:"version" json:"version"`
+	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
+}
+
+// PointInTime คือจุดที่ต้องการดูยอดสต็อก: Version > 0 = ถึง Version นั้น, At = ถึงเวลานั้น (รวมเวลานั้นด้วย)
+// ค่าว่างทั้งคู่ = ล่าสุด
+type PointInTime struct {
+	At      time.Time
+	Version int
+}
+
+// ParsePointInTime รับ "" (ล่าสุด), ตัวเลข (Version), RFC3339 หรือวันที่ YYYY-MM-DD (= สิ้นวันนั้นตาม UTC)
+func ParsePointInTime(s string) (PointInTime, error) {
+	if s == "" {
+		return PointInTime{}, nil
+	}
+	if v, err := strconv.Atoi(s); err == nil {
+		if v < 1 {
+			return PointInTime{}, ErrInvalidPointInTime
+		}
+		return PointInTime{Version: v}, nil
+	}
+	if at, err := time.Parse(time.RFC3339, s); err == nil {
+		return PointInTime{At: at}, nil
+	}
+	if day, err := time.Parse(time.DateOnly, s); err == nil {
+		return PointInTime{At: day.AddDate(0, 0, 1).Add(-time.Nanosecond)}, nil
+	}
+	return PointInTime{}, ErrInvalidPointInTime
+}
+
+func (p PointInTime) IsLatest() bool {
+	return p.Version == 0 && p.At.IsZero()
+}
+
+func (p PointInTime) String() string {
+	switch {
+	case p.Version > 0:
+		return fmt.Sprintf("v.%d", p.Version)
+	case !p.At.IsZero():
+		return p.At.UTC().Format(time.RFC3339Nano)
+	}
+	return "latest"
+}
+
+// Until ตัด Event (เรียงตาม Version เริ่มที่ 1) ให้เหลือเฉพาะช่วงต่อเนื่องจากต้น Stream ที่ยังไม่เลยจุดนี้
+// ตามเวลา = หยุดที่ Event แรกที่เลยเวลา (หรือ Version ขาดช่วงเพราะถูกกรองออก) แม้ตัวหลังจะมี Timestamp
+// ย้อนกลับมา (นาฬิกาคนละเครื่อง) ยอดที่ได้จึงเป็นยอดที่ Aggregate เคยเป็นจริง ไม่ใช่ Event ที่กระโดดข้ามกัน
+func (p PointInTime) Until(events []InventoryEvent) []InventoryEvent {
+	for i, evt := range events {
+		if evt.Version != i+1 ||
+			(p.Version > 0 && evt.Version > p.Version) ||
+			(p.Version == 0 && !p.At.IsZero() && evt.Timestamp.After(p.At)) {
+			return events[:i]
+		}
+	}
+	return events
+}
+
+// ReplayStock ไล่ Event (เรียงตาม Version) เป็นยอดสต็อก
+func ReplayStock(productID string, events []InventoryEvent) ProductView {
+	view := ProductView{ProductID: productID, Totals: map[string]int{}}
+	for _, evt := range events {
+		view.Apply(evt.Type, evt.Qty, evt.Version, evt.Timestamp)
+	}
+	return view
+}
+
+// StockSnapshot คือยอดสต็อก ณ จุดที่ขอ (last_version = Event สุดท้ายที่นับ, 0 = ยังไม่มีสินค้านี้)
+type StockSnapshot struct {
+	Point string `json:"point"`
+	ProductView
+}
+
+func SnapshotAt(productID string, events []InventoryEvent, point PointInTime) StockSnapshot {
+	return StockSnapshot{Point: point.String(), ProductView: ReplayStock(productID, point.Until(events))}
+}
+
+// StockChange คือยอดที่เปลี่ยน (To - From)
+type StockChange struct {
+	OnHand         int `json:"on_hand"`
+	Reserved       int `json:"reserved"`
+	Committed      int `json:"committed"`
+	AvailableStock int `json:"available_stock"`
+}
+
+// StockDiff คือยอดสองจุดพร้อม Event ที่ทำให้ยอดเปลี่ยน (หลัง From จนถึง To)
+type StockDiff struct {
+	ProductID string           `json:"product_id"`
+	From      StockSnapshot    `json:"from"`
+	To        StockSnapshot    `json:"to"`
+	Change    StockChange      `json:"change"`
+	Events    []InventoryEvent `json:"events"`
+}
+
+// DiffStock เทียบยอดสองจุดจาก Event ชุดเดียวกัน (ต้องมี Event อย่างน้อยถึง to)
+func DiffStock(productID string, events []InventoryEvent, from, to PointInTime) (StockDiff, error) {
+	before := from.Until(events)
+	after := to.Until(events)
+	if len(before) > len(after) {
+		return StockDiff{}, ErrInvalidStockRange
+	}
+
+	diff := StockDiff{
+		ProductID: productID,
+		From:      StockSnapshot{Point: from.String(), ProductView: ReplayStock(productID, before)},
+		To:        StockSnapshot{Point: to.String(), ProductView: ReplayStock(productID, after)},
+		Events:    append([]InventoryEvent{}, after[len(before):]...),
+	}
+	diff.Change = StockChange{
+		OnHand:         diff.To.OnHand - diff.From.OnHand,
+		Reserved:       diff.To.Reserved - diff.From.Reserved,
+		Committed:      diff.To.Committed - diff.From.Committed,
+		AvailableStock: diff.To.AvailableStock - diff.From.AvailableStock,
+	}
+	return diff, nil
+}
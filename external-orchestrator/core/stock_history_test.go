@@ -0,0 +1,76 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func monthEndEvents() []InventoryEvent {
+	day := func(d, h int) time.Time { return time.Date(2026, 9, d, h, 0, 0, 0, time.UTC) }
+	return []InventoryEvent{
+		{ProductID: "iphone-15", Type: "StockAdded", Qty: 100, Version: 1, Timestamp: day(1, 9)},
+		{ProductID: "iphone-15", OrderID: "ORD-1", Type: "StockReserved", Qty: 2, Version: 2, Timestamp: day(30, 23)},
+		{ProductID: "iphone-15", OrderID: "ORD-1", Type: "StockCommitted", Qty: 2, Version: 3, Timestamp: time.Date(2026, 10, 1, 0, 5, 0, 0, time.UTC)},
+		// นาฬิกาของอีกเครื่องช้ากว่า: Timestamp ย้อนกลับไปก่อนสิ้นเดือน แต่เกิดหลัง v.3
+		{ProductID: "iphone-15", OrderID: "ORD-2", Type: "StockReserved", Qty: 1, Version: 4, Timestamp: day(30, 23)},
+	}
+}
+
+func TestParsePointInTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "latest"},
+		{"12", "v.12"},
+		{"2026-09-30T23:59:59+07:00", "2026-09-30T16:59:59Z"},
+		{"2026-09-30", "2026-09-30T23:59:59.999999999Z"},
+	}
+	for _, tt := range tests {
+		got, err := ParsePointInTime(tt.in)
+		if err != nil || got.String() != tt.want {
+			t.Fatalf("ParsePointInTime(%q) = %s, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"0", "-1", "yesterday", "2026-13-01"} {
+		if _, err := ParsePointInTime(in); !errors.Is(err, ErrInvalidPointInTime) {
+			t.Fatalf("ParsePointInTime(%q) err = %v, want ErrInvalidPointInTime", in, err)
+		}
+	}
+}
+
+func TestSnapshotAtMonthEndStopsAtFirstLaterEvent(t *testing.T) {
+	monthEnd, _ := ParsePointInTime("2026-09-30")
+
+	got := SnapshotAt("iphone-15", monthEndEvents(), monthEnd)
+	// v.4 มี Timestamp ก่อนสิ้นเดือนแต่ต้องไม่ถูกนับ เพราะ v.3 ยังไม่เกิด
+	if got.LastVersion != 2 || got.OnHand != 100 || got.Reserved != 2 || got.AvailableStock != 98 {
+		t.Fatalf("month end = %+v", got)
+	}
+
+	if before := SnapshotAt("iphone-15", monthEndEvents(), PointInTime{At: time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC)}); before.LastVersion != 0 || before.AvailableStock != 0 {
+		t.Fatalf("before first event = %+v", before)
+	}
+}
+
+func TestDiffStockListsEventsBetweenPoints(t *testing.T) {
+	monthEnd, _ := ParsePointInTime("2026-09-30")
+
+	diff, err := DiffStock("iphone-15", monthEndEvents(), monthEnd, PointInTime{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Events) != 2 || diff.Events[0].Version != 3 || diff.Events[1].Version != 4 {
+		t.Fatalf("events = %+v", diff.Events)
+	}
+	want := StockChange{OnHand: -2, Reserved: -1, Committed: 2, AvailableStock: -1}
+	if diff.Change != want {
+		t.Fatalf("change = %+v, want %+v", diff.Change, want)
+	}
+
+	// ผสม Version กับเวลาได้ แต่ from ต้องไม่อยู่หลัง to
+	if _, err := DiffStock("iphone-15", monthEndEvents(), PointInTime{Version: 3}, monthEnd); !errors.Is(err, ErrInvalidStockRange) {
+		t.Fatalf("err = %v, want ErrInvalidStockRange", err)
+	}
+}
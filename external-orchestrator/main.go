@@ -5,23 +5,56 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
 
+	"contracts"
+	"external-orchestrator/adapters/choreography"
 	httpAdapter "external-orchestrator/adapters/http"
 	mongoAdapter "external-orchestrator/adapters/mongo"
+	temporalAdapter "external-orchestrator/adapters/temporal"
+	"external-orchestrator/app"
+	"external-orchestrator/core"
 	"external-orchestrator/workflows"
+	"infra/changefeed"
 )
 
 func main() {
 
 	mongoURI := getEnv("MONGO_URI", "mongodb://localhost:27017/?directConnection=true")
 	temporalHost := getEnv("TEMPORAL_HOST", "127.0.0.1:7233")
-	fmt.Printf("🔧 Config: Mongo=%s | Temporal=%s\n", mongoURI, temporalHost)
+	sagaMode := getEnv("SAGA_MODE", core.SagaModeOrchestration)
+	staleViewPolicy := getEnv("STALE_VIEW_POLICY", core.StaleViewWait) // wait / skip / replay
+	if staleViewPolicy != core.StaleViewWait && staleViewPolicy != core.StaleViewSkip && staleViewPolicy != core.StaleViewReplay {
+		log.Fatal("Invalid STALE_VIEW_POLICY: ", staleViewPolicy)
+	}
+	staleViewWait, err := time.ParseDuration(getEnv("STALE_VIEW_WAIT", "2s"))
+	if err != nil {
+		log.Fatal("Invalid STALE_VIEW_WAIT: ", err)
+	}
+	if sagaMode != core.SagaModeOrchestration && sagaMode != core.SagaModeChoreography {
+		log.Fatal("Invalid SAGA_MODE: ", sagaMode)
+	}
+	orderDeadline, err := time.ParseDuration(getEnv("ORDER_DEADLINE", "10m")) // 0 = ไม่มีเส้นตาย
+	if err != nil {
+		log.Fatal("Invalid ORDER_DEADLINE: ", err)
+	}
+	adminToken := getEnv("ADMIN_TOKEN", "") // ไม่ตั้ง = ปิด Route แก้ Catalog
+	if adminToken == "" {
+		log.Println("⚠️ ADMIN_TOKEN is not set: POST /products and PUT /products/:product_id/price are disabled")
+	}
+	stepPolicyFile := getEnv("SAGA_POLICY_FILE", "") // ไม่ตั้ง = Timeout / Retry แบบ Default ทุก Step
+	stepPolicies, err := loadStepPolicies(stepPolicyFile)
+	if err != nil {
+		log.Fatal("Invalid SAGA_POLICY_FILE: ", err)
+	}
+	fmt.Printf("🔧 Config: Mongo=%s | Temporal=%s | Saga=%s | OrderDeadline=%s | StaleView=%s(%s) | StepPolicies=%q\n", mongoURI, temporalHost, sagaMode, orderDeadline, staleViewPolicy, staleViewWait, stepPolicyFile)
 
 	// 1. Connect MongoDB
 	mongoOpts := options.Client().ApplyURI(mongoURI) // หรือใช้ Env Var
@@ -42,13 +75,29 @@ func main() {
 
 	// 3. Wiring Adapters (Dependency Injection)
 	repo := mongoAdapter.NewMongoProductRepository(db)
-	handler := httpAdapter.NewOrderHandler(repo, temporalClient)
+	inventoryEvents := mongoAdapter.NewMongoInventoryEventReader(db)
+	stockCheck := app.NewSoftStockCheck(repo, inventoryEvents, staleViewPolicy, staleViewWait)
+	orderRepo := mongoAdapter.NewMongoOrderRepository(db)
+	orderService := app.NewOrderService(orderRepo)
+	catalogRepo := mongoAdapter.NewMongoCatalogRepository(db)
+	catalogViewRepo := mongoAdapter.NewMongoCatalogViewRepository(db)
+	interventionRepo := mongoAdapter.NewMongoInterventionRepository(db)
+	handler := httpAdapter.NewOrderHandler(stockCheck, catalogViewRepo, orderService, inventoryEvents, temporalClient, sagaMode, core.SagaOptions{OrderDeadline: orderDeadline, Steps: stepPolicies})
+	catalogHandler := httpAdapter.NewCatalogHandler(catalogRepo, catalogViewRepo)
+	orderViewHandler := httpAdapter.NewOrderViewHandler(mongoAdapter.NewMongoOrderViewRepository(db))
+	adminHandler := httpAdapter.NewAdminHandler(interventionRepo, temporalClient)
+	stockHistoryHandler := httpAdapter.NewStockHistoryHandler(app.NewStockHistory(inventoryEvents))
+	salesHandler := httpAdapter.NewSalesAnalyticsHandler(mongoAdapter.NewMongoSalesAnalyticsRepository(db))
+	sagaActivities := temporalAdapter.NewSagaActivities(interventionRepo, orderService)
 
 	// --- ส่วนที่เพิ่ม: Start Workflow Worker ---
 	// เพื่อให้ Temporal Server รู้ว่า Workflow "OrderSagaWorkflow" อยู่ที่นี่
-	w := worker.New(temporalClient, "order-queue", worker.Options{})
+	w := worker.New(temporalClient, contracts.QueueOrder, worker.Options{})
 
 	w.RegisterWorkflow(workflows.OrderSagaWorkflow) // ลงทะเบียนฟังก์ชัน
+	w.RegisterActivity(sagaActivities.OpenIntervention)
+	w.RegisterActivity(sagaActivities.ResolveIntervention)
+	w.RegisterActivity(sagaActivities.RecordOrderEvent)
 
 	// รัน Worker ใน Background (Goroutine)
 	go func() {
@@ -57,14 +106,58 @@ func main() {
 		}
 	}()
 
+	// Choreography: ไม่มี Workflow ใหม่ (Worker ยังเปิดไว้ให้ Order เก่าที่ค้างอยู่)
+	// แต่ต้องฟังผลจาก Inventory / Payment มาเขียนลง Order Stream แทน
+	if sagaMode == core.SagaModeChoreography {
+		startOrderTracker(db, orderService)
+	}
+
 	// 4. Start HTTP Server
 	r := gin.Default()
 	r.POST("/orders", handler.CreateOrder)
+	r.GET("/orders", orderViewHandler.ListOrders) // ค้นหาจาก Read Model (orders_view)
+	r.GET("/orders/:order_id", handler.GetOrder)
+	r.DELETE("/orders/:order_id", handler.CancelOrder)
+
+	// Product Catalog (ราคาสินค้า): อ่านได้ทุกคน แต่สร้าง / แก้ราคาต้องมี Admin Token
+	// (ราคาคือที่มาของยอดเงินที่ Server คิดให้ Order ถ้าใครก็แก้ได้ การเช็คยอดเงินก็ไม่มีความหมาย)
+	r.GET("/products/:product_id", catalogHandler.GetProduct)
+	catalogAdmin := r.Group("/products", httpAdapter.RequireAdminToken(adminToken))
+	catalogAdmin.POST("", catalogHandler.CreateProduct)
+	catalogAdmin.PUT("/:product_id/price", catalogHandler.ChangePrice)
+
+	// ยอดสต็อกย้อนหลัง (Replay events ไม่ผ่าน Read Model)
+	r.GET("/products/:product_id/stock", stockHistoryHandler.GetStock)
+	r.GET("/products/:product_id/stock/diff", stockHistoryHandler.DiffStock)
+
+	// ยอดขายรายชั่วโมง / รายวัน (จาก Read Model sales_analytics)
+	r.GET("/products/:product_id/sales", salesHandler.GetSales)
+
+	// Admin: Manual Intervention Queue
+	admin := r.Group("/admin/sagas")
+	admin.GET("/stuck", adminHandler.ListStuckSagas)
+	admin.POST("/:order_id/retry", adminHandler.Retry)
+	admin.POST("/:order_id/force-release", adminHandler.ForceRelease)
+	admin.POST("/:order_id/resolve", adminHandler.Resolve)
 
 	log.Println("Orchestrator Service running on :8080")
 	r.Run(":8080")
 }
 
+// startOrderTracker เปิด Subscriber ที่ตามผลของ Choreography Saga (แต่ละตัวมี Checkpoint ของตัวเอง)
+func startOrderTracker(db *mongo.Database, orders *app.OrderService) {
+	tracker := choreography.NewOrderTracker(orders)
+
+	onInventory := changefeed.NewSubscriber(db, "order_tracker_inventory", "events",
+		bson.D{{Key: "fullDocument.type", Value: bson.M{"$in": bson.A{"StockReserved", "StockReservationRejected"}}}})
+	onPayment := changefeed.NewSubscriber(db, "order_tracker_payment", "payment_events",
+		bson.D{{Key: "fullDocument.type", Value: bson.M{"$in": bson.A{"PaymentProcessed", "PaymentFailed"}}}})
+
+	// Run ทำงานจนกว่า Service จะปิด (Stream พัง = เปิดใหม่เอง ไม่ทำให้ทั้ง Process ตาย)
+	go onInventory.Run(context.Background(), tracker.OnInventoryEvent)
+	go onPayment.Run(context.Background(), tracker.OnPaymentEvent)
+}
+
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
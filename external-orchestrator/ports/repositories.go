@@ -9,10 +9,65 @@ import (
 
 // 1. ต้องการคนช่วยอ่านสต็อก (จาก Read Model)
 type ProductRepository interface {
-	GetProductView(ctx context.Context, productID string) (*core.ProductView, error)
+	// minVersion > 0: ถ้า Read Model ยังไม่ถึง Version นี้ จะได้ core.ErrStaleReadModel
+	GetProductView(ctx context.Context, productID string, minVersion int) (*core.ProductView, error)
 }
 
-// 2. ต้องการคนช่วยสั่ง Workflow (Temporal)
+// 1b. อ่าน Event Store ของ Inventory (events) ตรงๆ ใช้ตอน Read Model ตามไม่ทัน
+type InventoryEventReader interface {
+	// Replay Stream ของสินค้าเป็นยอดคงเหลือ (ข้อมูลจริง ไม่ผ่าน Projector)
+	ReplayProductView(ctx context.Context, productID string) (*core.ProductView, error)
+	// Event ของสินค้าตั้งแต่ต้นจนถึง until (เรียงตาม Version, until ว่าง = ทั้งหมด) ใช้ดูยอดย้อนหลัง
+	// Query เดียวกับ InventoryRepository.GetEventsUntil ของ Inventory (อ่านเองตรงๆ ไม่ต้องรอ Inventory Worker)
+	GetEventsUntil(ctx context.Context, productID string, until core.PointInTime) ([]core.InventoryEvent, error)
+	// Event ล่าสุดใน Stream สินค้าที่ Order นี้ทำให้เกิด (nil = ยังไม่มี)
+	LastTokenForOrder(ctx context.Context, orderID string) (*core.ConsistencyToken, error)
+}
+
+// 2. ต้องการที่เก็บเคสที่ Compensate ไม่ผ่าน (Manual Intervention Queue)
+type InterventionRepository interface {
+	// เปิดเคสใหม่ หรืออัปเดต Error/จำนวนครั้ง ถ้าเคสยังเปิดอยู่
+	Open(ctx context.Context, intervention core.Intervention) error
+	// ปิดเคสพร้อมบันทึกว่าจบด้วยวิธีไหน
+	Resolve(ctx context.Context, orderID, resolution, operator string) error
+	// รายการเคสที่ยังค้างอยู่
+	ListOpen(ctx context.Context) ([]core.Intervention, error)
+	// ไม่เจอ = core.ErrInterventionNotFound
+	Get(ctx context.Context, orderID string) (*core.Intervention, error)
+}
+
+// 3. Event Store ของ Order (Order Stream)
+type OrderRepository interface {
+	// ดึง Event ทั้งหมดของ Order มาเพื่อ Replay
+	GetEvents(ctx context.Context, orderID string) ([]core.OrderEvent, error)
+	// บันทึก Event ใหม่ลง DB (Version ซ้ำ = มีคนเขียนตัดหน้า)
+	AppendEvent(ctx context.Context, event core.OrderEvent) error
+}
+
+// 4. Event Store ของ Product Catalog (ราคาสินค้า)
+type CatalogRepository interface {
+	GetEvents(ctx context.Context, productID string) ([]core.CatalogEvent, error)
+	AppendEvent(ctx context.Context, event core.CatalogEvent) error
+}
+
+// 5. ต้องการคนช่วยอ่านราคา (จาก Read Model catalog_view)
+type CatalogViewRepository interface {
+	GetCatalogView(ctx context.Context, productID string) (*core.CatalogView, error)
+}
+
+// 5b. ต้องการคนช่วยค้นหา Order (จาก Read Model orders_view)
+type OrderViewRepository interface {
+	// คืน Order ตามเงื่อนไข เรียงใหม่สุดก่อน ไม่เกิน q.Limit ตัว
+	ListOrders(ctx context.Context, q core.OrderQuery) ([]core.OrderView, error)
+}
+
+// 5c. ต้องการคนช่วยอ่านยอดขายรายชั่วโมง / รายวัน (จาก Read Model sales_analytics)
+type SalesAnalyticsRepository interface {
+	// คืนเฉพาะ Bucket ที่มียอดในช่วง q.From <= bucket < q.To (ไม่เรียง ไม่เติมช่วงว่าง)
+	ListSalesBuckets(ctx context.Context, q core.SalesQuery) ([]core.SalesBucket, error)
+}
+
+// 6. ต้องการคนช่วยสั่ง Workflow (Temporal)
 // หมายเหตุ: ใน Go เราใช้ client.Client ของ Temporal ได้เลย หรือจะห่อ Interface อีกชั้นก็ได้
 // ในที่นี้เพื่อความง่าย เราจะใช้ client.Client ใน Handler โดยตรงครับ
 type TemporalClient client.Client
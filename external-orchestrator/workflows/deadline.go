@@ -0,0 +1,67 @@
+package workflows
+
+import (
+	"errors"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ErrTypeOrderDeadlineExceeded คือ Type ของ ApplicationError เมื่อ Order เกินเวลาที่กำหนด
+const ErrTypeOrderDeadlineExceeded = "OrderDeadlineExceeded"
+
+// orderDeadline คือเส้นตายของทั้ง Order (Durable Timer: Worker ดับก็ยังนับต่อ)
+// พอหมดเวลาจะ Cancel Context ของ Saga -> Activity ที่ค้างอยู่จะถูกยกเลิก
+type orderDeadline struct {
+	exceeded  bool
+	stopTimer workflow.CancelFunc
+}
+
+// startOrderDeadline คืน Context ที่ Step ของ Saga ต้องใช้ (ถ้า d <= 0 = ไม่มีเส้นตาย)
+func startOrderDeadline(ctx workflow.Context, d time.Duration) (workflow.Context, *orderDeadline) {
+	if d <= 0 {
+		return ctx, nil
+	}
+
+	sagaCtx, cancelSaga := workflow.WithCancel(ctx)
+	timerCtx, stopTimer := workflow.WithCancel(ctx)
+	deadline := &orderDeadline{stopTimer: stopTimer}
+
+	timer := workflow.NewTimer(timerCtx, d)
+	workflow.Go(ctx, func(gCtx workflow.Context) {
+		// Timer ถูก Stop (Order จบก่อน) -> Get คืน CanceledError -> ไม่ต้องทำอะไร
+		if err := timer.Get(gCtx, nil); err == nil {
+			workflow.GetLogger(gCtx).Warn("Order deadline exceeded. Cancelling outstanding steps...", "Deadline", d)
+			deadline.exceeded = true
+			cancelSaga()
+		}
+	})
+	return sagaCtx, deadline
+}
+
+// active บอกว่ามีเส้นตายอยู่หรือไม่
+func (d *orderDeadline) active() bool {
+	return d != nil
+}
+
+// stop หยุด Timer เมื่อ Order จบก่อนเส้นตาย
+func (d *orderDeadline) stop() {
+	if d != nil {
+		d.stopTimer()
+	}
+}
+
+// wrap เปลี่ยน Error ที่เกิดจากการโดน Cancel เพราะหมดเวลา ให้เป็น OrderDeadlineExceeded
+func (d *orderDeadline) wrap(err error) error {
+	if d == nil || !d.exceeded || err == nil {
+		return err
+	}
+	return temporal.NewNonRetryableApplicationError("order deadline exceeded", ErrTypeOrderDeadlineExceeded, err)
+}
+
+// isDeadlineExceeded ตรวจว่า Error นี้มาจากการหมดเวลาของ Order
+func isDeadlineExceeded(err error) bool {
+	var appErr *temporal.ApplicationError
+	return errors.As(err, &appErr) && appErr.Type() == ErrTypeOrderDeadlineExceeded
+}
@@ -0,0 +1,67 @@
+package workflows
+
+import (
+	"errors"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"external-orchestrator/core"
+)
+
+// orderStream บันทึก Transition ของ Order ผ่าน Activity "RecordOrderEvent"
+// ถ้า enabled = false (Workflow รุ่นเก่า) ทุกเมธอดจะไม่ทำอะไรเลย
+type orderStream struct {
+	enabled bool
+	req     core.CreateOrderRequest
+}
+
+func (s orderStream) record(ctx workflow.Context, eventType, reason string) error {
+	if !s.enabled {
+		return nil
+	}
+
+	event := core.OrderEvent{
+		StreamID: s.req.OrderID,
+		Type:     eventType,
+		Reason:   reason,
+	}
+	switch eventType {
+	case core.EventOrderPlaced:
+		event.ProductID = s.req.ProductID
+		event.Qty = s.req.Qty
+		event.Amount = s.req.Amount
+		event.Mode = core.SagaModeOrchestration
+	case core.EventPaymentCaptured:
+		event.Amount = s.req.Amount
+	}
+
+	actCtx := workflow.WithActivityOptions(ctx, sagaActivityOptions())
+	return workflow.ExecuteActivity(actCtx, ActivityRecordOrderEvent, event).Get(actCtx, nil)
+}
+
+// recordOutcome บันทึกตอนจบแบบไม่สำเร็จ:
+// หมดเวลา -> OrderTimedOut, Cancel -> OrderCancelled, อื่นๆ -> OrderFailed
+func (s orderStream) recordOutcome(ctx workflow.Context, cause error) error {
+	if !s.enabled {
+		return nil
+	}
+	if isDeadlineExceeded(cause) {
+		disconnected, _ := workflow.NewDisconnectedContext(ctx)
+		return s.record(disconnected, core.EventOrderTimedOut, "order deadline exceeded")
+	}
+	if temporal.IsCanceledError(cause) {
+		disconnected, _ := workflow.NewDisconnectedContext(ctx)
+		return s.record(disconnected, core.EventOrderCancelled, "cancelled")
+	}
+	return s.record(ctx, core.EventOrderFailed, failureReason(cause))
+}
+
+// failureReason ดึงข้อความ Error ต้นเหตุออกมา (ตัด "activity error (type: ...)" ทิ้ง)
+func failureReason(err error) string {
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) {
+		return appErr.Error()
+	}
+	return err.Error()
+}
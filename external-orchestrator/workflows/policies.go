@@ -0,0 +1,59 @@
+package workflows
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"external-orchestrator/core"
+)
+
+// defaultStepPolicy คือพฤติกรรมเดิมก่อนมี Step Policy
+// Workflow ที่ไม่ได้ส่ง Policy มา (รวมถึงตัวที่เริ่มก่อนมี Feature นี้) จะได้แบบนี้ทุก Step
+var defaultStepPolicy = core.StepPolicy{
+	StartToCloseTimeout: core.DefaultStartToCloseTimeout,
+	InitialInterval:     time.Second,
+	BackoffCoefficient:  2.0,
+	MaximumInterval:     100 * time.Second,
+	MaximumAttempts:     3, // ลอง 3 ครั้ง
+}
+
+// stepActivityOptions แปลง Policy ของ Step เป็น ActivityOptions (Field ที่ไม่ได้ตั้ง = ค่า Default)
+func stepActivityOptions(p core.StepPolicy, taskQueue string) workflow.ActivityOptions {
+	d := defaultStepPolicy
+	if p.StartToCloseTimeout > 0 {
+		d.StartToCloseTimeout = p.StartToCloseTimeout
+	}
+	if p.HeartbeatTimeout > 0 {
+		d.HeartbeatTimeout = p.HeartbeatTimeout
+	}
+	if p.InitialInterval > 0 {
+		d.InitialInterval = p.InitialInterval
+	}
+	if p.BackoffCoefficient > 0 {
+		d.BackoffCoefficient = p.BackoffCoefficient
+	}
+	if p.MaximumInterval > 0 {
+		d.MaximumInterval = p.MaximumInterval
+	}
+	if p.MaximumAttempts != 0 {
+		d.MaximumAttempts = p.MaximumAttempts
+	}
+	if p.MaximumAttempts == core.UnlimitedAttempts {
+		d.MaximumAttempts = 0 // Temporal: 0 = ไม่จำกัด
+	}
+
+	return workflow.ActivityOptions{
+		TaskQueue:           taskQueue,
+		StartToCloseTimeout: d.StartToCloseTimeout,
+		HeartbeatTimeout:    d.HeartbeatTimeout,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:        d.InitialInterval,
+			BackoffCoefficient:     d.BackoffCoefficient,
+			MaximumInterval:        d.MaximumInterval,
+			MaximumAttempts:        d.MaximumAttempts,
+			NonRetryableErrorTypes: p.NonRetryableErrorTypes,
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package workflows
+
+import (
+	"testing"
+	"time"
+
+	"external-orchestrator/core"
+)
+
+func TestStepActivityOptionsDefaults(t *testing.T) {
+	opts := stepActivityOptions(core.StepPolicy{}, "inventory-queue")
+
+	if opts.TaskQueue != "inventory-queue" || opts.StartToCloseTimeout != time.Minute || opts.HeartbeatTimeout != 0 {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+	if p := opts.RetryPolicy; p.MaximumAttempts != 3 || p.InitialInterval != time.Second || p.MaximumInterval != 100*time.Second || p.BackoffCoefficient != 2 {
+		t.Fatalf("default retry policy changed: %+v", p)
+	}
+}
+
+func TestStepActivityOptionsOverrides(t *testing.T) {
+	opts := stepActivityOptions(core.StepPolicy{
+		StartToCloseTimeout:    2 * time.Minute,
+		HeartbeatTimeout:       10 * time.Second,
+		MaximumAttempts:        5,
+		NonRetryableErrorTypes: []string{"InsufficientFunds"},
+	}, "payment-queue")
+
+	if opts.StartToCloseTimeout != 2*time.Minute || opts.HeartbeatTimeout != 10*time.Second {
+		t.Fatalf("timeouts not applied: %+v", opts)
+	}
+	p := opts.RetryPolicy
+	if p.MaximumAttempts != 5 || len(p.NonRetryableErrorTypes) != 1 || p.NonRetryableErrorTypes[0] != "InsufficientFunds" {
+		t.Fatalf("retry policy not applied: %+v", p)
+	}
+	// Field ที่ไม่ได้ตั้ง ยังเป็นค่า Default
+	if p.InitialInterval != time.Second || p.MaximumInterval != 100*time.Second {
+		t.Fatalf("unset fields should keep defaults: %+v", p)
+	}
+}
+
+func TestStepActivityOptionsUnlimitedAttempts(t *testing.T) {
+	opts := stepActivityOptions(core.StepPolicy{MaximumAttempts: core.UnlimitedAttempts}, "inventory-queue")
+	if opts.RetryPolicy.MaximumAttempts != 0 {
+		t.Fatalf("expected unlimited (0), got %d", opts.RetryPolicy.MaximumAttempts)
+	}
+}
@@ -0,0 +1,35 @@
+package workflows
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go.temporal.io/sdk/worker"
+)
+
+// TestReplayOrderSagaHistories เอา History ของ Workflow ที่เคยรันจริง (testdata/histories/*.json)
+// มา Replay กับโค้ดปัจจุบัน ถ้าแก้ Saga แล้วลืมครอบ GetVersion เทสนี้จะพัง (Non-deterministic)
+//
+// เก็บ History เพิ่มได้จาก Temporal CLI:
+//
+//	temporal workflow show -w order-ORD-001 -o json > workflows/testdata/histories/vN_<scenario>.json
+func TestReplayOrderSagaHistories(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "histories", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no workflow histories found in testdata/histories")
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			replayer := worker.NewWorkflowReplayer()
+			replayer.RegisterWorkflow(OrderSagaWorkflow)
+
+			if err := replayer.ReplayWorkflowHistoryFromJSONFile(nil, file); err != nil {
+				t.Fatalf("replay %s: %v", file, err)
+			}
+		})
+	}
+}
@@ -3,88 +3,269 @@ package workflows
 import (
 	"time"
 
+	"contracts"
 	"external-orchestrator/core"
 
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
-// ชื่อ Activity ที่เราจะเรียก (ต้องตรงกับที่ Inventory/Payment Service Register ไว้)
+// Activity ของ Orchestrator เอง (Register อยู่ใน contracts.QueueOrder)
+// ส่วน Activity ของ Inventory / Payment อยู่ใน module contracts
 const (
-	ActivityReserveStock   = "ReserveStock"
-	ActivityReleaseStock   = "ReleaseStock"
-	ActivityProcessPayment = "ProcessPayment"
+	ActivityOpenIntervention    = "OpenIntervention"
+	ActivityResolveIntervention = "ResolveIntervention"
+	ActivityRecordOrderEvent    = "RecordOrderEvent"
 )
 
-// ชื่อ Task Queue ของแต่ละ Service
-const (
-	QueueInventory = "inventory-queue"
-	QueuePayment   = "payment-queue"
-)
-
-func OrderSagaWorkflow(ctx workflow.Context, req core.CreateOrderRequest) error {
+// opts เป็น Argument ตัวที่ 2 (Workflow ที่เริ่มก่อนมี opts จะได้ค่า Zero Value = ไม่มีเส้นตาย)
+func OrderSagaWorkflow(ctx workflow.Context, req core.CreateOrderRequest, opts core.SagaOptions) error {
 	logger := workflow.GetLogger(ctx)
 	logger.Info("Order Saga started", "OrderID", req.OrderID)
 
-	// --- Config: ตั้งค่า Retry Policy พื้นฐาน ---
-	retryPolicy := temporal.RetryPolicy{
-		InitialInterval:    time.Second,
-		BackoffCoefficient: 2.0,
-		MaximumInterval:    100 * time.Second,
-		MaximumAttempts:    3, // ลอง 3 ครั้ง
+	// สถานะปัจจุบันของ Saga (ให้ Admin Query ดูได้ตลอดเวลา)
+	state := core.SagaState{Status: core.SagaStatusRunning}
+	if err := workflow.SetQueryHandler(ctx, core.QuerySagaStatus, func() (core.SagaState, error) {
+		return state, nil
+	}); err != nil {
+		return err
+	}
+
+	// Order Stream: บันทึกทุก Transition ของ Order ลง Event Store
+	// (Workflow ที่เริ่มก่อนมี Order Stream จะไม่บันทึก เพื่อให้ Replay ตรงกับ History เดิม)
+	orders := orderStream{
+		enabled: workflow.GetVersion(ctx, changeOrderEventStream, workflow.DefaultVersion, 1) >= 1,
+		req:     req,
+	}
+
+	// เส้นตายของทั้ง Order: หมดเวลา -> ยกเลิก Step ที่ค้าง -> Compensate -> OrderTimedOut
+	sagaCtx := ctx
+	var deadline *orderDeadline
+	if workflow.GetVersion(ctx, changeOrderDeadline, workflow.DefaultVersion, 1) >= 1 {
+		sagaCtx, deadline = startOrderDeadline(ctx, opts.OrderDeadline)
 	}
 
+	// Activity ของ Inventory / Payment: Contract แบบ Struct (Workflow รุ่นเก่าใช้ชื่อเดิมแบบ Positional)
+	steps := sagaSteps{
+		typed: workflow.GetVersion(ctx, changeTypedContracts, workflow.DefaultVersion, 1) >= 1,
+		req:   req,
+	}
+
+	if err := orders.record(ctx, core.EventOrderPlaced, ""); err != nil {
+		return err
+	}
+
+	// --- Config: Timeout / Retry แยกตาม Step (มาจาก opts.Steps, ไม่ได้ตั้ง = ค่า Default) ---
+	reserveOptions := stepActivityOptions(opts.Steps.Reserve, contracts.QueueInventory)
+	paymentOptions := stepActivityOptions(opts.Steps.Payment, contracts.QueuePayment)
+	compensationOptions := stepActivityOptions(opts.Steps.Compensation, contracts.QueueInventory)
+	commitOptions := stepActivityOptions(opts.Steps.Commit, contracts.QueueInventory)
+
 	// -----------------------------------------------------
 	// STEP 1: Reserve Stock (เรียก Inventory Service)
 	// -----------------------------------------------------
-	inventoryOptions := workflow.ActivityOptions{
-		StartToCloseTimeout: time.Minute,
-		TaskQueue:           QueueInventory, // ส่งไปหา Inventory Worker
-		RetryPolicy:         &retryPolicy,
-	}
-	ctx1 := workflow.WithActivityOptions(ctx, inventoryOptions)
+	// หมดเวลา / ถูก Cancel ระหว่างจอง ต้องรอผลจริงของ Activity ก่อนสั่งคืน
+	// ไม่งั้น ReleaseStock อาจไปถึงก่อนการจอง (ไม่เจอการจอง = ไม่ทำอะไร) แล้วการจองที่ลงทีหลังจะค้างไว้
+	waitReserve := workflow.GetVersion(ctx, changeWaitReserveCancel, workflow.DefaultVersion, 1) >= 1
+	reserveOptions.WaitForCancellation = waitReserve || deadline.active()
+	ctx1 := workflow.WithActivityOptions(sagaCtx, reserveOptions)
 
-	err := workflow.ExecuteActivity(ctx1, ActivityReserveStock, req.ProductID, req.Qty).Get(ctx1, nil)
+	err := steps.reserveStock(ctx1)
 	if err != nil {
 		// ถ้าจองของไม่ได้ (เช่น Hard Check ไม่ผ่าน) -> ไม่ต้องทำอะไรต่อ
+		err = deadline.wrap(err)
 		logger.Error("Failed to reserve stock", "Error", err)
+
+		// หมดเวลา / ถูก Cancel: Inventory อาจจองให้ไปแล้วก่อนรู้ว่าถูกยกเลิก -> สั่งคืนไว้ก่อนเสมอ
+		// (Workflow ที่เริ่มก่อนมี Step นี้ จะจบแบบเดิมเพื่อให้ Replay ตรงกับ History)
+		if isDeadlineExceeded(err) || temporal.IsCanceledError(err) {
+			releaseCtx, _ := workflow.NewDisconnectedContext(ctx)
+			if workflow.GetVersion(releaseCtx, changeReleaseOnReserveAbort, workflow.DefaultVersion, 1) >= 1 {
+				if errManual := releaseAbortedReservation(releaseCtx, req, steps, compensationOptions, err, &state); errManual != nil {
+					return errManual
+				}
+			}
+		}
+
+		state = outcomeState(err)
+		if errRecord := orders.recordOutcome(ctx, err); errRecord != nil {
+			return errRecord
+		}
 		return err
 	}
 
 	// -----------------------------------------------------
 	// STEP 2: Process Payment (เรียก Payment Service)
 	// -----------------------------------------------------
-	paymentOptions := workflow.ActivityOptions{
-		StartToCloseTimeout: time.Minute,
-		TaskQueue:           QueuePayment, // ส่งไปหา Payment Worker
-		RetryPolicy:         &retryPolicy,
-	}
-	ctx2 := workflow.WithActivityOptions(ctx, paymentOptions)
+	// หมายเหตุ: ถ้าหมดเวลาระหว่างตัดเงิน เราไม่รอ Gateway ตอบ (WaitForCancellation = false)
+	// Gateway ต้องใช้ OrderID เป็น Idempotency Key และถือว่า Order ที่ถูกยกเลิกแล้วเป็นโมฆะ
+	ctx2 := workflow.WithActivityOptions(sagaCtx, paymentOptions)
 
-	err = workflow.ExecuteActivity(ctx2, ActivityProcessPayment, req.OrderID, req.Amount).Get(ctx2, nil)
+	// จองได้แล้ว -> ถ้าพังหลังจากนี้ (รวมถึงหมดเวลา) ต้อง Compensate เสมอ
+	err = orders.record(sagaCtx, core.EventStockReserved, "")
+	if err == nil {
+		err = steps.processPayment(ctx2)
+	}
 	if err != nil {
 		// !!! เกิดปัญหาตอนจ่ายเงิน !!!
+		err = deadline.wrap(err)
 		logger.Error("Payment failed. Starting compensation...", "Error", err)
 
 		// -----------------------------------------------------
 		// COMPENSATE: Release Stock (คืนของ)
 		// -----------------------------------------------------
-		// เราต้องใช้ Context เดิม (ctx1) หรือสร้างใหม่ที่ส่งไป Inventory Queue
-		// แต่ห้ามใช้ ctx ที่มี parent cancel (ต้องใช้ DisconnectedContext ถ้า Workflow โดนยกเลิก)
+		// ใช้ Policy ของ Compensation (ส่งไป Inventory Queue เหมือน ctx1 แต่มักจะ Retry นานกว่า)
+		// และห้ามใช้ ctx ที่มี parent cancel (ต้องใช้ DisconnectedContext ถ้า Workflow โดนยกเลิก)
+		state = core.SagaState{Status: core.SagaStatusCompensating, LastError: err.Error()}
 
 		compensateCtx, _ := workflow.NewDisconnectedContext(ctx) // เพื่อให้ทำงานต่อได้แม้ Workflow หลักจะ Error
-		compensateOpts := workflow.WithActivityOptions(compensateCtx, inventoryOptions)
+		compensateOpts := workflow.WithActivityOptions(compensateCtx, compensationOptions)
 
-		errCompensate := workflow.ExecuteActivity(compensateOpts, ActivityReleaseStock, req.ProductID, req.Qty).Get(compensateCtx, nil)
+		errCompensate := steps.releaseStock(compensateOpts)
 
 		if errCompensate != nil {
 			logger.Error("Failed to compensate stock!", "Error", errCompensate)
 			// ตรงนี้คือจุดวิกฤต (System Administrator ต้องเข้ามาดู Manual)
+			// ห้ามจบ Workflow ทิ้งไว้เฉยๆ -> เข้าคิวรอ Operator สั่งงานผ่าน Signal
+			// (Workflow ที่เริ่มก่อนมี Feature นี้ จะจบแบบเดิมเพื่อให้ Replay ตรงกับ History)
+			v := workflow.GetVersion(compensateCtx, changeManualIntervention, workflow.DefaultVersion, 1)
+			if v >= 1 {
+				if errManual := waitForManualCompensation(compensateCtx, req, steps, compensationOptions, errCompensate, &state); errManual != nil {
+					return errManual
+				}
+			}
 		}
 
+		state = outcomeState(err)
+		if errRecord := orders.recordOutcome(compensateCtx, err); errRecord != nil {
+			return errRecord
+		}
 		return err // ส่ง Error เดิมกลับไปบอกว่า Order Failed
 	}
 
+	// ตัดเงินไปแล้ว -> หยุดนับเวลา และต้องบันทึกให้จบแม้ Workflow จะโดน Cancel ตอนนี้
+	deadline.stop()
+	recordCtx, _ := workflow.NewDisconnectedContext(ctx)
+	if err := orders.record(recordCtx, core.EventPaymentCaptured, ""); err != nil {
+		return err
+	}
+
+	// -----------------------------------------------------
+	// STEP 3: Commit Stock (ของที่จองไว้ถูกขายจริง)
+	// -----------------------------------------------------
+	// เงินถูกตัดไปแล้ว Order ต้องจบเป็น COMPLETED เสมอ ถ้า Commit ไม่ผ่าน
+	// ของจะค้างอยู่ในยอด reserved ของ Read Model (ยอดที่ขายได้ยังถูกต้อง) -> Log ไว้ให้คนตาม
+	// (Workflow ที่เริ่มก่อนมี Step นี้ หรือยังใช้ Activity แบบเก่า จะข้ามไป)
+	if workflow.GetVersion(ctx, changeStockCommit, workflow.DefaultVersion, 1) >= 1 && steps.typed {
+		ctx3 := workflow.WithActivityOptions(recordCtx, commitOptions)
+		if err := steps.commitStock(ctx3); err != nil {
+			logger.Error("Failed to commit stock; reservation left open", "Error", err)
+		}
+	}
+
+	if err := orders.record(recordCtx, core.EventOrderCompleted, ""); err != nil {
+		return err
+	}
+
+	state = core.SagaState{Status: core.SagaStatusCompleted}
 	logger.Info("Order Saga completed successfully")
 	return nil
 }
+
+// releaseAbortedReservation สั่งคืนของหลังการจองถูกยกเลิกกลางทาง (ctx ต้องเป็น DisconnectedContext)
+// คืนไม่สำเร็จ = อาจมีของค้างจองอยู่ -> เข้าคิวรอ Operator เหมือนตอน Compensate ไม่ผ่าน
+func releaseAbortedReservation(ctx workflow.Context, req core.CreateOrderRequest, steps sagaSteps, compensationOptions workflow.ActivityOptions, cause error, state *core.SagaState) error {
+	*state = core.SagaState{Status: core.SagaStatusCompensating, LastError: cause.Error()}
+
+	err := steps.releaseStock(workflow.WithActivityOptions(ctx, compensationOptions))
+	if err == nil {
+		return nil
+	}
+	workflow.GetLogger(ctx).Error("Failed to release aborted reservation!", "Error", err)
+	return waitForManualCompensation(ctx, req, steps, compensationOptions, err, state)
+}
+
+// waitForManualCompensation พา Saga เข้าสถานะ NEEDS_ATTENTION แล้วรอ Signal จาก Operator
+// จนกว่าจะคืนของสำเร็จ หรือ Operator ยืนยันว่าแก้ไขเองแล้ว (ไม่มีทางหลุดออกไปเงียบๆ)
+func waitForManualCompensation(ctx workflow.Context, req core.CreateOrderRequest, steps sagaSteps, compensationOptions workflow.ActivityOptions, cause error, state *core.SagaState) error {
+	logger := workflow.GetLogger(ctx)
+	info := workflow.GetInfo(ctx)
+
+	localCtx := workflow.WithActivityOptions(ctx, sagaActivityOptions())
+
+	signals := workflow.GetSignalChannel(ctx, core.SignalIntervention)
+	attempts := 1
+	open := true // false = เคสยังเปิดอยู่จากรอบก่อน รอ Signal ต่อได้เลย
+
+	for {
+		if open {
+			*state = core.SagaState{Status: core.SagaStatusNeedsAttention, LastError: cause.Error()}
+
+			intervention := core.Intervention{
+				OrderID:    req.OrderID,
+				WorkflowID: info.WorkflowExecution.ID,
+				ProductID:  req.ProductID,
+				Qty:        req.Qty,
+				Error:      cause.Error(),
+				Attempts:   attempts,
+			}
+			if err := workflow.ExecuteActivity(localCtx, ActivityOpenIntervention, intervention).Get(localCtx, nil); err != nil {
+				return err
+			}
+		}
+		open = true
+
+		// รอ Operator สั่งงาน (Durable: Worker ดับก็ยังรออยู่)
+		var signal core.InterventionSignal
+		signals.Receive(ctx, &signal)
+		logger.Info("Manual intervention received", "Action", signal.Action, "Operator", signal.Operator, "Note", signal.Note)
+
+		var resolution string
+		switch signal.Action {
+		case core.ActionResolve:
+			resolution = "resolved-manually"
+		case core.ActionRetry, core.ActionForceRelease:
+			opts := compensationOptions
+			if signal.Action == core.ActionForceRelease {
+				// Force: Retry ไม่จำกัดจนกว่า Inventory จะรับ
+				policy := *compensationOptions.RetryPolicy
+				policy.MaximumAttempts = 0
+				opts.RetryPolicy = &policy
+			}
+			*state = core.SagaState{Status: core.SagaStatusCompensating, LastError: cause.Error()}
+
+			actCtx := workflow.WithActivityOptions(ctx, opts)
+			err := steps.releaseStock(actCtx)
+			if err != nil {
+				logger.Error("Manual compensation failed again", "Action", signal.Action, "Error", err)
+				cause = err
+				attempts++
+				continue // กลับไปรอคำสั่งใหม่
+			}
+			resolution = "released-by-" + signal.Action
+		default:
+			logger.Warn("Unknown intervention action ignored", "Action", signal.Action)
+			// Workflow ที่เริ่มก่อนแก้จะเปิดเคสซ้ำ (ต้องตรงกับ History เดิม)
+			open = workflow.GetVersion(ctx, changeIgnoreUnknownAction, workflow.DefaultVersion, 1) == workflow.DefaultVersion
+			continue
+		}
+
+		return workflow.ExecuteActivity(localCtx, ActivityResolveIntervention, req.OrderID, resolution, signal.Operator).Get(localCtx, nil)
+	}
+}
+
+// outcomeState แปลง Error ตอนจบให้เป็นสถานะของ Saga
+func outcomeState(err error) core.SagaState {
+	if isDeadlineExceeded(err) {
+		return core.SagaState{Status: core.SagaStatusTimedOut, LastError: err.Error()}
+	}
+	return core.SagaState{Status: core.SagaStatusFailed, LastError: err.Error()}
+}
+
+// sagaActivityOptions คือ Option ของ Activity ฝั่ง Orchestrator เอง (ส่งเข้า Queue ของ Workflow นี้)
+func sagaActivityOptions() workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+		RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 0}, // บันทึกต้องไม่หาย -> Retry ไม่จำกัด
+	}
+}
@@ -0,0 +1,230 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+
+	"contracts"
+	"external-orchestrator/core"
+)
+
+// sagaActivities คือ Activity ปลอมของทุก Service ที่ Saga เรียก (นับจำนวนครั้ง + บันทึก Order Event)
+type sagaActivities struct {
+	reserve func(ctx context.Context) error
+	release func() error
+	payment func(ctx context.Context) error
+
+	released    int
+	opened      int
+	resolved    string
+	orderEvents []string
+}
+
+func newSagaEnv(a *sagaActivities) *testsuite.TestWorkflowEnvironment {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(OrderSagaWorkflow)
+
+	contracts.ReserveStock.Register(env, func(ctx context.Context, in contracts.ReserveStockInput) (contracts.StockOutput, error) {
+		if a.reserve != nil {
+			return contracts.StockOutput{Version: 1}, a.reserve(ctx)
+		}
+		return contracts.StockOutput{Version: 1}, nil
+	})
+	contracts.ReleaseStock.Register(env, func(ctx context.Context, in contracts.ReleaseStockInput) (contracts.StockOutput, error) {
+		a.released++
+		if a.release != nil {
+			return contracts.StockOutput{}, a.release()
+		}
+		return contracts.StockOutput{Version: 2}, nil
+	})
+	contracts.CommitStock.Register(env, func(ctx context.Context, in contracts.CommitStockInput) (contracts.StockOutput, error) {
+		return contracts.StockOutput{Version: 2}, nil
+	})
+	contracts.ProcessPayment.Register(env, func(ctx context.Context, in contracts.ProcessPaymentInput) (contracts.Void, error) {
+		if a.payment != nil {
+			return contracts.Void{}, a.payment(ctx)
+		}
+		return contracts.Void{}, nil
+	})
+	env.RegisterActivityWithOptions(func(ctx context.Context, event core.OrderEvent) error {
+		a.orderEvents = append(a.orderEvents, event.Type)
+		return nil
+	}, activity.RegisterOptions{Name: ActivityRecordOrderEvent})
+	env.RegisterActivityWithOptions(func(ctx context.Context, in core.Intervention) error {
+		a.opened++
+		return nil
+	}, activity.RegisterOptions{Name: ActivityOpenIntervention})
+	env.RegisterActivityWithOptions(func(ctx context.Context, orderID, resolution, operator string) error {
+		a.resolved = resolution
+		return nil
+	}, activity.RegisterOptions{Name: ActivityResolveIntervention})
+	return env
+}
+
+var sagaRequest = core.CreateOrderRequest{OrderID: "ORD-T1", ProductID: "iphone-15", Qty: 1, Amount: 10000}
+
+// noRetry: Activity ที่พังให้ Fail ทันที (เทสไม่ต้องรอ Backoff)
+var noRetry = core.StepPolicy{MaximumAttempts: 1}
+
+func TestOrderSagaUnknownInterventionActionKeepsCaseOpen(t *testing.T) {
+	a := &sagaActivities{
+		payment: func(context.Context) error { return errors.New("gateway down") },
+		release: func() error { return errors.New("inventory down") },
+	}
+	env := newSagaEnv(a)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(core.SignalIntervention, core.InterventionSignal{Action: "reboot", Operator: "ops"})
+	}, time.Minute)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(core.SignalIntervention, core.InterventionSignal{Action: core.ActionResolve, Operator: "ops"})
+	}, 2*time.Minute)
+
+	opts := core.SagaOptions{Steps: core.StepPolicies{Payment: noRetry, Compensation: noRetry}}
+	env.ExecuteWorkflow(OrderSagaWorkflow, sagaRequest, opts)
+
+	if !env.IsWorkflowCompleted() || env.GetWorkflowError() == nil {
+		t.Fatalf("saga should end failed, err = %v", env.GetWorkflowError())
+	}
+	if a.opened != 1 {
+		t.Fatalf("intervention opened %d times, want 1 (unknown action must not reopen)", a.opened)
+	}
+	if a.resolved != "resolved-manually" {
+		t.Fatalf("resolution = %q", a.resolved)
+	}
+}
+
+// blockUntilCancelled คือ Activity ที่ค้างจนกว่า Saga จะยกเลิก (จำลอง Service ที่ไม่ตอบ)
+func blockUntilCancelled(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// activityOptionsSpy จับ ActivityOptions ที่ Saga ใช้เรียกแต่ละ Activity
+// (Test Env ตอบ Cancel ทันทีเสมอ ไม่รอ Activity จบจริง จึงต้องดู WaitForCancellation จาก Options แทน)
+type activityOptionsSpy struct {
+	interceptor.WorkerInterceptorBase
+	options map[string]workflow.ActivityOptions
+}
+
+func (s *activityOptionsSpy) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	return &spyInbound{WorkflowInboundInterceptorBase: interceptor.WorkflowInboundInterceptorBase{Next: next}, spy: s}
+}
+
+type spyInbound struct {
+	interceptor.WorkflowInboundInterceptorBase
+	spy *activityOptionsSpy
+}
+
+func (i *spyInbound) Init(outbound interceptor.WorkflowOutboundInterceptor) error {
+	return i.Next.Init(&spyOutbound{WorkflowOutboundInterceptorBase: interceptor.WorkflowOutboundInterceptorBase{Next: outbound}, spy: i.spy})
+}
+
+type spyOutbound struct {
+	interceptor.WorkflowOutboundInterceptorBase
+	spy *activityOptionsSpy
+}
+
+func (o *spyOutbound) ExecuteActivity(ctx workflow.Context, activityType string, args ...interface{}) workflow.Future {
+	o.spy.options[activityType] = workflow.GetActivityOptions(ctx)
+	return o.Next.ExecuteActivity(ctx, activityType, args...)
+}
+
+func TestOrderSagaAbortOutcomes(t *testing.T) {
+	tests := []struct {
+		name         string
+		activities   sagaActivities
+		cancelAfter  time.Duration // > 0 = Cancel Workflow หลังเวลานี้
+		noDeadline   bool          // ไม่ตั้ง OrderDeadline (ORDER_DEADLINE=0)
+		wantTimedOut bool
+		wantReleased int
+		wantEvents   []string
+	}{
+		{
+			name:         "deadline during reserve releases the order",
+			activities:   sagaActivities{reserve: blockUntilCancelled},
+			wantTimedOut: true,
+			wantReleased: 1,
+			wantEvents:   []string{core.EventOrderPlaced, core.EventOrderTimedOut},
+		},
+		{
+			name:         "deadline during payment compensates",
+			activities:   sagaActivities{payment: blockUntilCancelled},
+			wantTimedOut: true,
+			wantReleased: 1,
+			wantEvents:   []string{core.EventOrderPlaced, core.EventStockReserved, core.EventOrderTimedOut},
+		},
+		{
+			name:         "cancel during reserve releases the order",
+			activities:   sagaActivities{reserve: blockUntilCancelled},
+			cancelAfter:  100 * time.Millisecond,
+			wantReleased: 1,
+			wantEvents:   []string{core.EventOrderPlaced, core.EventOrderCancelled},
+		},
+		{
+			name:         "cancel during reserve with no deadline releases the order",
+			activities:   sagaActivities{reserve: blockUntilCancelled},
+			cancelAfter:  100 * time.Millisecond,
+			noDeadline:   true,
+			wantReleased: 1,
+			wantEvents:   []string{core.EventOrderPlaced, core.EventOrderCancelled},
+		},
+		{
+			name:       "rejected reserve does not release",
+			activities: sagaActivities{reserve: func(context.Context) error { return errors.New("out of stock") }},
+			wantEvents: []string{core.EventOrderPlaced, core.EventOrderFailed},
+		},
+		{
+			name:       "completed before the deadline",
+			wantEvents: []string{core.EventOrderPlaced, core.EventStockReserved, core.EventPaymentCaptured, core.EventOrderCompleted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.activities
+			env := newSagaEnv(&a)
+			spy := &activityOptionsSpy{options: map[string]workflow.ActivityOptions{}}
+			env.SetWorkerOptions(worker.Options{Interceptors: []interceptor.WorkerInterceptor{spy}})
+			if tt.cancelAfter > 0 {
+				env.RegisterDelayedCallback(env.CancelWorkflow, tt.cancelAfter)
+			}
+
+			opts := core.SagaOptions{
+				OrderDeadline: time.Second,
+				Steps:         core.StepPolicies{Reserve: noRetry, Payment: noRetry},
+			}
+			if tt.noDeadline {
+				opts.OrderDeadline = 0
+			}
+			env.ExecuteWorkflow(OrderSagaWorkflow, sagaRequest, opts)
+
+			if !env.IsWorkflowCompleted() {
+				t.Fatal("workflow did not complete")
+			}
+			err := env.GetWorkflowError()
+			if got := isDeadlineExceeded(err); got != tt.wantTimedOut {
+				t.Fatalf("timed out = %v, want %v (err = %v)", got, tt.wantTimedOut, err)
+			}
+			if a.released != tt.wantReleased {
+				t.Fatalf("released %d times, want %d", a.released, tt.wantReleased)
+			}
+			// ReleaseStock ต้องรอให้ ReserveStock จบจริงก่อน ไม่งั้นการจองที่ลงทีหลังจะไม่ถูกคืน
+			if !spy.options[contracts.ReserveStock.Name].WaitForCancellation {
+				t.Fatal("ReserveStock must wait for cancellation before the saga releases the order")
+			}
+			if strings.Join(a.orderEvents, ",") != strings.Join(tt.wantEvents, ",") {
+				t.Fatalf("order events = %v, want %v", a.orderEvents, tt.wantEvents)
+			}
+		})
+	}
+}
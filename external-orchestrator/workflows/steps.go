@@ -0,0 +1,60 @@
+package workflows
+
+import (
+	"go.temporal.io/sdk/workflow"
+
+	"contracts"
+	"external-orchestrator/core"
+)
+
+// sagaSteps เรียก Activity ของ Inventory / Payment ตาม Contract กลาง (module contracts)
+// typed = false คือ Workflow ที่เริ่มก่อนมี Contract: ต้องเรียกชื่อเดิมแบบ Positional
+// ให้ Command ตรงกับ History เดิม (ชื่อ Activity ต่างกัน = Non-determinism)
+type sagaSteps struct {
+	typed bool
+	req   core.CreateOrderRequest
+}
+
+func (s sagaSteps) reserveStock(ctx workflow.Context) error {
+	if !s.typed {
+		return workflow.ExecuteActivity(ctx, contracts.LegacyReserveStock, s.req.ProductID, s.req.Qty, s.req.OrderID).Get(ctx, nil)
+	}
+	_, err := contracts.ReserveStock.Execute(ctx, contracts.ReserveStockInput{
+		OrderID:   s.req.OrderID,
+		ProductID: s.req.ProductID,
+		Qty:       s.req.Qty,
+	})
+	return err
+}
+
+func (s sagaSteps) releaseStock(ctx workflow.Context) error {
+	if !s.typed {
+		return workflow.ExecuteActivity(ctx, contracts.LegacyReleaseStock, s.req.ProductID, s.req.Qty, s.req.OrderID).Get(ctx, nil)
+	}
+	_, err := contracts.ReleaseStock.Execute(ctx, contracts.ReleaseStockInput{
+		OrderID:   s.req.OrderID,
+		ProductID: s.req.ProductID,
+		Qty:       s.req.Qty,
+	})
+	return err
+}
+
+// commitStock มีแต่แบบ Contract (Workflow รุ่นก่อน Contract ไม่มี Step นี้)
+func (s sagaSteps) commitStock(ctx workflow.Context) error {
+	_, err := contracts.CommitStock.Execute(ctx, contracts.CommitStockInput{
+		OrderID:   s.req.OrderID,
+		ProductID: s.req.ProductID,
+	})
+	return err
+}
+
+func (s sagaSteps) processPayment(ctx workflow.Context) error {
+	if !s.typed {
+		return workflow.ExecuteActivity(ctx, contracts.LegacyProcessPayment, s.req.OrderID, s.req.Amount).Get(ctx, nil)
+	}
+	_, err := contracts.ProcessPayment.Execute(ctx, contracts.ProcessPaymentInput{
+		OrderID: s.req.OrderID,
+		Amount:  s.req.Amount,
+	})
+	return err
+}
@@ -0,0 +1,33 @@
+package workflows
+
+// Change ID สำหรับ workflow.GetVersion
+//
+// กฎของไฟล์นี้: ทุกครั้งที่แก้ลำดับ/ชนิดของ Command ใน OrderSagaWorkflow
+// (เพิ่ม/ลบ/สลับ Activity, Timer, Signal) ต้องครอบโค้ดใหม่ด้วย GetVersion
+// แล้วเพิ่ม Change ID ที่นี่ เพื่อให้ Order ที่ค้างอยู่ระหว่าง Deploy Replay ผ่าน
+// ห้ามลบ Change ID จนกว่าจะแน่ใจว่าไม่มี Workflow เวอร์ชันเก่าค้างอยู่แล้ว
+const (
+	// v1: Compensate ไม่ผ่าน -> เข้าคิว Manual Intervention แทนการจบ Workflow เงียบๆ
+	changeManualIntervention = "manual-intervention"
+
+	// v1: บันทึก Transition ของ Order ลง Order Stream (order_events)
+	changeOrderEventStream = "order-event-stream"
+
+	// v1: เส้นตายของทั้ง Order (Durable Timer) -> หมดเวลาแล้ว Compensate + OrderTimedOut
+	changeOrderDeadline = "order-deadline"
+
+	// v1: เรียก Inventory / Payment ผ่าน Contract แบบ Struct (ชื่อ Activity ใหม่ เช่น ReserveStockV2)
+	changeTypedContracts = "typed-activity-contracts"
+
+	// v1: ตัดเงินสำเร็จ -> ยืนยันการขายกับ Inventory (StockCommitted) ก่อนปิด Order
+	changeStockCommit = "stock-commit"
+
+	// v1: Signal ที่ไม่รู้จักระหว่างรอ Operator -> รอ Signal ถัดไปเฉยๆ (เดิมเปิดเคสซ้ำด้วย OpenIntervention)
+	changeIgnoreUnknownAction = "ignore-unknown-intervention-action"
+
+	// v1: หมดเวลา / ถูก Cancel ระหว่างจอง -> สั่งคืนของของ Order นี้ (Inventory ไม่ได้จองให้ = ไม่ทำอะไร)
+	changeReleaseOnReserveAbort = "release-on-reserve-abort"
+
+	// v1: ถูก Cancel ระหว่างจองโดยไม่มีเส้นตาย -> รอ ReserveStock จบจริงก่อนสั่งคืน (เดิมรอเฉพาะตอนมีเส้นตาย)
+	changeWaitReserveCancel = "wait-reserve-cancellation"
+)
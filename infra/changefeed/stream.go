@@ -0,0 +1,44 @@
+package changefeed
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrHistoryLost / ErrStreamInvalidated = Resume Token ใช้ต่อไม่ได้ -> ต้องไล่จาก Collection ตรงๆ
+// Subscriber จัดการเอง ส่วน Projector ส่งต่อให้ Registry ผ่าน EventSource
+var (
+	ErrHistoryLost       = errors.New("change stream history lost") // Resume Token หลุดจาก Oplog ไปแล้ว
+	ErrStreamInvalidated = errors.New("change stream invalidated")  // Collection ถูก Drop / Rename
+)
+
+// Error Code ของ Server เมื่อ Resume ต่อไม่ได้
+const (
+	codeChangeStreamFatalError  = 280 // เช่น Resume Token ไม่อยู่ใน Oplog แล้ว (Server รุ่นเก่า)
+	codeChangeStreamHistoryLost = 286
+)
+
+// StreamError แปลง Error ของ Driver ที่ Resume ต่อไม่ได้ให้เป็น ErrHistoryLost (Error อื่นคืนตามเดิม)
+func StreamError(err error) error {
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && (serverErr.HasErrorCode(codeChangeStreamHistoryLost) || serverErr.HasErrorCode(codeChangeStreamFatalError)) {
+		return fmt.Errorf("%w: %v", ErrHistoryLost, err)
+	}
+	return err
+}
+
+// DocumentFilter แปลงเงื่อนไขของ Change Stream (fullDocument.x) ให้ใช้ Query Collection ตรงๆ ได้
+// เงื่อนไขที่ไม่ได้อยู่ใต้ fullDocument (เช่น operationType) ไม่มีในเอกสาร จึงถูกตัดทิ้ง
+func DocumentFilter(match bson.D) bson.D {
+	filter := bson.D{}
+	for _, e := range match {
+		if field, ok := strings.CutPrefix(e.Key, "fullDocument."); ok {
+			filter = append(filter, bson.E{Key: field, Value: e.Value})
+		}
+	}
+	return filter
+}
@@ -0,0 +1,226 @@
+// Package changefeed คือ Subscriber ของ MongoDB Change Stream ที่ Reactor ของ Choreography Saga ใช้ร่วมกัน
+// (Inventory / Payment / Order Tracker ของ Orchestrator)
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Handler รับ fullDocument ของ Event ที่ถูก Insert (ต้อง Idempotent: Event เดิมอาจถูกส่งมาซ้ำ)
+type Handler func(ctx context.Context, doc bson.Raw) error
+
+// maxBackoff คือระยะรอสูงสุดระหว่าง Retry (ทั้ง Handler และการเปิด Stream ใหม่)
+const maxBackoff = 30 * time.Second
+
+// Subscriber อ่าน Change Stream (เฉพาะ Insert) ของ Collection แล้วส่ง fullDocument ให้ Handler
+// Resume Token จะถูกบันทึกลง checkpoints หลัง Handler ทำสำเร็จเท่านั้น (At-least-once)
+//
+// ไม่มี Checkpoint = เริ่มจากต้น Oplog เหมือน Projector (Event ที่เกิดก่อน Deploy ครั้งแรกไม่หาย)
+// Token หลุดจาก Oplog / Stream ถูก Invalidate = ไล่ทุกเอกสารใน Collection ใหม่แล้วเปิด Stream จากตอนนี้
+// Stream พังแบบอื่น = รอแล้วเปิดใหม่จาก Checkpoint (Run จบเฉพาะตอน ctx ถูกยกเลิก)
+type Subscriber struct {
+	Name        string // ใช้เป็น _id ใน checkpoints
+	Source      *mongo.Collection
+	Checkpoints *mongo.Collection
+	Match       bson.D // เงื่อนไขเพิ่มเติม เช่น {{Key: "fullDocument.type", Value: "OrderPlaced"}}
+}
+
+func NewSubscriber(db *mongo.Database, name, source string, match bson.D) *Subscriber {
+	return &Subscriber{
+		Name:        name,
+		Source:      db.Collection(source),
+		Checkpoints: db.Collection("checkpoints"),
+		Match:       match,
+	}
+}
+
+// Run ทำงานจนกว่า ctx จะถูกยกเลิก (คืน ctx.Err() เสมอ)
+func (s *Subscriber) Run(ctx context.Context, handle Handler) error {
+	for attempt := 1; ; attempt++ {
+		err := s.watch(ctx, handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, ErrHistoryLost) || errors.Is(err, ErrStreamInvalidated) {
+			log.Printf("🚑 [%s] %v -> recovering from %s", s.Name, err, s.Source.Name())
+			err = s.recover(ctx, handle)
+			if err == nil {
+				attempt = 0
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+		backoff := backoffFor(attempt)
+		log.Printf("⚠️ [%s] Change stream stopped, reopening in %s: %v", s.Name, backoff, err)
+		if err := sleep(ctx, backoff); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Subscriber) watch(ctx context.Context, handle Handler) error {
+	streamOpts := options.ChangeStream()
+
+	var checkpoint struct {
+		ResumeToken bson.Raw `bson:"resume_token"`
+	}
+	err := s.Checkpoints.FindOne(ctx, bson.M{"_id": s.Name}).Decode(&checkpoint)
+	switch {
+	case err == nil:
+		streamOpts.SetResumeAfter(checkpoint.ResumeToken)
+	case errors.Is(err, mongo.ErrNoDocuments):
+		// ไม่มี Checkpoint -> เริ่มจากต้น Oplog (Handler Idempotent อยู่แล้ว)
+		// Oplog ไม่ครอบถึงแล้ว Server จะตอบ History Lost -> recover ไล่จาก Collection แทน
+		log.Printf("🆕 [%s] No checkpoint found. Reading %s from the start of the oplog...", s.Name, s.Source.Name())
+		startOfTime := primitive.Timestamp{T: 1, I: 0}
+		streamOpts.SetStartAtOperationTime(&startOfTime)
+	default:
+		return err
+	}
+
+	// Insert ที่ตรงเงื่อนไข + Drop / Rename / Invalidate เพื่อให้รู้ว่าต้อง Recover
+	insert := append(bson.D{{Key: "operationType", Value: "insert"}}, s.Match...)
+	lost := bson.D{{Key: "operationType", Value: bson.M{"$in": bson.A{"drop", "rename", "invalidate"}}}}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.D{{Key: "$or", Value: bson.A{insert, lost}}}}}}
+
+	stream, err := s.Source.Watch(ctx, pipeline, streamOpts)
+	if err != nil {
+		return StreamError(err)
+	}
+	defer stream.Close(context.Background())
+
+	log.Printf("👀 [%s] Watching %s...", s.Name, s.Source.Name())
+
+	for stream.Next(ctx) {
+		var change struct {
+			ID            bson.Raw `bson:"_id"`
+			OperationType string   `bson:"operationType"`
+			FullDocument  bson.Raw `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&change); err != nil {
+			// Decode ไม่ได้ = ไม่รู้ว่าเป็น Event อะไร ห้ามข้ามเงียบๆ -> หยุดแล้วเปิดใหม่จาก Checkpoint
+			return fmt.Errorf("decode change: %w", err)
+		}
+		if change.OperationType != "insert" {
+			return ErrStreamInvalidated
+		}
+
+		if err := s.handle(ctx, handle, change.FullDocument); err != nil {
+			return err
+		}
+		if err := s.save(ctx, change.ID); err != nil {
+			log.Printf("⚠️ [%s] Failed to save checkpoint: %v", s.Name, err)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return StreamError(err)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	// Stream ปิดเองโดยไม่มี Error = Server ปิด Cursor หลัง Invalidate
+	return ErrStreamInvalidated
+}
+
+// recover ใช้เมื่อ Resume Token ใช้ต่อไม่ได้
+//  1. จด Head ของ Stream ไว้ก่อน (Event ที่เข้ามาระหว่างไล่จะอยู่หลัง Head -> Stream ใหม่ส่งมาให้)
+//  2. ไล่ทุกเอกสารที่ตรงเงื่อนไขใน Collection ตามลำดับ _id (Handler Idempotent อยู่แล้ว)
+//  3. บันทึก Head เป็น Checkpoint แล้วให้ Run เปิด Stream ใหม่จากตรงนั้น
+func (s *Subscriber) recover(ctx context.Context, handle Handler) error {
+	head, err := s.head(ctx)
+	if err != nil {
+		return fmt.Errorf("read head: %w", err)
+	}
+
+	cursor, err := s.Source.Find(ctx, DocumentFilter(s.Match), options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return fmt.Errorf("read %s: %w", s.Source.Name(), err)
+	}
+	defer cursor.Close(context.Background())
+
+	caughtUp := 0
+	for cursor.Next(ctx) {
+		if err := s.handle(ctx, handle, cursor.Current); err != nil {
+			return err
+		}
+		caughtUp++
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", s.Source.Name(), err)
+	}
+
+	if err := s.save(ctx, head); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	log.Printf("✅ [%s] Caught up %d event(s) from %s, reopening change stream at current time.", s.Name, caughtUp, s.Source.Name())
+	return nil
+}
+
+// head คืน Resume Token ของตำแหน่งปัจจุบันของ Oplog
+func (s *Subscriber) head(ctx context.Context) (bson.Raw, error) {
+	stream, err := s.Source.Watch(ctx, mongo.Pipeline{})
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close(context.Background())
+
+	// ให้ Server ตอบกลับ 1 รอบเพื่อได้ Post-batch Resume Token
+	stream.TryNext(ctx)
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return stream.ResumeToken(), nil
+}
+
+// handle Retry จนกว่าจะสำเร็จ (ห้ามข้าม Event เพราะจะทำให้ Saga ค้าง)
+func (s *Subscriber) handle(ctx context.Context, handle Handler, doc bson.Raw) error {
+	for attempt := 1; ; attempt++ {
+		err := handle(ctx, doc)
+		if err == nil {
+			return nil
+		}
+		backoff := backoffFor(attempt)
+		log.Printf("❌ [%s] Handler failed (attempt %d), retry in %s: %v", s.Name, attempt, backoff, err)
+		if err := sleep(ctx, backoff); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Subscriber) save(ctx context.Context, token bson.Raw) error {
+	_, err := s.Checkpoints.UpdateOne(ctx,
+		bson.M{"_id": s.Name},
+		bson.M{"$set": bson.M{"resume_token": token}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// backoffFor = 1s, 2s, 3s, ... ไม่เกิน maxBackoff
+func backoffFor(attempt int) time.Duration {
+	backoff := time.Duration(attempt) * time.Second
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,45 @@
+package changefeed
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestDocumentFilterStripsFullDocumentPrefix(t *testing.T) {
+	match := bson.D{{Key: "fullDocument.type", Value: bson.M{"$in": bson.A{"PaymentProcessed", "PaymentFailed"}}}}
+
+	got := DocumentFilter(match)
+	if len(got) != 1 || got[0].Key != "type" {
+		t.Fatalf("filter = %v, want {type: ...}", got)
+	}
+	if len(DocumentFilter(bson.D{{Key: "operationType", Value: "insert"}})) != 0 {
+		t.Fatal("conditions outside fullDocument do not apply to documents")
+	}
+	if len(DocumentFilter(nil)) != 0 {
+		t.Fatal("no match should read every document")
+	}
+}
+
+func TestStreamErrorHistoryLost(t *testing.T) {
+	for _, code := range []int32{codeChangeStreamHistoryLost, codeChangeStreamFatalError} {
+		err := StreamError(mongo.CommandError{Code: code, Message: "resume point may no longer be in the oplog"})
+		if !errors.Is(err, ErrHistoryLost) {
+			t.Fatalf("code %d: err = %v, want ErrHistoryLost", code, err)
+		}
+	}
+
+	other := mongo.CommandError{Code: 6, Message: "host unreachable"}
+	if err := StreamError(other); errors.Is(err, ErrHistoryLost) {
+		t.Fatalf("network error must not trigger recovery: %v", err)
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	if backoffFor(1) != time.Second || backoffFor(5) != 5*time.Second || backoffFor(100) != maxBackoff {
+		t.Fatalf("backoff = %s %s %s", backoffFor(1), backoffFor(5), backoffFor(100))
+	}
+}
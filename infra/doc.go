@@ -0,0 +1,7 @@
+// Package infra คือ Adapter ของ Infrastructure ที่หลาย Service ใช้ร่วมกัน (แยกจาก contracts ที่มีแค่ Type กลาง)
+//
+// changefeed: Subscriber ของ MongoDB Change Stream + การจัดการ Resume Token ที่ใช้ต่อไม่ได้ (ใช้ร่วมกับ Projector)
+// outbox: Relay + Broker ของ Transactional Outbox (Adapter ของ MongoDB / NATS / In-memory)
+//
+// Service ที่ใช้อ้างถึงด้วย replace => ../infra จึงต้อง Build Image จาก Root ของ Repo เหมือน contracts
+package infra
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"infra/outbox"
+)
+
+// Broker คือ Broker ใน Process เดียวกัน (ใช้ตอน Dev / Test ที่ไม่มี NATS)
+// เก็บทุกข้อความไว้ใน RAM และแจกให้ Subscriber ที่ลงทะเบียนไว้
+type Broker struct {
+	mu          sync.Mutex
+	messages    []outbox.Message
+	subscribers []func(outbox.Message)
+
+	// FailNext ทำให้ Publish ครั้งถัดไป Error (จำลอง Broker ล่ม)
+	FailNext error
+}
+
+func NewBroker() *Broker {
+	return &Broker{}
+}
+
+var _ outbox.Broker = (*Broker)(nil)
+
+func (b *Broker) Publish(ctx context.Context, msg outbox.Message) error {
+	b.mu.Lock()
+	if err := b.FailNext; err != nil {
+		b.FailNext = nil
+		b.mu.Unlock()
+		return err
+	}
+	b.messages = append(b.messages, msg)
+	subscribers := append([]func(outbox.Message){}, b.subscribers...)
+	b.mu.Unlock()
+
+	for _, handle := range subscribers {
+		handle(msg)
+	}
+	return nil
+}
+
+// Subscribe ลงทะเบียน Handler ที่จะถูกเรียกทุกครั้งที่มีข้อความใหม่ (เรียกแบบ Synchronous)
+func (b *Broker) Subscribe(handle func(outbox.Message)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, handle)
+}
+
+// Messages คืนข้อความทั้งหมดที่เคยรับ เรียงตามลำดับที่รับ
+func (b *Broker) Messages() []outbox.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]outbox.Message{}, b.messages...)
+}
@@ -0,0 +1,55 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"infra/outbox"
+)
+
+// Repository อ่าน / Mark ข้อความใน Collection Outbox ของ Service หนึ่ง (เช่น inventory_outbox)
+type Repository struct {
+	Collection *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database, collection string) outbox.Repository {
+	return &Repository{
+		Collection: db.Collection(collection),
+	}
+}
+
+func (r *Repository) FetchPending(ctx context.Context, limit int) ([]outbox.Message, error) {
+	// _id เป็น ObjectID (Hex) -> เรียงตามเวลาที่เขียน
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.Collection.Find(ctx, bson.M{"status": outbox.StatusPending}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []outbox.Message
+	if err = cursor.All(ctx, &msgs); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (r *Repository) MarkPublished(ctx context.Context, id string) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{
+		"$set":   bson.M{"status": outbox.StatusPublished, "published_at": time.Now()},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"last_error": ""},
+	})
+	return err
+}
+
+func (r *Repository) MarkFailed(ctx context.Context, id string, reason string) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{"last_error": reason},
+		"$inc": bson.M{"attempts": 1},
+	})
+	return err
+}
@@ -0,0 +1,49 @@
+package nats
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"infra/outbox"
+)
+
+// JetStreamPublisher ส่งข้อความเข้า NATS JetStream
+// ใช้ Outbox ID เป็น Nats-Msg-Id -> ถ้า Relay ส่งซ้ำภายใน Duplicate Window JetStream จะทิ้งให้
+type JetStreamPublisher struct {
+	JS jetstream.JetStream
+}
+
+// NewJetStreamPublisher สร้าง (หรืออัปเดต) Stream ที่ครอบ subjects ของ Service ที่ส่ง เช่น "inventory.>"
+func NewJetStreamPublisher(ctx context.Context, nc *nats.Conn, streamName string, subjects []string) (outbox.Broker, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       streamName,
+		Subjects:   subjects,
+		Storage:    jetstream.FileStorage,
+		Duplicates: 2 * time.Minute,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &JetStreamPublisher{JS: js}, nil
+}
+
+func (p *JetStreamPublisher) Publish(ctx context.Context, msg outbox.Message) error {
+	out := &nats.Msg{
+		Subject: msg.Subject,
+		Data:    msg.Payload,
+		Header:  nats.Header{},
+	}
+	out.Header.Set("Stream-Id", msg.StreamID)
+
+	// รอ PubAck -> ถ้าได้ Ack แปลว่า JetStream เก็บลง Disk แล้ว
+	_, err := p.JS.PublishMsg(ctx, out, jetstream.WithMsgID(msg.ID))
+	return err
+}
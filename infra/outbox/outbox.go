@@ -0,0 +1,45 @@
+// Package outbox คือ Transactional Outbox ที่ Inventory / Payment ใช้ร่วมกัน
+//
+// Service เขียน Message ลง Collection Outbox ของตัวเองใน Transaction เดียวกับ Event จริง
+// แล้ว Relay ค่อยส่งออกไปที่ Broker (NATS JetStream / In-memory) ตามลำดับของแต่ละ Stream
+package outbox
+
+import (
+	"context"
+	"time"
+)
+
+// สถานะของข้อความใน Outbox
+const (
+	StatusPending   = "PENDING"
+	StatusPublished = "PUBLISHED"
+)
+
+// Message คือ Event ที่รอส่งออกไปที่ Broker
+// ถูกเขียนใน Transaction เดียวกับ Event จริง -> ถ้า Event ถูกบันทึก ข้อความนี้ต้องมีเสมอ
+type Message struct {
+	ID          string     `bson:"_id"`       // ใช้เป็น Message ID ให้ Broker กันข้อความซ้ำ
+	StreamID    string     `bson:"stream_id"` // ข้อความใน Stream เดียวกันต้องออกตามลำดับ
+	Seq         int        `bson:"seq"`       // ลำดับใน Stream (Version ของ Event, 0 = เรียงตามลำดับที่เขียน)
+	Subject     string     `bson:"subject"`   // เช่น inventory.StockReserved, payment.PaymentProcessed
+	Payload     []byte     `bson:"payload"`   // Event ในรูป JSON
+	Status      string     `bson:"status"`
+	Attempts    int        `bson:"attempts"`
+	LastError   string     `bson:"last_error,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at"`
+	PublishedAt *time.Time `bson:"published_at,omitempty"`
+}
+
+// Repository ใช้โดย Relay เพื่อดึงข้อความที่ยังไม่ได้ส่ง
+type Repository interface {
+	// ข้อความที่ยังไม่ได้ส่ง เรียงตามลำดับที่เขียน
+	FetchPending(ctx context.Context, limit int) ([]Message, error)
+	MarkPublished(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id string, reason string) error
+}
+
+// Broker คือปลายทางของ Event (NATS JetStream / In-memory)
+type Broker interface {
+	// Return nil เมื่อ Broker รับข้อความแล้วเท่านั้น (Ack)
+	Publish(ctx context.Context, msg Message) error
+}
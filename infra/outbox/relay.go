@@ -0,0 +1,96 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+)
+
+// Relay ดึงข้อความจาก Outbox ไปส่งที่ Broker
+//   - At-least-once: Mark ว่าส่งแล้วหลัง Broker Ack เท่านั้น (ตายกลางทาง = ส่งซ้ำ, Consumer ต้อง Idempotent)
+//   - ลำดับต่อ Stream: ถ้าข้อความไหนส่งไม่ผ่าน ข้อความถัดไปของ Stream เดียวกันจะรอรอบหน้า
+//
+// ออกแบบให้รันแค่ 1 ตัวต่อ Service (ถ้ารันหลายตัวลำดับจะไม่รับประกัน)
+type Relay struct {
+	Outbox    Repository
+	Broker    Broker
+	BatchSize int
+	Interval  time.Duration // ระยะห่างระหว่างรอบตอนไม่มีงานค้าง
+}
+
+func NewRelay(outbox Repository, broker Broker) *Relay {
+	return &Relay{
+		Outbox:    outbox,
+		Broker:    broker,
+		BatchSize: 100,
+		Interval:  500 * time.Millisecond,
+	}
+}
+
+// Run วน Flush จนกว่า ctx จะถูกยกเลิก
+func (r *Relay) Run(ctx context.Context) error {
+	log.Println("📤 Outbox relay started")
+	for {
+		published, err := r.Flush(ctx)
+		if err != nil {
+			log.Printf("⚠️ Outbox relay: %v", err)
+		}
+
+		// ยังมีงานเต็ม Batch และไม่มี Error -> ทำต่อทันที
+		if err == nil && published == r.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.Interval):
+		}
+	}
+}
+
+// Flush ส่งข้อความที่ค้างอยู่ 1 รอบ คืนจำนวนที่ส่งสำเร็จ
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	msgs, err := r.Outbox.FetchPending(ctx, r.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// แยกตาม Stream (คงลำดับที่เจอ Stream ก่อน-หลังไว้) แล้วเรียงตาม Seq ภายใน Stream
+	var streams []string
+	byStream := map[string][]Message{}
+	for _, msg := range msgs {
+		if _, ok := byStream[msg.StreamID]; !ok {
+			streams = append(streams, msg.StreamID)
+		}
+		byStream[msg.StreamID] = append(byStream[msg.StreamID], msg)
+	}
+
+	published := 0
+	var firstErr error
+	for _, streamID := range streams {
+		pending := byStream[streamID]
+		sort.SliceStable(pending, func(i, j int) bool { return pending[i].Seq < pending[j].Seq })
+
+		for _, msg := range pending {
+			if err := r.Broker.Publish(ctx, msg); err != nil {
+				if markErr := r.Outbox.MarkFailed(ctx, msg.ID, err.Error()); markErr != nil {
+					log.Printf("⚠️ Outbox relay: mark failed %s: %v", msg.ID, markErr)
+				}
+				if firstErr == nil {
+					firstErr = err
+				}
+				break // ห้ามส่งตัวถัดไปของ Stream นี้ก่อน ไม่งั้นลำดับเพี้ยน
+			}
+			// ถ้า Mark ไม่ผ่าน รอบหน้าจะส่งซ้ำ (At-least-once) -> หยุด Stream นี้ไว้ก่อนเช่นกัน
+			if err := r.Outbox.MarkPublished(ctx, msg.ID); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				break
+			}
+			published++
+		}
+	}
+	return published, firstErr
+}
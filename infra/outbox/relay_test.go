@@ -0,0 +1,176 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"infra/outbox"
+	"infra/outbox/memory"
+)
+
+// fakeOutbox คือ Outbox ใน RAM (คืนข้อความ PENDING ตามลำดับที่ใส่)
+type fakeOutbox struct {
+	msgs []outbox.Message
+}
+
+func (o *fakeOutbox) add(id, streamID string, seq int) {
+	o.msgs = append(o.msgs, outbox.Message{
+		ID:       id,
+		StreamID: streamID,
+		Seq:      seq,
+		Subject:  "inventory.StockReserved",
+		Status:   outbox.StatusPending,
+	})
+}
+
+// addPayment เพิ่มข้อความแบบ Payment: Stream คือ Order ID และไม่มี Seq (ลำดับ = ลำดับที่เขียน)
+func (o *fakeOutbox) addPayment(id, orderID, eventType string) {
+	o.msgs = append(o.msgs, outbox.Message{
+		ID:       id,
+		StreamID: orderID,
+		Subject:  "payment." + eventType,
+		Status:   outbox.StatusPending,
+	})
+}
+
+func (o *fakeOutbox) FetchPending(ctx context.Context, limit int) ([]outbox.Message, error) {
+	var pending []outbox.Message
+	for _, msg := range o.msgs {
+		if msg.Status == outbox.StatusPending && len(pending) < limit {
+			pending = append(pending, msg)
+		}
+	}
+	return pending, nil
+}
+
+func (o *fakeOutbox) MarkPublished(ctx context.Context, id string) error {
+	return o.update(id, func(msg *outbox.Message) { msg.Status = outbox.StatusPublished })
+}
+
+func (o *fakeOutbox) MarkFailed(ctx context.Context, id string, reason string) error {
+	return o.update(id, func(msg *outbox.Message) { msg.LastError = reason })
+}
+
+func (o *fakeOutbox) update(id string, apply func(*outbox.Message)) error {
+	for i := range o.msgs {
+		if o.msgs[i].ID == id {
+			o.msgs[i].Attempts++
+			apply(&o.msgs[i])
+			return nil
+		}
+	}
+	return fmt.Errorf("message %s not found", id)
+}
+
+func publishedIDs(broker *memory.Broker) []string {
+	var ids []string
+	for _, msg := range broker.Messages() {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+func TestRelayPublishesInStreamOrder(t *testing.T) {
+	store := &fakeOutbox{}
+	// a-2 ถูกเขียนก่อน a-1 ใน Outbox (เช่น ObjectID จากคนละเครื่อง) -> ต้องส่งตาม Seq
+	store.add("a-2", "iphone-15", 2)
+	store.add("b-1", "macbook-pro", 1)
+	store.add("a-1", "iphone-15", 1)
+
+	broker := memory.NewBroker()
+	relay := outbox.NewRelay(store, broker)
+
+	published, err := relay.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published != 3 {
+		t.Fatalf("published = %d, want 3", published)
+	}
+
+	got := fmt.Sprint(publishedIDs(broker))
+	if want := "[a-1 a-2 b-1]"; got != want {
+		t.Fatalf("publish order = %s, want %s", got, want)
+	}
+}
+
+func TestRelayHoldsStreamAfterFailure(t *testing.T) {
+	store := &fakeOutbox{}
+	store.add("a-1", "iphone-15", 1)
+	store.add("a-2", "iphone-15", 2)
+	store.add("b-1", "macbook-pro", 1)
+
+	broker := memory.NewBroker()
+	broker.FailNext = errors.New("broker unavailable")
+	relay := outbox.NewRelay(store, broker)
+
+	// รอบแรก: a-1 ส่งไม่ผ่าน -> a-2 ต้องรอ แต่ Stream อื่นไปต่อได้
+	published, err := relay.Flush(context.Background())
+	if err == nil {
+		t.Fatal("expected publish error")
+	}
+	if published != 1 {
+		t.Fatalf("published = %d, want 1", published)
+	}
+	if got := fmt.Sprint(publishedIDs(broker)); got != "[b-1]" {
+		t.Fatalf("published after failure = %s, want [b-1]", got)
+	}
+	if store.msgs[0].LastError == "" || store.msgs[0].Status != outbox.StatusPending {
+		t.Fatalf("a-1 should stay pending with last_error, got %+v", store.msgs[0])
+	}
+
+	// รอบสอง: Broker กลับมา -> ส่งที่เหลือตามลำดับ
+	if _, err := relay.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(publishedIDs(broker)); got != "[b-1 a-1 a-2]" {
+		t.Fatalf("publish order = %s, want [b-1 a-1 a-2]", got)
+	}
+}
+
+func TestRelayKeepsWriteOrderWithoutSeq(t *testing.T) {
+	store := &fakeOutbox{}
+	// Payment Event ไม่มี Version: ORD-1 จ่ายไม่ผ่านแล้วลองใหม่สำเร็จ ต้องออกตามลำดับที่เขียน
+	store.addPayment("p-1", "ORD-1", "PaymentFailed")
+	store.addPayment("p-2", "ORD-2", "PaymentProcessed")
+	store.addPayment("p-3", "ORD-1", "PaymentProcessed")
+
+	broker := memory.NewBroker()
+	if _, err := outbox.NewRelay(store, broker).Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(publishedIDs(broker)); got != "[p-1 p-3 p-2]" {
+		t.Fatalf("publish order = %s, want [p-1 p-3 p-2]", got)
+	}
+}
+
+func TestRelayHoldsOrderAfterPaymentPublishFailure(t *testing.T) {
+	store := &fakeOutbox{}
+	store.addPayment("p-1", "ORD-1", "PaymentFailed")
+	store.addPayment("p-2", "ORD-1", "PaymentProcessed")
+	store.addPayment("p-3", "ORD-2", "PaymentProcessed")
+
+	broker := memory.NewBroker()
+	broker.FailNext = errors.New("nats: no responders")
+	relay := outbox.NewRelay(store, broker)
+
+	if _, err := relay.Flush(context.Background()); err == nil {
+		t.Fatal("expected publish error")
+	}
+	// ORD-1 ต้องรอทั้ง Stream (ห้ามส่ง PaymentProcessed ก่อน PaymentFailed) แต่ ORD-2 ไปต่อได้
+	if got := fmt.Sprint(publishedIDs(broker)); got != "[p-3]" {
+		t.Fatalf("published after failure = %s, want [p-3]", got)
+	}
+	if msg := store.msgs[0]; msg.Attempts != 1 || msg.LastError != "nats: no responders" {
+		t.Fatalf("p-1 should record the failed attempt, got %+v", msg)
+	}
+
+	if _, err := relay.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(publishedIDs(broker)); got != "[p-3 p-1 p-2]" {
+		t.Fatalf("publish order = %s, want [p-3 p-1 p-2]", got)
+	}
+}
@@ -0,0 +1,106 @@
+package choreography
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"inventory-service/app"
+	"inventory-service/core"
+	"inventory-service/ports"
+)
+
+// InventoryReactor คือฝั่ง Inventory ของ Choreography Saga
+//
+//	OrderPlaced      -> จองของ -> StockReserved / StockReservationRejected
+//	PaymentFailed    -> คืนของ -> StockReleased
+//	PaymentProcessed -> ยืนยันการขาย -> StockCommitted
+type InventoryReactor struct {
+	Service *app.InventoryService
+	Orders  ports.OrderReader
+}
+
+func NewInventoryReactor(service *app.InventoryService, orders ports.OrderReader) *InventoryReactor {
+	return &InventoryReactor{Service: service, Orders: orders}
+}
+
+// OnOrderPlaced รับ fullDocument จาก order_events
+func (r *InventoryReactor) OnOrderPlaced(ctx context.Context, doc bson.Raw) error {
+	var order core.PlacedOrder
+	if err := bson.Unmarshal(doc, &order); err != nil {
+		return err
+	}
+	// Order ที่ Temporal เป็นคนคุม -> ไม่ใช่หน้าที่เรา
+	if order.Mode != core.SagaModeChoreography {
+		return nil
+	}
+
+	_, err := r.Service.ReserveStock(ctx, order.OrderID, order.ProductID, order.Qty)
+	if errors.Is(err, app.ErrOutOfStock) {
+		log.Printf("🚫 Reservation rejected: order=%s product=%s qty=%d", order.OrderID, order.ProductID, order.Qty)
+		_, err = r.Service.RejectReservation(ctx, order.OrderID, order.ProductID, order.Qty)
+		return err
+	}
+	if err == nil {
+		log.Printf("📦 Stock reserved: order=%s product=%s qty=%d", order.OrderID, order.ProductID, order.Qty)
+	}
+	return err
+}
+
+// OnPaymentFailed รับ fullDocument จาก payment_events
+func (r *InventoryReactor) OnPaymentFailed(ctx context.Context, doc bson.Raw) error {
+	order, err := r.paymentOrder(ctx, doc)
+	if err != nil || order == nil {
+		return err
+	}
+
+	// คืนเฉพาะที่เราจองให้ Order นี้จริงๆ
+	reservation, err := r.Service.FindReservation(ctx, order.ProductID, order.OrderID)
+	if err != nil || reservation == nil {
+		return err
+	}
+
+	log.Printf("↩️ Releasing stock: order=%s product=%s qty=%d", order.OrderID, order.ProductID, reservation.Qty)
+	_, err = r.Service.ReleaseStock(ctx, order.OrderID, order.ProductID, reservation.Qty)
+	return err
+}
+
+// OnPaymentProcessed รับ fullDocument จาก payment_events
+func (r *InventoryReactor) OnPaymentProcessed(ctx context.Context, doc bson.Raw) error {
+	order, err := r.paymentOrder(ctx, doc)
+	if err != nil || order == nil {
+		return err
+	}
+
+	_, err = r.Service.CommitStock(ctx, order.OrderID, order.ProductID)
+	if errors.Is(err, app.ErrNoReservation) {
+		// Payment ตัดเงินหลังจองสำเร็จเท่านั้น -> มาถึงตรงนี้แปลว่าข้อมูลผิดปกติ ต้องให้คนดู (Retry ไปก็ไม่หาย)
+		log.Printf("🚨 Cannot commit stock: order=%s product=%s: %v", order.OrderID, order.ProductID, err)
+		return nil
+	}
+	if err == nil {
+		log.Printf("✅ Stock committed: order=%s product=%s", order.OrderID, order.ProductID)
+	}
+	return err
+}
+
+// paymentOrder หา Order ของ Payment Event นี้ (nil = ไม่ใช่ Order ของ Choreography)
+func (r *InventoryReactor) paymentOrder(ctx context.Context, doc bson.Raw) (*core.PlacedOrder, error) {
+	var payment struct {
+		OrderID string `bson:"order_id"`
+	}
+	if err := bson.Unmarshal(doc, &payment); err != nil {
+		return nil, err
+	}
+
+	order, err := r.Orders.GetPlacedOrder(ctx, payment.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.Mode != core.SagaModeChoreography {
+		return nil, nil
+	}
+	return order, nil
+}
@@ -2,21 +2,30 @@ package mongo
 
 import (
 	"context"
+	"encoding/json"
 	"inventory-service/core"
 	"inventory-service/ports"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"infra/outbox"
 )
 
+// OutboxCollection คือ Outbox ของ Service นี้ (Relay อ่านจากที่นี่)
+const OutboxCollection = "inventory_outbox"
+
 type MongoRepository struct {
 	Collection *mongo.Collection
+	Outbox     *mongo.Collection
 }
 
 func NewMongoRepository(db *mongo.Database) ports.InventoryRepository {
 	return &MongoRepository{
 		Collection: db.Collection("events"), // เก็บลง collection นี้
+		Outbox:     db.Collection(OutboxCollection),
 	}
 }
 
@@ -37,7 +46,56 @@ func (r *MongoRepository) GetEvents(ctx context.Context, productID string) ([]co
 	return events, nil
 }
 
+func (r *MongoRepository) GetEventsUntil(ctx context.Context, productID string, until core.PointInTime) ([]core.StockEvent, error) {
+	filter := bson.M{"stream_id": productID}
+	switch {
+	case until.Version > 0:
+		filter["version"] = bson.M{"$lte": until.Version}
+	case !until.At.IsZero():
+		filter["timestamp"] = bson.M{"$lte": until.At}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+	cursor, err := r.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var events []core.StockEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	// Filter ตามเวลาอาจได้ Event ที่ข้าม Version มา (Timestamp ย้อน) -> ตัดให้เหลือช่วงต่อเนื่องจากต้น Stream
+	return until.Until(events), nil
+}
+
+// AppendEvent เขียน Event + Outbox ใน Transaction เดียว (ต้องรัน MongoDB แบบ Replica Set)
+// ถ้า Version ชน Transaction จะ Abort ทั้งคู่ -> ไม่มีข้อความผีหลุดออกไป
 func (r *MongoRepository) AppendEvent(ctx context.Context, event core.StockEvent) error {
-	_, err := r.Collection.InsertOne(ctx, event)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	msg := outbox.Message{
+		ID:        primitive.NewObjectID().Hex(),
+		StreamID:  event.StreamID,
+		Seq:       event.Version,
+		Subject:   "inventory." + event.Type,
+		Payload:   payload,
+		Status:    outbox.StatusPending,
+		CreatedAt: event.Timestamp,
+	}
+
+	session, err := r.Collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if _, err := r.Collection.InsertOne(sc, event); err != nil {
+			return nil, err
+		}
+		return r.Outbox.InsertOne(sc, msg)
+	})
 	return err
 }
@@ -3,85 +3,66 @@ package temporal
 import (
 	"context"
 	"errors"
-	"time"
 
-	"inventory-service/core"
-	"inventory-service/ports"
+	"go.temporal.io/sdk/temporal"
+
+	"contracts"
+	"inventory-service/app"
 )
 
 type InventoryActivities struct {
-	Repo ports.InventoryRepository
+	Service *app.InventoryService
 }
 
-func NewInventoryActivities(repo ports.InventoryRepository) *InventoryActivities {
-	return &InventoryActivities{Repo: repo}
+func NewInventoryActivities(service *app.InventoryService) *InventoryActivities {
+	return &InventoryActivities{Service: service}
 }
 
-// Activity 1: จองสต็อก (Hard Check)
-func (a *InventoryActivities) ReserveStock(ctx context.Context, productID string, qty int) error {
-	// 1. Replay
-	events, _ := a.Repo.GetEvents(ctx, productID)
-	agg := core.NewInventoryAggregate(productID)
-	agg.Replay(events) // Replay จะได้ agg.LastVersion ออกมาด้วย (สมมติเป็น 5)
-
-	// 2. Validate Stock
-	if agg.CurrentStock < qty {
-		return errors.New("out of stock")
-	}
-
-	// 3. Prepare New Event
-	newEvent := core.StockEvent{
-		StreamID:  productID,
-		Type:      core.EventStockReserved,
-		Qty:       qty,
-		Timestamp: time.Now(),
-		Version:   agg.LastVersion + 1, // บังคับว่าเป็น Version 6 เท่านั้น
-	}
-
-	// 4. Append (ถ้ามีคนอื่นแย่งเขียน Version 6 ไปก่อนหน้านี้เสี้ยววินาที ตรงนี้จะ Error)
-	err := a.Repo.AppendEvent(ctx, newEvent)
-	if err != nil {
-		// ถ้า MongoDB ฟ้องว่า Duplicate Key
-		return errors.New("concurrency error: please retry")
-	}
-
-	return nil
+// Activity 1: จองสต็อก (Hard Check) -> contracts.ReserveStock
+func (a *InventoryActivities) ReserveStock(ctx context.Context, in contracts.ReserveStockInput) (contracts.StockOutput, error) {
+	// Replay -> Validate -> Append (Version ชน = Error ให้ Temporal Retry)
+	version, err := a.Service.ReserveStock(ctx, in.OrderID, in.ProductID, in.Qty)
+	return contracts.StockOutput{Version: version}, activityError(err)
 }
 
-// Activity 2: คืนสต็อก (Compensate)
+// Activity 2: คืนสต็อก (Compensate) -> contracts.ReleaseStock
 // จะถูกเรียกเมื่อ Payment พัง
-// adapters/temporal/activities.go
-
-func (a *InventoryActivities) ReleaseStock(ctx context.Context, productID string, qty int) error {
-	// 1. Load History (ต้องดึงประวัติเก่ามาก่อน เพื่อหา Version ล่าสุด)
-	events, err := a.Repo.GetEvents(ctx, productID)
-	if err != nil {
-		return err
-	}
+func (a *InventoryActivities) ReleaseStock(ctx context.Context, in contracts.ReleaseStockInput) (contracts.StockOutput, error) {
+	// ถ้าบังเอิญมีคนแย่งเขียน Version นี้ตัดหน้าไปพอดี (Concurrency)
+	// Temporal จะจับ Error นี้แล้ว Retry ให้เองตาม Policy -> Replay ใหม่ -> ได้ Version ใหม่
+	version, err := a.Service.ReleaseStock(ctx, in.OrderID, in.ProductID, in.Qty)
+	return contracts.StockOutput{Version: version}, activityError(err)
+}
 
-	// 2. Replay (เพื่อเอาค่า LastVersion)
-	agg := core.NewInventoryAggregate(productID)
-	agg.Replay(events)
+// Activity 3: ยืนยันการขาย (หลังตัดเงินสำเร็จ) -> contracts.CommitStock
+func (a *InventoryActivities) CommitStock(ctx context.Context, in contracts.CommitStockInput) (contracts.StockOutput, error) {
+	version, err := a.Service.CommitStock(ctx, in.OrderID, in.ProductID)
+	return contracts.StockOutput{Version: version}, activityError(err)
+}
 
-	// หมายเหตุ: ตอนคืนของ ปกติเราไม่ต้องเช็คว่า agg.CurrentStock พอไหม
-	// เพราะการคืนของคือการบวกเพิ่ม ย่อมทำได้เสมอ
+// LegacyReserveStock คือ Activity แบบ Positional (contracts.LegacyReserveStock) ของ Workflow รุ่นเก่า
+// orderID มาเป็น Argument ตัวท้าย: Workflow ที่เริ่มก่อนจะไม่ได้ส่งมา (= "" ไม่ผูกกับ Order)
+func (a *InventoryActivities) LegacyReserveStock(ctx context.Context, productID string, qty int, orderID string) error {
+	_, err := a.ReserveStock(ctx, contracts.ReserveStockInput{OrderID: orderID, ProductID: productID, Qty: qty})
+	return err
+}
 
-	// 3. Prepare New Event (สร้าง Event คืนของ โดยเพิ่ม Version ไป 1)
-	newEvent := core.StockEvent{
-		StreamID:  productID,
-		Type:      core.EventStockReleased, // เป็น Type คืนของ
-		Qty:       qty,
-		Timestamp: time.Now(),
-		Version:   agg.LastVersion + 1, // ✅ ต้องบวก 1 จากตัวล่าสุดเสมอ
-	}
+// LegacyReleaseStock คือ Activity แบบ Positional (contracts.LegacyReleaseStock) ของ Workflow รุ่นเก่า
+func (a *InventoryActivities) LegacyReleaseStock(ctx context.Context, productID string, qty int, orderID string) error {
+	_, err := a.ReleaseStock(ctx, contracts.ReleaseStockInput{OrderID: orderID, ProductID: productID, Qty: qty})
+	return err
+}
 
-	// 4. Append ลง DB
-	err = a.Repo.AppendEvent(ctx, newEvent)
-	if err != nil {
-		// ถ้าบังเอิญมีคนแย่งเขียน Version นี้ตัดหน้าไปพอดี (Concurrency)
-		// Temporal จะจับ Error นี้แล้ว Retry ให้เองตาม Policy -> กลับไปทำข้อ 1 ใหม่ -> ได้ Version ใหม่
-		return err
+// activityError แปะ Type ตาม contracts ให้ Error ของ Domain
+// Orchestrator จะได้เลือกได้ว่า Type ไหนไม่ต้อง Retry (ส่วนจะ Retry หรือไม่ เป็นเรื่องของ Step Policy)
+func activityError(err error) error {
+	switch {
+	case errors.Is(err, app.ErrOutOfStock):
+		return temporal.NewApplicationErrorWithCause(err.Error(), contracts.ErrTypeOutOfStock, err)
+	case errors.Is(err, app.ErrConcurrency):
+		return temporal.NewApplicationErrorWithCause(err.Error(), contracts.ErrTypeConcurrency, err)
+	case errors.Is(err, app.ErrNoReservation):
+		return temporal.NewApplicationErrorWithCause(err.Error(), contracts.ErrTypeNoReservation, err)
 	}
-
-	return nil
+	return err
 }
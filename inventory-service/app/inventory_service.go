@@ -0,0 +1,162 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"inventory-service/core"
+	"inventory-service/ports"
+)
+
+var (
+	ErrOutOfStock  = errors.New("out of stock")
+	ErrConcurrency = errors.New("concurrency error: please retry")
+	// Commit ได้เฉพาะ Order ที่จองไว้และยังไม่ได้คืน
+	ErrNoReservation = errors.New("no active reservation for order")
+)
+
+// InventoryService คือ Use Case ของ Inventory ที่ใช้ร่วมกันทั้ง Temporal Activity (Orchestration)
+// และ Reactor ที่ฟัง Event (Choreography)
+type InventoryService struct {
+	Repo ports.InventoryRepository
+}
+
+func NewInventoryService(repo ports.InventoryRepository) *InventoryService {
+	return &InventoryService{Repo: repo}
+}
+
+// ReserveStock จองสต็อก (Hard Check) -> StockReserved คืน Version ของ Event
+// ถ้าส่ง orderID มา และ Order นี้เคยจอง/ถูกปฏิเสธไปแล้ว จะไม่เขียนซ้ำ (Idempotent)
+func (s *InventoryService) ReserveStock(ctx context.Context, orderID, productID string, qty int) (int, error) {
+	// 1. Replay
+	agg, events, err := s.load(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+	if evt := findOrderEvent(events, orderID, core.EventStockReserved, core.EventStockReservationRejected); evt != nil {
+		return evt.Version, nil
+	}
+
+	// 2. Validate Stock
+	if agg.CurrentStock < qty {
+		return 0, ErrOutOfStock
+	}
+
+	// 3. Append (ถ้ามีคนอื่นแย่งเขียน Version เดียวกันไปก่อน ตรงนี้จะ Error)
+	return s.append(ctx, agg, orderID, core.EventStockReserved, qty)
+}
+
+// RejectReservation บันทึกว่าจองให้ Order นี้ไม่ได้ (ใช้ใน Choreography ให้คนอื่นรู้ผล)
+func (s *InventoryService) RejectReservation(ctx context.Context, orderID, productID string, qty int) (int, error) {
+	agg, events, err := s.load(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+	if evt := findOrderEvent(events, orderID, core.EventStockReserved, core.EventStockReservationRejected); evt != nil {
+		return evt.Version, nil
+	}
+	return s.append(ctx, agg, orderID, core.EventStockReservationRejected, qty)
+}
+
+// ReleaseStock คืนสต็อก (Compensate) -> StockReleased คืน Version ของ Event
+// Order ที่ไม่ได้ถือของอยู่ (ไม่เคยจอง / Commit ไปแล้ว) = ไม่ทำอะไร คืน Version 0
+// Saga จึงสั่งคืนได้เสมอแม้ไม่รู้ว่าการจองสำเร็จหรือไม่ (เช่น หมดเวลาระหว่างจอง)
+func (s *InventoryService) ReleaseStock(ctx context.Context, orderID, productID string, qty int) (int, error) {
+	agg, events, err := s.load(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+	if evt := findOrderEvent(events, orderID, core.EventStockReleased); evt != nil {
+		return evt.Version, nil
+	}
+	if orderID != "" {
+		// orderID ว่าง = Workflow รุ่นเก่าที่ไม่ได้ส่งมา ตรวจไม่ได้ -> คืนตาม qty แบบเดิม
+		reservation := findOrderEvent(events, orderID, core.EventStockReserved)
+		if reservation == nil || findOrderEvent(events, orderID, core.EventStockCommitted) != nil {
+			return 0, nil
+		}
+		qty = reservation.Qty // คืนเท่าที่จองไว้เสมอ
+	}
+
+	// หมายเหตุ: ตอนคืนของ ปกติเราไม่ต้องเช็คว่า agg.CurrentStock พอไหม
+	// เพราะการคืนของคือการบวกเพิ่ม ย่อมทำได้เสมอ
+	return s.append(ctx, agg, orderID, core.EventStockReleased, qty)
+}
+
+// CommitStock ยืนยันว่าของที่จองให้ Order นี้ถูกขายจริง (หลังตัดเงินสำเร็จ) -> StockCommitted
+// ยอดที่ Commit = ยอดที่จองไว้ (ไม่เชื่อ qty จากผู้เรียก เพราะห้ามตัดของเกินที่จอง)
+func (s *InventoryService) CommitStock(ctx context.Context, orderID, productID string) (int, error) {
+	agg, events, err := s.load(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+	if evt := findOrderEvent(events, orderID, core.EventStockCommitted); evt != nil {
+		return evt.Version, nil
+	}
+	reservation := findOrderEvent(events, orderID, core.EventStockReserved)
+	if reservation == nil || findOrderEvent(events, orderID, core.EventStockReleased) != nil {
+		return 0, ErrNoReservation
+	}
+	return s.append(ctx, agg, orderID, core.EventStockCommitted, reservation.Qty)
+}
+
+// FindReservation หา Event การจองของ Order นี้ (nil = ไม่เคยจอง)
+func (s *InventoryService) FindReservation(ctx context.Context, productID, orderID string) (*core.StockEvent, error) {
+	events, err := s.Repo.GetEvents(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		if events[i].OrderID == orderID && events[i].Type == core.EventStockReserved {
+			return &events[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *InventoryService) load(ctx context.Context, productID string) (*core.InventoryAggregate, []core.StockEvent, error) {
+	events, err := s.Repo.GetEvents(ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	agg := core.NewInventoryAggregate(productID)
+	agg.Replay(events)
+	return agg, events, nil
+}
+
+func (s *InventoryService) append(ctx context.Context, agg *core.InventoryAggregate, orderID, eventType string, qty int) (int, error) {
+	newEvent := core.StockEvent{
+		StreamID:  agg.ProductID,
+		OrderID:   orderID,
+		Type:      eventType,
+		Qty:       qty,
+		Timestamp: time.Now(),
+		Version:   agg.LastVersion + 1, // ✅ ต้องบวก 1 จากตัวล่าสุดเสมอ
+	}
+
+	if err := s.Repo.AppendEvent(ctx, newEvent); err != nil {
+		// ถ้า MongoDB ฟ้องว่า Duplicate Key -> มีคนเขียน Version นี้ตัดหน้า
+		// ผู้เรียก (Temporal / Reactor) จะ Retry -> Replay ใหม่ -> ได้ Version ใหม่
+		return 0, ErrConcurrency
+	}
+	return newEvent.Version, nil
+}
+
+// findOrderEvent หา Event ของ Order นี้ในชนิดที่กำหนด (orderID ว่าง = ไม่เช็ค, nil = ไม่เจอ)
+func findOrderEvent(events []core.StockEvent, orderID string, types ...string) *core.StockEvent {
+	if orderID == "" {
+		return nil
+	}
+	for i, evt := range events {
+		if evt.OrderID != orderID {
+			continue
+		}
+		for _, t := range types {
+			if evt.Type == t {
+				return &events[i]
+			}
+		}
+	}
+	return nil
+}
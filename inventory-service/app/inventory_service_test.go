@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"inventory-service/core"
+)
+
+// fakeRepo คือ Event Store ใน RAM (Version ซ้ำ = Error เหมือน Unique Index)
+type fakeRepo struct {
+	events []core.StockEvent
+}
+
+func (r *fakeRepo) GetEvents(ctx context.Context, productID string) ([]core.StockEvent, error) {
+	var out []core.StockEvent
+	for _, e := range r.events {
+		if e.StreamID == productID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) GetEventsUntil(ctx context.Context, productID string, until core.PointInTime) ([]core.StockEvent, error) {
+	events, _ := r.GetEvents(ctx, productID)
+	return until.Until(events), nil
+}
+
+func (r *fakeRepo) AppendEvent(ctx context.Context, event core.StockEvent) error {
+	for _, e := range r.events {
+		if e.StreamID == event.StreamID && e.Version == event.Version {
+			return errors.New("duplicate key")
+		}
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func stocked(qty int) *fakeRepo {
+	return &fakeRepo{events: []core.StockEvent{{StreamID: "iphone-15", Type: core.EventStockAdded, Qty: qty, Version: 1}}}
+}
+
+func TestReleaseStockWithoutReservationIsNoop(t *testing.T) {
+	repo := stocked(10)
+	svc := NewInventoryService(repo)
+
+	// หมดเวลาระหว่างจอง แต่ Inventory ยังไม่ได้จองให้ -> Saga สั่งคืน = ไม่มีอะไรเกิดขึ้น
+	version, err := svc.ReleaseStock(context.Background(), "ORD-1", "iphone-15", 2)
+	if err != nil || version != 0 {
+		t.Fatalf("release = v.%d, %v; want v.0, nil", version, err)
+	}
+	if len(repo.events) != 1 {
+		t.Fatalf("no event should be written: %+v", repo.events)
+	}
+}
+
+func TestReleaseStockReturnsReservedQtyOnce(t *testing.T) {
+	repo := stocked(10)
+	svc := NewInventoryService(repo)
+	ctx := context.Background()
+
+	if _, err := svc.ReserveStock(ctx, "ORD-1", "iphone-15", 3); err != nil {
+		t.Fatal(err)
+	}
+	first, err := svc.ReleaseStock(ctx, "ORD-1", "iphone-15", 99)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := svc.ReleaseStock(ctx, "ORD-1", "iphone-15", 99)
+	if err != nil || again != first {
+		t.Fatalf("repeated release = v.%d, %v; want v.%d", again, err, first)
+	}
+
+	agg, _, _ := svc.load(ctx, "iphone-15")
+	if agg.CurrentStock != 10 || len(repo.events) != 3 {
+		t.Fatalf("stock = %d after %d events, want 10 after 3", agg.CurrentStock, len(repo.events))
+	}
+}
+
+func TestReleaseStockAfterCommitIsNoop(t *testing.T) {
+	repo := stocked(10)
+	svc := NewInventoryService(repo)
+	ctx := context.Background()
+
+	if _, err := svc.ReserveStock(ctx, "ORD-1", "iphone-15", 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CommitStock(ctx, "ORD-1", "iphone-15"); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := svc.ReleaseStock(ctx, "ORD-1", "iphone-15", 3); err != nil || version != 0 {
+		t.Fatalf("release after commit = v.%d, %v; want v.0, nil", version, err)
+	}
+}
@@ -25,6 +25,7 @@ func (a *InventoryAggregate) Apply(event StockEvent) {
 	case EventStockReleased:
 		a.CurrentStock += event.Qty
 	}
+	// StockCommitted / StockReservationRejected: ยอดที่จองได้ไม่เปลี่ยน (Commit ถูกหักไปแล้วตอนจอง)
 	a.LastVersion = event.Version
 }
 
@@ -7,14 +7,41 @@ const (
 	EventStockReserved = "StockReserved"
 	EventStockReleased = "StockReleased" // ใช้ตอน Compensate
 	EventStockAdded    = "StockAdded"    // ใช้ตอนเติมของ
+
+	// ตัดเงินสำเร็จ -> ของที่จองไว้ถูกขายจริง (ยอดที่ขายได้ไม่เปลี่ยน แต่ของออกจากคลัง)
+	EventStockCommitted = "StockCommitted"
+
+	// จองให้ Order ไม่ได้ (ไม่เปลี่ยนยอด แต่ต้องบอกคนอื่นใน Choreography)
+	EventStockReservationRejected = "StockReservationRejected"
 )
 
-// โครงสร้าง Event ที่จะเก็บลง MongoDB
+// โครงสร้าง Event ที่จะเก็บลง MongoDB (json = รูปแบบที่ส่งออกไปที่ Broker)
 type StockEvent struct {
-	ID        string    `bson:"_id,omitempty"` // Mongo generates this
-	Version   int       `bson:"version"`
-	StreamID  string    `bson:"stream_id"` // Product ID
-	Type      string    `bson:"type"`
-	Qty       int       `bson:"qty"`
-	Timestamp time.Time `bson:"timestamp"`
+	ID        string    `bson:"_id,omitempty" json:"-"` // Mongo generates this
+	Version   int       `bson:"version" json:"version"`
+	StreamID  string    `bson:"stream_id" json:"stream_id"`                   // Product ID
+	OrderID   string    `bson:"order_id,omitempty" json:"order_id,omitempty"` // Correlation: Order ที่ทำให้เกิด Event นี้
+	Type      string    `bson:"type" json:"type"`
+	Qty       int       `bson:"qty" json:"qty"`
+	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
+}
+
+// PointInTime คือจุดที่ต้องการย้อนดู Stream: Version > 0 = ถึง Version นั้น, At = ถึงเวลานั้น (รวมเวลานั้นด้วย)
+// ค่าว่างทั้งคู่ = ล่าสุด
+type PointInTime struct {
+	At      time.Time
+	Version int
+}
+
+// Until ตัด Event (เรียงตาม Version เริ่มที่ 1) ให้เหลือเฉพาะช่วงต่อเนื่องจากต้น Stream ที่ยังไม่เลยจุดนี้
+// ตามเวลา = หยุดที่ Event แรกที่เลยเวลา แม้ตัวหลังจะมี Timestamp ย้อนกลับมา (นาฬิกาคนละเครื่อง)
+func (p PointInTime) Until(events []StockEvent) []StockEvent {
+	for i, evt := range events {
+		if evt.Version != i+1 ||
+			(p.Version > 0 && evt.Version > p.Version) ||
+			(p.Version == 0 && !p.At.IsZero() && evt.Timestamp.After(p.At)) {
+			return events[:i]
+		}
+	}
+	return events
 }
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPointInTimeUntilReplaysAggregateAtThatPoint(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2026, 9, d, h, 0, 0, 0, time.UTC) }
+	events := []StockEvent{
+		{StreamID: "iphone-15", Type: EventStockAdded, Qty: 100, Version: 1, Timestamp: day(1, 9)},
+		{StreamID: "iphone-15", OrderID: "ORD-1", Type: EventStockReserved, Qty: 2, Version: 2, Timestamp: day(30, 23)},
+		{StreamID: "iphone-15", OrderID: "ORD-1", Type: EventStockReleased, Qty: 2, Version: 3, Timestamp: time.Date(2026, 10, 1, 0, 5, 0, 0, time.UTC)},
+		// นาฬิกาของอีกเครื่องช้ากว่า: Timestamp ย้อนกลับไปก่อนสิ้นเดือน แต่เกิดหลัง v.3
+		{StreamID: "iphone-15", OrderID: "ORD-2", Type: EventStockReserved, Qty: 1, Version: 4, Timestamp: day(30, 23)},
+	}
+
+	tests := []struct {
+		name        string
+		point       PointInTime
+		wantVersion int
+		wantStock   int
+	}{
+		{name: "latest", wantVersion: 4, wantStock: 99},
+		{name: "version", point: PointInTime{Version: 3}, wantVersion: 3, wantStock: 100},
+		{name: "month end stops at first later event", point: PointInTime{At: day(30, 23).Add(time.Hour)}, wantVersion: 2, wantStock: 98},
+		{name: "before first event", point: PointInTime{At: day(1, 0)}, wantVersion: 0, wantStock: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agg := NewInventoryAggregate("iphone-15")
+			agg.Replay(tt.point.Until(events))
+			if agg.LastVersion != tt.wantVersion || agg.CurrentStock != tt.wantStock {
+				t.Fatalf("got v.%d stock %d, want v.%d stock %d", agg.LastVersion, agg.CurrentStock, tt.wantVersion, tt.wantStock)
+			}
+		})
+	}
+}
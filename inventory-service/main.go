@@ -4,20 +4,35 @@ import (
 	"context"
 	"log"
 	"os"
+	"time"
 
+	natsgo "github.com/nats-io/nats.go"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
 
+	"contracts"
+	"infra/changefeed"
+	"infra/outbox"
+	"infra/outbox/memory"
+	outboxMongo "infra/outbox/mongo"
+	natsAdapter "infra/outbox/nats"
+	"inventory-service/adapters/choreography"
 	mongoAdapter "inventory-service/adapters/mongo"
 	temporalAdapter "inventory-service/adapters/temporal"
+	"inventory-service/app"
+	"inventory-service/core"
 )
 
 func main() {
 
 	mongoURI := getEnv("MONGO_URI", "mongodb://localhost:27017/?directConnection=true")
 	temporalHost := getEnv("TEMPORAL_HOST", "127.0.0.1:7233")
+	sagaMode := getEnv("SAGA_MODE", core.SagaModeOrchestration)
+	eventBus := getEnv("EVENT_BUS", "memory") // nats = ส่งออก NATS JetStream, memory = เก็บใน Process (Dev)
 
 	// 1. Connect MongoDB
 	mongoOpts := options.Client().ApplyURI(mongoURI)
@@ -38,24 +53,81 @@ func main() {
 
 	// 3. Setup Adapters
 	repo := mongoAdapter.NewMongoRepository(db)
-	activities := temporalAdapter.NewInventoryActivities(repo)
+	service := app.NewInventoryService(repo)
+	activities := temporalAdapter.NewInventoryActivities(service)
+
+	// Outbox Relay: ส่ง Event ที่เขียนลง Outbox ออกไปที่ Broker
+	broker := newBroker(eventBus)
+	relay := outbox.NewRelay(outboxMongo.NewRepository(db, mongoAdapter.OutboxCollection), broker)
+	go func() {
+		log.Fatal(relay.Run(context.Background()))
+	}()
+
+	// Choreography: ฟัง Event เองแทนการรอ Temporal สั่ง (Worker ยังเปิดไว้ให้ Order เก่าที่ค้างอยู่)
+	if sagaMode == core.SagaModeChoreography {
+		startReactors(db, service)
+	}
 
 	// 4. Start Worker
-	// "order-queue" คือชื่อ Queue ที่เราตั้งไว้ใน Orchestrator
-	// หรือจะแยกเป็น "inventory-queue" ก็ได้แล้วแต่ design
-	w := worker.New(temporalClient, "inventory-queue", worker.Options{})
+	// ชื่อ Queue / ชื่อ Activity / Input มาจาก Contract เดียวกับที่ Orchestrator ใช้
+	w := worker.New(temporalClient, contracts.QueueInventory, worker.Options{})
 
-	// Register Functions ให้ Temporal รู้จัก
-	w.RegisterActivity(activities.ReserveStock)
-	w.RegisterActivity(activities.ReleaseStock)
+	// Register Functions ให้ Temporal รู้จัก (Signature ไม่ตรง Contract = Compile ไม่ผ่าน)
+	contracts.ReserveStock.Register(w, activities.ReserveStock)
+	contracts.ReleaseStock.Register(w, activities.ReleaseStock)
+	contracts.CommitStock.Register(w, activities.CommitStock)
 
-	log.Println("Inventory Worker Started...")
+	// Workflow รุ่นเก่ายังเรียกชื่อเดิมแบบ Positional Arguments
+	w.RegisterActivityWithOptions(activities.LegacyReserveStock, activity.RegisterOptions{Name: contracts.LegacyReserveStock})
+	w.RegisterActivityWithOptions(activities.LegacyReleaseStock, activity.RegisterOptions{Name: contracts.LegacyReleaseStock})
+
+	log.Printf("Inventory Worker Started... (saga mode: %s)", sagaMode)
 	err = w.Run(worker.InterruptCh())
 	if err != nil {
 		log.Fatal("Unable to start worker", err)
 	}
 }
 
+// newBroker เลือก Adapter ของ Event Bus ตาม EVENT_BUS
+func newBroker(eventBus string) outbox.Broker {
+	switch eventBus {
+	case "nats":
+		nc, err := natsgo.Connect(getEnv("NATS_URL", natsgo.DefaultURL))
+		if err != nil {
+			log.Fatal("Unable to connect NATS: ", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		broker, err := natsAdapter.NewJetStreamPublisher(ctx, nc, "INVENTORY", []string{"inventory.>"})
+		if err != nil {
+			log.Fatal("Unable to create JetStream stream: ", err)
+		}
+		return broker
+	case "memory":
+		return memory.NewBroker()
+	default:
+		log.Fatal("Invalid EVENT_BUS: ", eventBus)
+		return nil
+	}
+}
+
+// startReactors เปิด Subscriber ของ Choreography Saga (แต่ละตัวมี Checkpoint ของตัวเอง)
+func startReactors(db *mongo.Database, service *app.InventoryService) {
+	reactor := choreography.NewInventoryReactor(service, mongoAdapter.NewMongoOrderReader(db))
+
+	onOrderPlaced := changefeed.NewSubscriber(db, "inventory_on_order_placed", "order_events",
+		bson.D{{Key: "fullDocument.type", Value: "OrderPlaced"}})
+	onPaymentFailed := changefeed.NewSubscriber(db, "inventory_on_payment_failed", "payment_events",
+		bson.D{{Key: "fullDocument.type", Value: "PaymentFailed"}})
+	onPaymentProcessed := changefeed.NewSubscriber(db, "inventory_on_payment_processed", "payment_events",
+		bson.D{{Key: "fullDocument.type", Value: "PaymentProcessed"}})
+
+	// Run ทำงานจนกว่า Service จะปิด (Stream พัง = เปิดใหม่เอง ไม่ทำให้ทั้ง Process ตาย)
+	go onOrderPlaced.Run(context.Background(), reactor.OnOrderPlaced)
+	go onPaymentFailed.Run(context.Background(), reactor.OnPaymentFailed)
+	go onPaymentProcessed.Run(context.Background(), reactor.OnPaymentProcessed)
+}
+
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
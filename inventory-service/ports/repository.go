@@ -8,6 +8,15 @@ import (
 type InventoryRepository interface {
 	// ดึง Event ทั้งหมดมาเพื่อ Replay
 	GetEvents(ctx context.Context, productID string) ([]core.StockEvent, error)
-	// บันทึก Event ใหม่ลง DB
+	// ดึง Event ตั้งแต่ต้น Stream จนถึงจุดที่ขอ (Time Travel) เรียงตาม Version
+	// Orchestrator อ่าน collection events เดียวกันผ่าน InventoryEventReader ของตัวเองด้วยเงื่อนไขเดียวกันนี้
+	GetEventsUntil(ctx context.Context, productID string, until core.PointInTime) ([]core.StockEvent, error)
+	// บันทึก Event ใหม่ลง DB (พร้อมข้อความใน Outbox ใน Transaction เดียวกัน)
 	AppendEvent(ctx context.Context, event core.StockEvent) error
 }
+
+// OrderReader อ่าน Order ที่ถูกวางไว้ (ใช้ตัดสินใจใน Choreography)
+type OrderReader interface {
+	// คืน nil ถ้าไม่เจอ Order นี้
+	GetPlacedOrder(ctx context.Context, orderID string) (*core.PlacedOrder, error)
+}
@@ -0,0 +1,64 @@
+package choreography
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"payment-service/app"
+	"payment-service/core"
+	"payment-service/ports"
+)
+
+// PaymentReactor คือฝั่ง Payment ของ Choreography Saga
+//
+//	StockReserved -> ตัดเงิน -> PaymentProcessed / PaymentFailed
+type PaymentReactor struct {
+	Service *app.PaymentService
+	Orders  ports.OrderReader
+}
+
+func NewPaymentReactor(service *app.PaymentService, orders ports.OrderReader) *PaymentReactor {
+	return &PaymentReactor{Service: service, Orders: orders}
+}
+
+// OnStockReserved รับ fullDocument จาก events (Inventory Event Store)
+func (r *PaymentReactor) OnStockReserved(ctx context.Context, doc bson.Raw) error {
+	var reserved struct {
+		OrderID string `bson:"order_id"`
+	}
+	if err := bson.Unmarshal(doc, &reserved); err != nil {
+		return err
+	}
+	// ไม่มี order_id = การจองที่เขียนก่อน Inventory บันทึก order_id ลง Event (Recover อ่านย้อนทั้ง Collection จึงยังเจอได้)
+	// ผูกกับ Order ไม่ได้ = ไม่มีอะไรให้ตัดเงิน -> ข้าม (การจองของ Temporal มี order_id แล้ว และถูกกรองด้วย Mode ด้านล่าง)
+	if reserved.OrderID == "" {
+		return nil
+	}
+
+	order, err := r.Orders.GetPlacedOrder(ctx, reserved.OrderID)
+	if err != nil {
+		return err
+	}
+	if order == nil || order.Mode != core.SagaModeChoreography {
+		return nil
+	}
+
+	// Subscriber เป็น At-least-once -> ห้ามตัดเงินซ้ำ
+	done, err := r.Service.HasOutcome(ctx, order.OrderID)
+	if err != nil || done {
+		return err
+	}
+
+	err = r.Service.Charge(ctx, order.OrderID, order.Amount)
+	if errors.Is(err, app.ErrInsufficientFunds) {
+		log.Printf("💸 Payment failed: order=%s amount=%d", order.OrderID, order.Amount)
+		return r.Service.RecordFailure(ctx, order.OrderID, order.Amount, err.Error())
+	}
+	if err == nil {
+		log.Printf("💰 Payment processed: order=%s amount=%d", order.OrderID, order.Amount)
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+package mongo
+
+import (
+	"context"
+
+	"payment-service/core"
+	"payment-service/ports"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoOrderReader struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoOrderReader(db *mongo.Database) ports.OrderReader {
+	return &MongoOrderReader{
+		Collection: db.Collection("order_events"), // อ่านอย่างเดียว (Orchestrator เป็นเจ้าของ)
+	}
+}
+
+func (r *MongoOrderReader) GetPlacedOrder(ctx context.Context, orderID string) (*core.PlacedOrder, error) {
+	var order core.PlacedOrder
+	err := r.Collection.FindOne(ctx, bson.M{"stream_id": orderID, "type": "OrderPlaced"}).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &order, nil
+}
@@ -2,24 +2,73 @@ package mongo
 
 import (
 	"context"
+	"encoding/json"
 	"payment-service/core"
 	"payment-service/ports"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"infra/outbox"
 )
 
+// OutboxCollection คือ Outbox ของ Service นี้ (Relay อ่านจากที่นี่)
+const OutboxCollection = "payment_outbox"
+
 type MongoRepository struct {
 	Collection *mongo.Collection
+	Outbox     *mongo.Collection
 }
 
 func NewMongoRepository(db *mongo.Database) ports.PaymentRepository {
 	// แยก Database หรือ Collection ให้ชัดเจน
 	return &MongoRepository{
 		Collection: db.Collection("payment_events"),
+		Outbox:     db.Collection(OutboxCollection),
 	}
 }
 
+// AppendEvent เขียน Event + Outbox ใน Transaction เดียว (ต้องรัน MongoDB แบบ Replica Set)
 func (r *MongoRepository) AppendEvent(ctx context.Context, event core.PaymentEvent) error {
-	_, err := r.Collection.InsertOne(ctx, event)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	msg := outbox.Message{
+		ID:        primitive.NewObjectID().Hex(),
+		StreamID:  event.OrderID,
+		Subject:   "payment." + event.Type,
+		Payload:   payload,
+		Status:    outbox.StatusPending,
+		CreatedAt: event.Timestamp,
+	}
+
+	session, err := r.Collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if _, err := r.Collection.InsertOne(sc, event); err != nil {
+			return nil, err
+		}
+		return r.Outbox.InsertOne(sc, msg)
+	})
 	return err
 }
+
+func (r *MongoRepository) GetEvents(ctx context.Context, orderID string) ([]core.PaymentEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	cursor, err := r.Collection.Find(ctx, bson.M{"order_id": orderID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var events []core.PaymentEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -3,42 +3,35 @@ package temporal
 import (
 	"context"
 	"errors"
-	"time"
 
-	"payment-service/core"
-	"payment-service/ports"
+	"go.temporal.io/sdk/temporal"
+
+	"contracts"
+	"payment-service/app"
 )
 
 type PaymentActivities struct {
-	Repo ports.PaymentRepository
+	Service *app.PaymentService
 }
 
-func NewPaymentActivities(repo ports.PaymentRepository) *PaymentActivities {
-	return &PaymentActivities{Repo: repo}
+func NewPaymentActivities(service *app.PaymentService) *PaymentActivities {
+	return &PaymentActivities{Service: service}
 }
 
-// Activity: ProcessPayment
-func (a *PaymentActivities) ProcessPayment(ctx context.Context, orderID string, amount int) error {
-	// --- จำลอง Logic การตัดเงิน ---
-	// ในของจริงอาจจะยิง API ไปหา Stripe / Omise / Bank
-
-	if amount > 10000 {
-		return errors.New("insufficient funds (simulated)")
-	}
+// Activity: ProcessPayment -> contracts.ProcessPayment
+func (a *PaymentActivities) ProcessPayment(ctx context.Context, in contracts.ProcessPaymentInput) (contracts.Void, error) {
+	return contracts.Void{}, activityError(a.Service.Charge(ctx, in.OrderID, in.Amount)) // Return nil แปลว่า Activity สำเร็จ Temporal จะไปต่อ
+}
 
-	// ถ้าตัดเงินผ่าน ให้บันทึก Event
-	event := core.PaymentEvent{
-		OrderID:   orderID,
-		Amount:    amount,
-		Type:      core.EventPaymentProcessed,
-		Status:    "SUCCESS",
-		Timestamp: time.Now(),
-	}
+// LegacyProcessPayment คือ Activity แบบ Positional (contracts.LegacyProcessPayment) ของ Workflow รุ่นเก่า
+func (a *PaymentActivities) LegacyProcessPayment(ctx context.Context, orderID string, amount int) error {
+	return activityError(a.Service.Charge(ctx, orderID, amount))
+}
 
-	err := a.Repo.AppendEvent(ctx, event)
-	if err != nil {
-		return err
+// activityError แปะ Type ตาม contracts ให้ Error ของ Domain (Orchestrator ใช้เลือกว่าไม่ต้อง Retry)
+func activityError(err error) error {
+	if errors.Is(err, app.ErrInsufficientFunds) {
+		return temporal.NewApplicationErrorWithCause(err.Error(), contracts.ErrTypeInsufficientFunds, err)
 	}
-
-	return nil // Return nil แปลว่า Activity สำเร็จ Temporal จะไปต่อ
+	return err
 }
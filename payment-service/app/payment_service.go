@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-service/core"
+	"payment-service/ports"
+)
+
+var ErrInsufficientFunds = errors.New("insufficient funds (simulated)")
+
+// PaymentService คือ Use Case ของ Payment ที่ใช้ร่วมกันทั้ง Temporal Activity (Orchestration)
+// และ Reactor ที่ฟัง Event (Choreography)
+type PaymentService struct {
+	Repo ports.PaymentRepository
+}
+
+func NewPaymentService(repo ports.PaymentRepository) *PaymentService {
+	return &PaymentService{Repo: repo}
+}
+
+// Charge ตัดเงิน -> PaymentProcessed
+func (s *PaymentService) Charge(ctx context.Context, orderID string, amount int) error {
+	// --- จำลอง Logic การตัดเงิน ---
+	// ในของจริงอาจจะยิง API ไปหา Stripe / Omise / Bank
+
+	if amount > 10000 {
+		return ErrInsufficientFunds
+	}
+
+	// ถ้าตัดเงินผ่าน ให้บันทึก Event
+	return s.Repo.AppendEvent(ctx, core.PaymentEvent{
+		OrderID:   orderID,
+		Amount:    amount,
+		Type:      core.EventPaymentProcessed,
+		Status:    "SUCCESS",
+		Timestamp: time.Now(),
+	})
+}
+
+// RecordFailure บันทึกว่าตัดเงินไม่ผ่าน -> PaymentFailed (ใช้ใน Choreography ให้ Inventory คืนของ)
+func (s *PaymentService) RecordFailure(ctx context.Context, orderID string, amount int, reason string) error {
+	return s.Repo.AppendEvent(ctx, core.PaymentEvent{
+		OrderID:   orderID,
+		Amount:    amount,
+		Type:      core.EventPaymentFailed,
+		Status:    "FAILED",
+		Reason:    reason,
+		Timestamp: time.Now(),
+	})
+}
+
+// HasOutcome บอกว่า Order นี้เคยตัดเงิน (ผ่านหรือไม่ผ่าน) ไปแล้วหรือยัง
+func (s *PaymentService) HasOutcome(ctx context.Context, orderID string) (bool, error) {
+	events, err := s.Repo.GetEvents(ctx, orderID)
+	if err != nil {
+		return false, err
+	}
+	for _, evt := range events {
+		if evt.Type == core.EventPaymentProcessed || evt.Type == core.EventPaymentFailed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -7,11 +7,13 @@ const (
 	EventPaymentFailed    = "PaymentFailed"
 )
 
+// json = รูปแบบที่ส่งออกไปที่ Broker
 type PaymentEvent struct {
-	ID        string    `bson:"_id,omitempty"`
-	OrderID   string    `bson:"order_id"` // ใช้ OrderID เป็น Stream ID
-	Amount    int       `bson:"amount"`
-	Type      string    `bson:"type"`
-	Status    string    `bson:"status"` // SUCCESS / FAILED
-	Timestamp time.Time `bson:"timestamp"`
+	ID        string    `bson:"_id,omitempty" json:"-"`
+	OrderID   string    `bson:"order_id" json:"order_id"` // ใช้ OrderID เป็น Stream ID
+	Amount    int       `bson:"amount" json:"amount"`
+	Type      string    `bson:"type" json:"type"`
+	Status    string    `bson:"status" json:"status"` // SUCCESS / FAILED
+	Reason    string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
 }
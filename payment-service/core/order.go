@@ -0,0 +1,16 @@
+package core
+
+// โหมดของ Saga (เลือกตอน Start Service ด้วย SAGA_MODE)
+const (
+	SagaModeOrchestration = "orchestration" // Temporal เป็นคนสั่ง (OrderSagaWorkflow)
+	SagaModeChoreography  = "choreography"  // แต่ละ Service ฟัง Event แล้วทำต่อเอง
+)
+
+// PlacedOrder คือข้อมูล Order ที่ Inventory ต้องรู้ (อ่านจาก OrderPlaced ใน order_events)
+type PlacedOrder struct {
+	OrderID   string `bson:"stream_id"`
+	ProductID string `bson:"product_id"`
+	Qty       int    `bson:"qty"`
+	Amount    int    `bson:"amount"`
+	Mode      string `bson:"mode"`
+}
@@ -5,21 +5,36 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
+	natsgo "github.com/nats-io/nats.go"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
 
+	"contracts"
+	"infra/changefeed"
+	"infra/outbox"
+	"infra/outbox/memory"
+	outboxMongo "infra/outbox/mongo"
+	natsAdapter "infra/outbox/nats"
+	"payment-service/adapters/choreography"
 	mongoAdapter "payment-service/adapters/mongo"
 	temporalAdapter "payment-service/adapters/temporal"
+	"payment-service/app"
+	"payment-service/core"
 )
 
 func main() {
 
 	mongoURI := getEnv("MONGO_URI", "mongodb://localhost:27017/?directConnection=true")
 	temporalHost := getEnv("TEMPORAL_HOST", "127.0.0.1:7233")
-	fmt.Printf("🔧 Config: Mongo=%s | Temporal=%s\n", mongoURI, temporalHost)
+	sagaMode := getEnv("SAGA_MODE", core.SagaModeOrchestration)
+	eventBus := getEnv("EVENT_BUS", "memory") // nats = ส่งออก NATS JetStream, memory = เก็บใน Process (Dev)
+	fmt.Printf("🔧 Config: Mongo=%s | Temporal=%s | Saga=%s\n", mongoURI, temporalHost, sagaMode)
 
 	// 1. Connect MongoDB
 	mongoOpts := options.Client().ApplyURI(mongoURI)
@@ -40,13 +55,33 @@ func main() {
 
 	// 3. Setup Adapters
 	repo := mongoAdapter.NewMongoRepository(db)
-	activities := temporalAdapter.NewPaymentActivities(repo)
+	service := app.NewPaymentService(repo)
+	activities := temporalAdapter.NewPaymentActivities(service)
+
+	// Outbox Relay: ส่ง Event ที่เขียนลง Outbox ออกไปที่ Broker
+	broker := newBroker(eventBus)
+	relay := outbox.NewRelay(outboxMongo.NewRepository(db, mongoAdapter.OutboxCollection), broker)
+	go func() {
+		log.Fatal(relay.Run(context.Background()))
+	}()
+
+	// Choreography: ฟัง StockReserved เองแทนการรอ Temporal สั่ง
+	if sagaMode == core.SagaModeChoreography {
+		reactor := choreography.NewPaymentReactor(service, mongoAdapter.NewMongoOrderReader(db))
+		onStockReserved := changefeed.NewSubscriber(db, "payment_on_stock_reserved", "events",
+			bson.D{{Key: "fullDocument.type", Value: "StockReserved"}})
+		// Run ทำงานจนกว่า Service จะปิด (Stream พัง = เปิดใหม่เอง ไม่ทำให้ทั้ง Process ตาย)
+		go onStockReserved.Run(context.Background(), reactor.OnStockReserved)
+	}
 
 	// 4. Start Worker
-	// สังเกต: TaskQueue ชื่อ "payment-queue" (ต้องตรงกับที่ Orchestrator เรียก)
-	w := worker.New(temporalClient, "payment-queue", worker.Options{})
+	// ชื่อ Queue / ชื่อ Activity / Input มาจาก Contract เดียวกับที่ Orchestrator ใช้
+	w := worker.New(temporalClient, contracts.QueuePayment, worker.Options{})
+
+	contracts.ProcessPayment.Register(w, activities.ProcessPayment)
 
-	w.RegisterActivity(activities.ProcessPayment)
+	// Workflow รุ่นเก่ายังเรียกชื่อเดิมแบบ Positional Arguments
+	w.RegisterActivityWithOptions(activities.LegacyProcessPayment, activity.RegisterOptions{Name: contracts.LegacyProcessPayment})
 
 	log.Println("Payment Worker Started...")
 	err = w.Run(worker.InterruptCh())
@@ -55,6 +90,29 @@ func main() {
 	}
 }
 
+// newBroker เลือก Adapter ของ Event Bus ตาม EVENT_BUS
+func newBroker(eventBus string) outbox.Broker {
+	switch eventBus {
+	case "nats":
+		nc, err := natsgo.Connect(getEnv("NATS_URL", natsgo.DefaultURL))
+		if err != nil {
+			log.Fatal("Unable to connect NATS: ", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		broker, err := natsAdapter.NewJetStreamPublisher(ctx, nc, "PAYMENT", []string{"payment.>"})
+		if err != nil {
+			log.Fatal("Unable to create JetStream stream: ", err)
+		}
+		return broker
+	case "memory":
+		return memory.NewBroker()
+	default:
+		log.Fatal("Invalid EVENT_BUS: ", eventBus)
+		return nil
+	}
+}
+
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
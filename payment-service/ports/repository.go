@@ -6,5 +6,13 @@ import (
 )
 
 type PaymentRepository interface {
+	// บันทึก Event ใหม่ลง DB (พร้อมข้อความใน Outbox ใน Transaction เดียวกัน)
 	AppendEvent(ctx context.Context, event core.PaymentEvent) error
+	GetEvents(ctx context.Context, orderID string) ([]core.PaymentEvent, error)
+}
+
+// OrderReader อ่าน Order ที่ถูกวางไว้ (ใช้ตัดสินใจใน Choreography)
+type OrderReader interface {
+	// คืน nil ถ้าไม่เจอ Order นี้
+	GetPlacedOrder(ctx context.Context, orderID string) (*core.PlacedOrder, error)
 }
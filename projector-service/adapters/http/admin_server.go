@@ -0,0 +1,105 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"projector-service/app"
+)
+
+// AdminServer คือ HTTP API สำหรับ Operator (Instance ไหนก็ได้ ผลเหมือนกันเพราะสถานะอยู่ใน MongoDB)
+//
+//	GET  /projections                    สถานะทุก Projection
+//	GET  /projections/{name}             สถานะ Projection เดียว
+//	POST /projections/{name}/pause       หยุด (ทุก Instance คืน Lease ในรอบถัดไป)
+//	POST /projections/{name}/resume      รันต่อจาก Checkpoint เดิม
+//	POST /projections/{name}/reset       อ่านใหม่ตั้งแต่ต้น
+//	POST /projections/{name}/skip-to-now ข้าม Event ที่ค้างทั้งหมด
+type AdminServer struct {
+	Admin *app.ProjectorAdmin
+}
+
+func NewAdminServer(admin *app.ProjectorAdmin) *AdminServer {
+	return &AdminServer{Admin: admin}
+}
+
+func (s *AdminServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /projections", s.list)
+	mux.HandleFunc("GET /projections/{name}", s.get)
+	mux.HandleFunc("POST /projections/{name}/pause", s.command(s.Admin.Pause, "paused"))
+	mux.HandleFunc("POST /projections/{name}/resume", s.command(s.Admin.Resume, "resumed"))
+	mux.HandleFunc("POST /projections/{name}/reset", s.command(s.Admin.Reset, "reset"))
+	mux.HandleFunc("POST /projections/{name}/skip-to-now", s.command(s.Admin.SkipToNow, "skipped"))
+	return mux
+}
+
+// Run เปิด Server จนกว่า ctx จะถูกยกเลิก
+func (s *AdminServer) Run(ctx context.Context, addr string) error {
+	server := &http.Server{Addr: addr, Handler: s.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+
+	log.Printf("🛠️ Projector admin API listening on %s", addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *AdminServer) list(w http.ResponseWriter, r *http.Request) {
+	statuses, err := s.Admin.Status(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"projections": statuses})
+}
+
+func (s *AdminServer) get(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Admin.StatusOf(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// command: Reset / Skip รอให้ทุก Instance หยุดก่อน คำขออาจใช้เวลาถึง TTL ของ Lease
+func (s *AdminServer) command(run func(context.Context, string) error, done string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := run(r.Context(), name); err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"projection": name, "status": done})
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, app.ErrUnknownProjection):
+		status = http.StatusNotFound
+	case errors.Is(err, app.ErrProjectionBusy), errors.Is(err, app.ErrAdminInProgress), errors.Is(err, app.ErrSkipUnsupported):
+		status = http.StatusConflict
+	default:
+		log.Printf("⚠️ Admin request failed: %v", err)
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
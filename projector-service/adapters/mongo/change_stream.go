@@ -0,0 +1,281 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"infra/changefeed"
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// ChangeStreamSource อ่าน Event จาก MongoDB Change Stream (ต้องเป็น Replica Set)
+// Source เดียว = Watch ระดับ Collection (Token เดิมใช้ต่อได้)
+// หลาย Source = Watch ระดับ Database แล้วกรองด้วย ns.coll (Token เดียวครอบทุก Collection ตามลำดับ Oplog)
+type ChangeStreamSource struct {
+	DB *mongo.Database
+}
+
+func NewChangeStreamSource(db *mongo.Database) ports.EventSource {
+	return &ChangeStreamSource{DB: db}
+}
+
+func (s *ChangeStreamSource) Watch(ctx context.Context, sources []string, filter bson.D, resumeAfter bson.Raw, handle func(core.Change) error) error {
+	stream, err := s.open(ctx, sources, filter, resumeAfter)
+	if err != nil {
+		return changefeed.StreamError(err)
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		if isInvalidate(stream) {
+			return ports.ErrStreamInvalidated
+		}
+		change := decodeChange(stream, sources)
+		if err := handle(change); err != nil {
+			return err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return changefeed.StreamError(err)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	// Stream ปิดเองโดยไม่มี Error = Server ปิด Cursor หลัง Invalidate
+	return ports.ErrStreamInvalidated
+}
+
+func (s *ChangeStreamSource) WatchBatches(ctx context.Context, sources []string, filter bson.D, resumeAfter bson.Raw, size int, window time.Duration, handle func([]core.Change) error) error {
+	stream, err := s.open(ctx, sources, filter, resumeAfter)
+	if err != nil {
+		return changefeed.StreamError(err)
+	}
+	defer stream.Close(context.Background())
+
+	var batch []core.Change
+	var started time.Time
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := handle(batch)
+		batch = nil
+		return err
+	}
+
+	for {
+		// ยังไม่มีกลุ่ม -> รอ Event ตัวแรก, มีกลุ่มแล้ว -> เอาเฉพาะที่ค้างอยู่ (TryNext ไม่รอ Event ใหม่)
+		var next bool
+		if len(batch) == 0 {
+			next = stream.Next(ctx)
+		} else {
+			next = stream.TryNext(ctx)
+		}
+		if !next {
+			if len(batch) == 0 || stream.Err() != nil || ctx.Err() != nil {
+				break // กลุ่มที่ค้างไม่ได้บันทึก Checkpoint -> จะถูกส่งมาใหม่
+			}
+			// ไล่ทันแล้ว ส่งเท่าที่มี
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		if isInvalidate(stream) {
+			if err := flush(); err != nil { // Event ก่อนหน้า Invalidate ยังใช้ได้
+				return err
+			}
+			return ports.ErrStreamInvalidated
+		}
+		change := decodeChange(stream, sources)
+		if len(batch) == 0 {
+			started = time.Now()
+		}
+		batch = append(batch, change)
+		if len(batch) >= size || time.Since(started) >= window {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return changefeed.StreamError(err)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return ports.ErrStreamInvalidated
+}
+
+func (s *ChangeStreamSource) Head(ctx context.Context, sources []string) (bson.Raw, error) {
+	stream, err := s.watch(ctx, sources, mongo.Pipeline{}, options.ChangeStream())
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close(context.Background())
+
+	// ให้ Server ตอบกลับ 1 รอบเพื่อได้ Post-batch Resume Token (= ตำแหน่งปัจจุบันของ Oplog)
+	stream.TryNext(ctx)
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return stream.ResumeToken(), nil
+}
+
+func (s *ChangeStreamSource) CatchUp(ctx context.Context, sources []string, filter bson.D, resumeAfter bson.Raw, handle func(core.Change) error) (bson.Raw, error) {
+	stream, err := s.open(ctx, sources, filter, resumeAfter)
+	if err != nil {
+		return nil, changefeed.StreamError(err)
+	}
+	defer stream.Close(context.Background())
+
+	// TryNext = false แปลว่าไม่มี Event ค้างแล้ว (ไม่รอ Event ใหม่)
+	for stream.TryNext(ctx) {
+		if isInvalidate(stream) {
+			return nil, ports.ErrStreamInvalidated
+		}
+		change := decodeChange(stream, sources)
+		if err := handle(change); err != nil {
+			return nil, err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, changefeed.StreamError(err)
+	}
+	return stream.ResumeToken(), nil
+}
+
+func (s *ChangeStreamSource) History(ctx context.Context, sources []string, filter bson.D, after map[string]int, handle func(core.Change) error) error {
+	for _, source := range sources {
+		if err := s.history(ctx, source, filter, after, handle); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+	}
+	return nil
+}
+
+func (s *ChangeStreamSource) history(ctx context.Context, source string, filter bson.D, after map[string]int, handle func(core.Change) error) error {
+	query := changefeed.DocumentFilter(filter)
+	if after != nil {
+		// Stream ที่ View มีแล้ว -> เฉพาะ Version ที่ใหม่กว่า, Stream ที่ View ยังไม่มี -> ทั้งหมด
+		known := bson.A{}
+		or := bson.A{}
+		for streamID, version := range after {
+			known = append(known, streamID)
+			or = append(or, bson.M{"stream_id": streamID, "version": bson.M{"$gt": version}})
+		}
+		or = append(or, bson.M{"stream_id": bson.M{"$nin": known}})
+		query = append(query, bson.E{Key: "$or", Value: or})
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "stream_id", Value: 1}, {Key: "version", Value: 1}})
+	cursor, err := s.DB.Collection(source).Find(ctx, query, findOpts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(ctx) {
+		if err := handle(core.HistoryChange(source, cursor.Current)); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// Latest ใช้ _id (ObjectID เรียงตามเวลาที่เขียน) หาตัวใหม่สุด ไม่ต้องมี Index บน timestamp
+func (s *ChangeStreamSource) Latest(ctx context.Context, sources []string, filter bson.D) (time.Time, error) {
+	var latest time.Time
+	opts := options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}}).SetProjection(bson.M{"timestamp": 1})
+	for _, source := range sources {
+		var event struct {
+			Timestamp time.Time `bson:"timestamp"`
+		}
+		err := s.DB.Collection(source).FindOne(ctx, changefeed.DocumentFilter(filter), opts).Decode(&event)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s: %w", source, err)
+		}
+		if event.Timestamp.After(latest) {
+			latest = event.Timestamp
+		}
+	}
+	return latest, nil
+}
+
+func (s *ChangeStreamSource) open(ctx context.Context, sources []string, filter bson.D, resumeAfter bson.Raw) (*mongo.ChangeStream, error) {
+	streamOpts := options.ChangeStream()
+	if resumeAfter != nil {
+		// กรณี 1: มี Token (เคยรันแล้ว) -> ทำต่อจากเดิม
+		streamOpts.SetResumeAfter(resumeAfter)
+	} else {
+		// กรณี 2: ไม่มี Token (เพิ่งลบ Checkpoint หรือรันครั้งแรก)
+		// 💥 ต้องสั่งให้เริ่มอ่านตั้งแต่ "จุดเริ่มต้นของเวลา" (Timestamp 1, 0)
+		startOfTime := primitive.Timestamp{T: 1, I: 0}
+		streamOpts.SetStartAtOperationTime(&startOfTime)
+	}
+
+	// Filter: สนใจแค่การ Insert ข้อมูลใหม่ลง Event Store ที่ฟังอยู่ (+ เงื่อนไขของ Projection)
+	// และ Drop / Rename / Invalidate เพื่อให้รู้ว่าต้อง Recover
+	// (Stream ระดับ Database ไม่ถูก Invalidate เมื่อ Collection หาย จึงต้องดู Drop / Rename เอง)
+	ns := bson.E{Key: "ns.coll", Value: bson.M{"$in": sources}}
+	insert := bson.D{{Key: "operationType", Value: "insert"}, ns}
+	insert = append(insert, filter...)
+	lost := bson.D{{Key: "operationType", Value: bson.M{"$in": bson.A{"drop", "rename"}}}, ns}
+	match := bson.D{{Key: "$or", Value: bson.A{insert, lost, bson.D{{Key: "operationType", Value: "invalidate"}}}}}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: match}}}
+
+	return s.watch(ctx, sources, pipeline, streamOpts)
+}
+
+// watch เปิด Stream ระดับ Collection (Source เดียว) หรือ Database (หลาย Source)
+func (s *ChangeStreamSource) watch(ctx context.Context, sources []string, pipeline mongo.Pipeline, opts *options.ChangeStreamOptions) (*mongo.ChangeStream, error) {
+	switch len(sources) {
+	case 0:
+		return nil, errors.New("no source to watch")
+	case 1:
+		return s.DB.Collection(sources[0]).Watch(ctx, pipeline, opts)
+	default:
+		return s.DB.Watch(ctx, pipeline, opts)
+	}
+}
+
+func isInvalidate(stream *mongo.ChangeStream) bool {
+	op, _ := stream.Current.Lookup("operationType").StringValueOK()
+	return op == "invalidate" || op == "drop" || op == "rename"
+}
+
+// decodeChange แปลงข้อมูลที่ Change Stream ส่งมา
+// Decode ไม่ได้ = คืน Change ที่มี Err พร้อม Change Event ทั้งก้อน ให้ Registry เก็บลง Dead Letter (ไม่ข้ามเงียบๆ)
+func decodeChange(stream *mongo.ChangeStream, sources []string) core.Change {
+	var change struct {
+		ID           bson.Raw `bson:"_id"`          // นี่คือ Resume Token ของ Event นี้
+		FullDocument bson.Raw `bson:"fullDocument"` // ข้อมูล Event จริงๆ
+		NS           struct {
+			Coll string `bson:"coll"`
+		} `bson:"ns"`
+	}
+	if err := stream.Decode(&change); err != nil {
+		source, _ := stream.Current.Lookup("ns", "coll").StringValueOK()
+		if source == "" && len(sources) == 1 {
+			source = sources[0]
+		}
+		return core.Change{
+			Source: source,
+			Token:  stream.ResumeToken(), // Checkpoint ต้องเลื่อนผ่าน Event นี้ได้หลังเก็บลง Dead Letter
+			Raw:    stream.Current,
+			Err:    fmt.Errorf("decode change event: %w", err),
+		}
+	}
+	return core.Change{Source: change.NS.Coll, Token: change.ID, Document: change.FullDocument, Raw: stream.Current}
+}
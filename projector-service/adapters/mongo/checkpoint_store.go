@@ -0,0 +1,76 @@
+package mongo
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// MongoCheckpointStore เก็บว่าอ่านถึงไหนแล้ว (Resume Token) ใน checkpoints
+type MongoCheckpointStore struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoCheckpointStore(db *mongo.Database) ports.CheckpointStore {
+	return &MongoCheckpointStore{Collection: db.Collection("checkpoints")}
+}
+
+func (s *MongoCheckpointStore) Load(ctx context.Context, id string) (bson.Raw, error) {
+	var checkpoint core.Checkpoint
+	err := s.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&checkpoint)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return checkpoint.Token, nil
+}
+
+func (s *MongoCheckpointStore) Save(ctx context.Context, id string, token bson.Raw, eventAt time.Time) error {
+	set := bson.M{"resume_token": token, "updated_at": time.Now()}
+	update := bson.M{"$set": set}
+	if eventAt.IsZero() {
+		update["$unset"] = bson.M{"event_at": ""} // ไม่รู้ว่าอ่านถึงเวลาไหน -> ห้ามเหลือค่าเก่าไว้หลอก Lag
+	} else {
+		set["event_at"] = eventAt
+	}
+	_, err := s.Collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		update,
+		options.Update().SetUpsert(true), // ถ้าไม่มีให้สร้างใหม่
+	)
+	return err
+}
+
+func (s *MongoCheckpointStore) List(ctx context.Context, base string) ([]core.Checkpoint, error) {
+	cursor, err := s.Collection.Find(ctx, ofProjection(base), options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := []core.Checkpoint{}
+	if err := cursor.All(ctx, &checkpoints); err != nil {
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+func (s *MongoCheckpointStore) Delete(ctx context.Context, base string) error {
+	_, err := s.Collection.DeleteMany(ctx, ofProjection(base))
+	return err
+}
+
+// ofProjection = Checkpoint ของ Projection ทั้งแบบตัวเดียวและทุก Partition (ไม่โดน ID อื่นที่ขึ้นต้นเหมือนกัน)
+func ofProjection(base string) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"_id": base},
+		bson.M{"_id": bson.M{"$regex": "^" + regexp.QuoteMeta(base) + "#"}},
+	}}
+}
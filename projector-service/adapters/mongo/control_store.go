@@ -0,0 +1,47 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// MongoControlStore เก็บคำสั่ง Pause / Resume ใน projector_controls (1 เอกสาร / Projection)
+type MongoControlStore struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoControlStore(db *mongo.Database) ports.ControlStore {
+	return &MongoControlStore{Collection: db.Collection("projector_controls")}
+}
+
+func (s *MongoControlStore) List(ctx context.Context) (map[string]core.Control, error) {
+	cursor, err := s.Collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var controls []core.Control
+	if err := cursor.All(ctx, &controls); err != nil {
+		return nil, err
+	}
+	out := make(map[string]core.Control, len(controls))
+	for _, c := range controls {
+		out[c.Projection] = c
+	}
+	return out, nil
+}
+
+func (s *MongoControlStore) SetPaused(ctx context.Context, projection string, paused bool) error {
+	_, err := s.Collection.UpdateOne(ctx,
+		bson.M{"_id": projection},
+		bson.M{"$set": bson.M{"paused": paused, "updated_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
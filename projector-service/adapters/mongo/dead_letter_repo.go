@@ -0,0 +1,92 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+type MongoDeadLetterStore struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoDeadLetterStore(db *mongo.Database) ports.DeadLetterStore {
+	return &MongoDeadLetterStore{Collection: db.Collection("projection_dead_letters")}
+}
+
+func (s *MongoDeadLetterStore) Add(ctx context.Context, letter core.DeadLetter) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"projection": letter.Projection,
+			"source":     letter.Source,
+			"token":      letter.Token,
+			"change":     letter.Change,
+			"error":      letter.Error,
+			"status":     core.DeadLetterOpen,
+			"updated_at": now,
+		},
+		"$inc":         bson.M{"attempts": letter.Attempts},
+		"$unset":       bson.M{"closed_at": ""},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	_, err := s.Collection.UpdateOne(ctx, bson.M{"_id": letter.ID}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *MongoDeadLetterStore) List(ctx context.Context, projection string) ([]core.DeadLetter, error) {
+	filter := bson.M{"status": core.DeadLetterOpen}
+	if projection != "" {
+		filter["projection"] = projection
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}) // เคสเก่าสุดขึ้นก่อน
+
+	cursor, err := s.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	items := []core.DeadLetter{}
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *MongoDeadLetterStore) Get(ctx context.Context, id string) (*core.DeadLetter, error) {
+	var item core.DeadLetter
+	err := s.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&item)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *MongoDeadLetterStore) RecordFailure(ctx context.Context, id string, reason string) error {
+	_, err := s.Collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{"error": reason, "updated_at": time.Now()},
+			"$inc": bson.M{"attempts": 1},
+		},
+	)
+	return err
+}
+
+func (s *MongoDeadLetterStore) Close(ctx context.Context, id string, status string) error {
+	now := time.Now()
+	_, err := s.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": core.DeadLetterOpen},
+		bson.M{"$set": bson.M{"status": status, "closed_at": now, "updated_at": now}},
+	)
+	return err
+}
@@ -0,0 +1,69 @@
+package mongo
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// MongoLeaseStore เก็บ Lease ใน projector_leases (1 เอกสารต่อ Lease, _id = ชื่อ)
+// เวลาทั้งหมดใช้ $$NOW ของ MongoDB: นาฬิกาของแต่ละ Instance เพี้ยนกันได้โดยไม่ทำให้ 2 ตัวถือ Lease เดียวกัน
+type MongoLeaseStore struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoLeaseStore(db *mongo.Database) ports.LeaseStore {
+	return &MongoLeaseStore{Collection: db.Collection("projector_leases")}
+}
+
+func (s *MongoLeaseStore) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"owner": owner},
+			bson.M{"$expr": bson.M{"$lte": bson.A{"$expires_at", "$$NOW"}}},
+		},
+	}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"owner":      bson.M{"$literal": owner},
+		"expires_at": bson.M{"$add": bson.A{"$$NOW", ttl.Milliseconds()}},
+		// acquired_at เปลี่ยนเฉพาะตอนเปลี่ยนมือ (ต่ออายุไม่นับ) ไว้ดูว่าถือมานานแค่ไหน
+		"acquired_at": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$owner", owner}}, "$acquired_at", "$$NOW"}},
+	}}}}
+
+	_, err := s.Collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil // มีเอกสารอยู่แล้วแต่ไม่ Match = ตัวอื่นถืออยู่และยังไม่หมดอายุ
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *MongoLeaseStore) Release(ctx context.Context, name, owner string) error {
+	_, err := s.Collection.DeleteOne(ctx, bson.M{"_id": name, "owner": owner})
+	return err
+}
+
+func (s *MongoLeaseStore) Live(ctx context.Context, prefix string) ([]core.Lease, error) {
+	cursor, err := s.Collection.Find(ctx, bson.M{
+		"_id":   bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)},
+		"$expr": bson.M{"$gt": bson.A{"$expires_at", "$$NOW"}},
+	}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	leases := []core.Lease{}
+	if err := cursor.All(ctx, &leases); err != nil {
+		return nil, err
+	}
+	return leases, nil
+}
@@ -0,0 +1,56 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// MongoStockViewStore อ่าน / เขียน products_view ตรงๆ โดยไม่ผ่าน Projection (ใช้ตรวจและซ่อม Drift)
+// ทุกการเขียนมีเงื่อนไข last_version: ถ้า Projector เขียนระหว่างนั้นจะไม่ทับของใหม่
+type MongoStockViewStore struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoStockViewStore(db *mongo.Database) ports.StockViewStore {
+	return &MongoStockViewStore{Collection: db.Collection("products_view")}
+}
+
+func (s *MongoStockViewStore) List(ctx context.Context) ([]core.StockState, error) {
+	cursor, err := s.Collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	views := []core.StockState{}
+	if err := cursor.All(ctx, &views); err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+func (s *MongoStockViewStore) Replace(ctx context.Context, state core.StockState, atVersion int) (bool, error) {
+	if atVersion == 0 {
+		_, err := s.Collection.InsertOne(ctx, state)
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil // Projector สร้างไปแล้ว
+		}
+		return err == nil, err
+	}
+	res, err := s.Collection.ReplaceOne(ctx, bson.M{"product_id": state.ProductID, "last_version": atVersion}, state)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+func (s *MongoStockViewStore) Delete(ctx context.Context, productID string, atVersion int) (bool, error) {
+	res, err := s.Collection.DeleteOne(ctx, bson.M{"product_id": productID, "last_version": atVersion})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount == 1, nil
+}
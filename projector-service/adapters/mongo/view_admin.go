@@ -0,0 +1,32 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"projector-service/ports"
+)
+
+type MongoViewAdmin struct {
+	DB *mongo.Database
+}
+
+func NewMongoViewAdmin(db *mongo.Database) ports.ViewAdmin {
+	return &MongoViewAdmin{DB: db}
+}
+
+func (a *MongoViewAdmin) Drop(ctx context.Context, collection string) error {
+	return a.DB.Collection(collection).Drop(ctx)
+}
+
+// Swap ใช้ renameCollection + dropTarget (Atomic ใน Replica Set, Index ของ from ตามไปด้วย)
+func (a *MongoViewAdmin) Swap(ctx context.Context, from, to string) error {
+	cmd := bson.D{
+		{Key: "renameCollection", Value: a.DB.Name() + "." + from},
+		{Key: "to", Value: a.DB.Name() + "." + to},
+		{Key: "dropTarget", Value: true},
+	}
+	return a.DB.Client().Database("admin").RunCommand(ctx, cmd).Err()
+}
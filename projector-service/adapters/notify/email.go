@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// EmailNotifier เป็น Stub: สร้างอีเมลแล้วเขียนลง Log แทนการส่งจริง (ต่อ SMTP / บริการอีเมลภายหลังได้โดยไม่ต้องแก้ Projection)
+type EmailNotifier struct {
+	To []string
+}
+
+func NewEmailNotifier(to []string) ports.Notifier {
+	return &EmailNotifier{To: to}
+}
+
+func (e *EmailNotifier) Name() string { return "email" }
+
+func (e *EmailNotifier) Notify(_ context.Context, alert core.StockAlert) error {
+	subject, body := emailMessage(alert)
+	log.Printf("📧 [stub] To: %v | Subject: %s | %s", e.To, subject, body)
+	return nil
+}
+
+func emailMessage(alert core.StockAlert) (subject, body string) {
+	if alert.Type == core.AlertStockDepleted {
+		subject = fmt.Sprintf("Out of stock: %s", alert.ProductID)
+	} else {
+		subject = fmt.Sprintf("Low stock: %s", alert.ProductID)
+	}
+	body = fmt.Sprintf("%s has %d available (reorder point %d) as of %s.",
+		alert.ProductID, alert.Available, alert.ReorderPoint, alert.EventAt.UTC().Format("2006-01-02 15:04 MST"))
+	return subject, body
+}
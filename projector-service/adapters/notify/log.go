@@ -0,0 +1,27 @@
+// Package notify คือ Sink ของ Alert สต็อก (เลือกได้หลายตัวผ่าน STOCK_ALERT_SINKS)
+package notify
+
+import (
+	"context"
+	"log"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// LogNotifier เขียน Alert ลง Log ของ Projector (Default: ไม่ต้องตั้งค่าอะไร)
+type LogNotifier struct{}
+
+func NewLogNotifier() ports.Notifier { return LogNotifier{} }
+
+func (LogNotifier) Name() string { return "log" }
+
+func (LogNotifier) Notify(_ context.Context, alert core.StockAlert) error {
+	icon := "📉"
+	if alert.Type == core.AlertStockDepleted {
+		icon = "🚨"
+	}
+	log.Printf("%s %s: %s available=%d reorder_point=%d (v.%d)",
+		icon, alert.Type, alert.ProductID, alert.Available, alert.ReorderPoint, alert.Version)
+	return nil
+}
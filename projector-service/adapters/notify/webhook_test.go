@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"projector-service/core"
+)
+
+func TestWebhookPostsAlertWithIdempotencyKey(t *testing.T) {
+	var got core.StockAlert
+	var key string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("Idempotency-Key")
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	alert := core.StockAlert{ID: "iphone-15|StockDepleted|v.4", Type: core.AlertStockDepleted, ProductID: "iphone-15", Version: 4}
+	if err := NewWebhookNotifier(srv.URL).Notify(context.Background(), alert); err != nil {
+		t.Fatal(err)
+	}
+	if key != alert.ID || got.Type != core.AlertStockDepleted || got.ProductID != "iphone-15" {
+		t.Fatalf("received %+v with key %q", got, key)
+	}
+}
+
+func TestWebhookFailsOnErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	if err := NewWebhookNotifier(srv.URL).Notify(context.Background(), core.StockAlert{ID: "x"}); err == nil {
+		t.Fatal("expected error for 503")
+	}
+}
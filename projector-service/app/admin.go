@@ -0,0 +1,313 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+var (
+	ErrUnknownProjection = errors.New("projection not found")
+	// ErrProjectionBusy: สั่ง Pause แล้วแต่ยังมี Instance ถือ Lease อยู่จนหมดเวลารอ (ไม่แตะ Checkpoint)
+	ErrProjectionBusy = errors.New("projection is still running on some instance")
+	// ErrAdminInProgress: มี Reset / Skip ของ Projection เดียวกันกำลังทำอยู่ (Instance ไหนก็ได้)
+	ErrAdminInProgress = errors.New("another reset or skip-to-now is in progress")
+	// ErrSkipUnsupported: Projection เติม Gap จาก Event Store เอง Event ที่ข้ามไปจะกลับมาตอน Event ถัดไปของ Stream นั้น
+	ErrSkipUnsupported = errors.New("skip-to-now has no effect on a projection that fills version gaps")
+)
+
+// ProjectorAdmin คือคำสั่งของ Operator ที่ทำได้ขณะ Projector รันอยู่ (ทุก Instance เห็นผลเหมือนกัน)
+//
+// Pause / Resume เขียนลง ControlStore แล้วแต่ละ Coordinator หยุด / รับงานเองในรอบถัดไป
+// Reset / Skip เขียน Checkpoint ใหม่ ซึ่งต้องไม่มีใครรันงานนั้นอยู่ (ไม่งั้นจะบันทึก Checkpoint เก่าทับ)
+// จึง Pause ก่อน รอจนทุก Instance คืน Lease แล้วค่อยเขียน จากนั้นคืนสถานะเดิม (ถ้าเดิม Pause อยู่ก็ยัง Pause)
+// ทั้งหมดทำภายใต้ Lease admin/<projection> ใน LeaseStore: คำสั่งที่สองจาก Instance ไหนก็ตามถูกปฏิเสธ
+// (ไม่งั้นคำสั่งแรกจะ Resume ระหว่างที่คำสั่งที่สองกำลังเขียน Checkpoint)
+type ProjectorAdmin struct {
+	Registry    *Registry
+	Leases      ports.LeaseStore
+	Controls    ports.ControlStore
+	DeadLetters ports.DeadLetterStore
+	Partitions  int
+	Settle      time.Duration // รอให้ Coordinator ทุกตัวเห็น Pause (= Interval: รอบที่อ่าน Control ไปก่อนหน้าจบแล้ว)
+	Wait        time.Duration // รอให้คืน Lease นานสุด
+	Poll        time.Duration
+	Owner       string // ID ของ Instance นี้ (เจ้าของ Lease ของคำสั่ง)
+
+	seq atomic.Uint64 // แยกเจ้าของ Lease ของแต่ละคำสั่งใน Instance เดียวกัน (Acquire ซ้ำด้วย owner เดิม = ต่ออายุ)
+}
+
+// NewProjectorAdmin ใช้ค่าเดียวกับ Coordinator ของ Instance นี้
+func NewProjectorAdmin(c *Coordinator, controls ports.ControlStore, deadLetters ports.DeadLetterStore) *ProjectorAdmin {
+	return &ProjectorAdmin{
+		Registry:    c.Registry,
+		Leases:      c.Leases,
+		Controls:    controls,
+		DeadLetters: deadLetters,
+		Partitions:  c.Partitions,
+		Settle:      c.Interval,
+		Wait:        c.TTL + 2*c.Interval,
+		Poll:        200 * time.Millisecond,
+		Owner:       c.Owner,
+	}
+}
+
+// Status คืนสถานะทุก Projection ตามลำดับที่ Register
+func (a *ProjectorAdmin) Status(ctx context.Context) ([]core.ProjectionStatus, error) {
+	controls, err := a.Controls.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read controls: %w", err)
+	}
+	leases, err := a.Leases.Live(ctx, leaseWorkPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("read leases: %w", err)
+	}
+
+	statuses := make([]core.ProjectionStatus, 0, len(a.Registry.projections))
+	for _, p := range a.Registry.projections {
+		status, err := a.status(ctx, p, controls[p.Name()], leases)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name(), err)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// StatusOf คืนสถานะของ Projection เดียว
+func (a *ProjectorAdmin) StatusOf(ctx context.Context, name string) (*core.ProjectionStatus, error) {
+	statuses, err := a.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range statuses {
+		if s.Name == name {
+			return &s, nil
+		}
+	}
+	return nil, ErrUnknownProjection
+}
+
+func (a *ProjectorAdmin) status(ctx context.Context, p ports.Projection, control core.Control, leases []core.Lease) (core.ProjectionStatus, error) {
+	status := core.ProjectionStatus{Name: p.Name(), Sources: p.Sources(), State: core.ProjectionRunning}
+	if control.Paused {
+		status.State = core.ProjectionPaused
+	}
+
+	checkpoints, err := a.Registry.Checkpoints.List(ctx, p.CheckpointID())
+	if err != nil {
+		return status, fmt.Errorf("read checkpoints: %w", err)
+	}
+	saved := map[string]core.Checkpoint{}
+	for _, cp := range checkpoints {
+		saved[cp.ID] = cp
+	}
+	owners := map[string]string{}
+	for _, l := range workLeases(leases, p.CheckpointID()) {
+		owners[l.Name] = l.Owner
+	}
+
+	// เฉพาะ Partition ตามค่าปัจจุบัน (Checkpoint ของจำนวน Partition เดิมไม่ได้ใช้แล้ว)
+	var slowest time.Time
+	known := true
+	for i := 0; i < max(a.Partitions, 1); i++ {
+		id := core.Partition{Index: i, Count: max(a.Partitions, 1)}.CheckpointID(p.CheckpointID())
+		part := core.PartitionStatus{Checkpoint: id, Owner: owners[leaseWorkPrefix+id]}
+		cp, ok := saved[id]
+		if !ok && i == 0 && a.Partitions > 1 {
+			// ยังไม่เคยรันแบบแบ่ง Partition -> ทุก Partition จะเริ่มจาก Checkpoint ตอนรันตัวเดียว
+			cp, ok = saved[p.CheckpointID()]
+		}
+		if ok {
+			part.LastToken = cp.Token.String()
+			part.EventAt = timeOrNil(cp.EventAt)
+			part.UpdatedAt = timeOrNil(cp.UpdatedAt)
+		}
+		if cp.EventAt.IsZero() {
+			known = false
+		} else {
+			if slowest.IsZero() || cp.EventAt.Before(slowest) {
+				slowest = cp.EventAt
+			}
+			if status.LastEventAt == nil || cp.EventAt.After(*status.LastEventAt) {
+				status.LastEventAt = part.EventAt
+			}
+		}
+		status.Partitions = append(status.Partitions, part)
+	}
+
+	latest, err := a.Registry.Source.Latest(ctx, p.Sources(), p.Filter())
+	if err != nil {
+		return status, fmt.Errorf("read latest event: %w", err)
+	}
+	switch {
+	case latest.IsZero(): // ยังไม่มี Event เลย
+		lag := 0.0
+		status.LagSeconds = &lag
+	case known:
+		lag := max(latest.Sub(slowest), 0).Seconds()
+		status.LagSeconds = &lag
+	}
+
+	letters, err := a.DeadLetters.List(ctx, p.Name())
+	if err != nil {
+		return status, fmt.Errorf("read dead letters: %w", err)
+	}
+	status.Errors = len(letters)
+	return status, nil
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func (a *ProjectorAdmin) Pause(ctx context.Context, name string) error {
+	if a.Registry.Find(name) == nil {
+		return ErrUnknownProjection
+	}
+	if err := a.Controls.SetPaused(ctx, name, true); err != nil {
+		return err
+	}
+	log.Printf("⏸️ [%s] Pause requested.", name)
+	return nil
+}
+
+func (a *ProjectorAdmin) Resume(ctx context.Context, name string) error {
+	if a.Registry.Find(name) == nil {
+		return ErrUnknownProjection
+	}
+	if err := a.Controls.SetPaused(ctx, name, false); err != nil {
+		return err
+	}
+	log.Printf("▶️ [%s] Resume requested.", name)
+	return nil
+}
+
+// Reset ลบ Checkpoint ทุก Partition: รอบถัดไปอ่าน Event ทั้งหมดตั้งแต่ต้นลง View เดิม
+// (Handler Idempotent: Event ที่ View มีแล้วถูกข้าม ต้องการ View ใหม่ทั้งก้อนให้ใช้ rebuild)
+func (a *ProjectorAdmin) Reset(ctx context.Context, name string) error {
+	return a.whileStopped(ctx, name, func(p ports.Projection) error {
+		if err := a.Registry.Checkpoints.Delete(ctx, p.CheckpointID()); err != nil {
+			return fmt.Errorf("delete checkpoints: %w", err)
+		}
+		log.Printf("⏮️ [%s] Checkpoints reset, replaying from the beginning.", name)
+		return nil
+	})
+}
+
+// SkipToNow บันทึก Head ของ Source เป็น Checkpoint ทุก Partition: Event ที่ค้างอยู่ถูกข้ามทั้งหมด (View ไม่ได้ Apply)
+// Projection ที่เติม Gap เอง (ports.GapFilling) ถูกปฏิเสธก่อน Pause: ข้ามไปก็ถูกดึงกลับมา Apply อยู่ดี
+func (a *ProjectorAdmin) SkipToNow(ctx context.Context, name string) error {
+	if _, ok := a.Registry.Find(name).(ports.GapFilling); ok {
+		return fmt.Errorf("%w: %s (use reset, or rebuild to start over)", ErrSkipUnsupported, name)
+	}
+	return a.whileStopped(ctx, name, func(p ports.Projection) error {
+		latest, err := a.Registry.Source.Latest(ctx, p.Sources(), p.Filter())
+		if err != nil {
+			return fmt.Errorf("read latest event: %w", err)
+		}
+		head, err := a.Registry.Source.Head(ctx, p.Sources())
+		if err != nil {
+			return fmt.Errorf("read head: %w", err)
+		}
+		if err := a.Registry.Checkpoints.Delete(ctx, p.CheckpointID()); err != nil {
+			return fmt.Errorf("delete checkpoints: %w", err)
+		}
+		for i := 0; i < max(a.Partitions, 1); i++ {
+			id := core.Partition{Index: i, Count: max(a.Partitions, 1)}.CheckpointID(p.CheckpointID())
+			if err := a.Registry.Checkpoints.Save(ctx, id, head, latest); err != nil {
+				return fmt.Errorf("save checkpoint: %w", err)
+			}
+		}
+		log.Printf("⏭️ [%s] Skipped to the current head of %s.", name, sourceNames(p))
+		return nil
+	})
+}
+
+// whileStopped ถือ Lease ของคำสั่ง -> Pause -> รอทุก Instance คืน Lease -> change -> คืนสถานะ Pause เดิม -> คืน Lease ของคำสั่ง
+func (a *ProjectorAdmin) whileStopped(ctx context.Context, name string, change func(ports.Projection) error) error {
+	p := a.Registry.Find(name)
+	if p == nil {
+		return ErrUnknownProjection
+	}
+
+	// อายุครอบเวลารอทั้งหมดของ waitStopped บวกเวลาเขียน Checkpoint: Instance ที่ตายกลางคำสั่งไม่ล็อกค้างตลอดไป
+	lock, owner := leaseAdminPrefix+name, fmt.Sprintf("%s#%d", a.Owner, a.seq.Add(1))
+	ok, err := a.Leases.Acquire(ctx, lock, owner, a.Settle+a.Wait+time.Minute)
+	if err != nil {
+		return fmt.Errorf("acquire admin lease: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrAdminInProgress, name)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := a.Leases.Release(ctx, lock, owner); err != nil {
+			log.Printf("⚠️ [%s] Failed to release admin lease (expires on its own): %v", name, err)
+		}
+	}()
+
+	controls, err := a.Controls.List(ctx)
+	if err != nil {
+		return fmt.Errorf("read controls: %w", err)
+	}
+	if !controls[name].Paused {
+		if err := a.Controls.SetPaused(ctx, name, true); err != nil {
+			return err
+		}
+		defer func() {
+			// ctx ของคำขออาจถูกยกเลิกไปแล้ว แต่ต้อง Resume ให้ได้ ไม่งั้น Projection ค้าง Pause
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := a.Controls.SetPaused(ctx, name, false); err != nil {
+				log.Printf("⚠️ [%s] Failed to resume after admin command: %v", name, err)
+			}
+		}()
+	}
+
+	if err := a.waitStopped(ctx, p); err != nil {
+		return err
+	}
+	return change(p)
+}
+
+// waitStopped รอ 1 รอบของ Coordinator (รอบที่อ่าน Control ก่อน Pause อาจยังแย่ง Lease ได้) แล้วรอจนไม่มี Lease เหลือ
+// Instance ที่หยุดงานจะคืน Lease หลังบันทึก Checkpoint สุดท้ายแล้วเสมอ
+func (a *ProjectorAdmin) waitStopped(ctx context.Context, p ports.Projection) error {
+	ctx, cancel := context.WithTimeout(ctx, a.Settle+a.Wait)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(a.Settle):
+	}
+
+	for {
+		leases, err := a.Leases.Live(ctx, leaseWorkPrefix)
+		if err != nil {
+			return fmt.Errorf("read leases: %w", err)
+		}
+		held := workLeases(leases, p.CheckpointID())
+		if len(held) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("%w: %s held by %s", ErrProjectionBusy, held[0].Name, held[0].Owner)
+			}
+			return ctx.Err()
+		case <-time.After(a.Poll):
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"projector-service/core"
+)
+
+// fakeControls คือ projector_controls ใน RAM
+type fakeControls struct {
+	mu       sync.Mutex
+	controls map[string]core.Control
+}
+
+func (c *fakeControls) List(ctx context.Context) (map[string]core.Control, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := map[string]core.Control{}
+	for name, control := range c.controls {
+		out[name] = control
+	}
+	return out, nil
+}
+
+func (c *fakeControls) SetPaused(ctx context.Context, projection string, paused bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.controls == nil {
+		c.controls = map[string]core.Control{}
+	}
+	c.controls[projection] = core.Control{Projection: projection, Paused: paused, UpdatedAt: time.Now()}
+	return nil
+}
+
+func (c *fakeControls) paused(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.controls[name].Paused
+}
+
+func newTestAdmin(c *Coordinator, controls *fakeControls, deadLetters *fakeDeadLetters) *ProjectorAdmin {
+	admin := NewProjectorAdmin(c, controls, deadLetters)
+	admin.Settle = 20 * time.Millisecond
+	admin.Wait = 200 * time.Millisecond
+	admin.Poll = 5 * time.Millisecond
+	return admin
+}
+
+func TestPausedProjectionStopsOnEveryInstanceUntilResumed(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"stream_id": "iphone-15", "type": "StockAdded"})
+	checkpoints := &fakeCheckpoints{}
+	leases := &fakeLeases{}
+	controls := &fakeControls{}
+	c, seen := newTestCoordinator(source, checkpoints, leases, "a", 2)
+	c.Controls = controls
+	admin := newTestAdmin(c, controls, &fakeDeadLetters{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Run(ctx) }()
+	waitFor(t, "first event", func() bool { return len(seen.streams()) == 1 })
+
+	if err := admin.Pause(ctx, "products_view"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "leases released", func() bool { return leases.owners()["a"] == 0 })
+	time.Sleep(5 * c.Interval)
+	if n := leases.owners()["a"]; n != 0 {
+		t.Fatalf("paused projection re-acquired %d lease(s)", n)
+	}
+
+	source.add("events", bson.M{"stream_id": "ipad-air", "type": "StockAdded"})
+	if err := admin.Resume(ctx, "products_view"); err != nil {
+		t.Fatal(err)
+	}
+	// รันต่อจาก Checkpoint เดิม: ได้ Event ใหม่ ไม่ได้ Event แรกซ้ำ
+	waitFor(t, "event after resume", func() bool { return len(seen.streams()) == 2 })
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if streams := seen.streams(); streams[1] != "ipad-air" {
+		t.Fatalf("streams = %v", streams)
+	}
+}
+
+func TestResetAndSkipRewriteEveryPartitionCheckpoint(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"stream_id": "iphone-15", "type": "StockAdded"})
+	source.add("events", bson.M{"stream_id": "ipad-air", "type": "StockAdded"})
+	checkpoints := &fakeCheckpoints{}
+	checkpoints.Save(context.Background(), "main_projector", source.token(0), time.Time{}) // ตอนยังรันตัวเดียว
+	checkpoints.Save(context.Background(), "main_projector#0/2", source.token(0), time.Time{})
+	checkpoints.Save(context.Background(), "main_projector_v2", source.token(0), time.Time{}) // Projection อื่น ห้ามโดน
+	controls := &fakeControls{}
+	c, _ := newTestCoordinator(source, checkpoints, &fakeLeases{}, "a", 2)
+	admin := newTestAdmin(c, controls, &fakeDeadLetters{})
+	ctx := context.Background()
+
+	if err := admin.SkipToNow(ctx, "products_view"); err != nil {
+		t.Fatal(err)
+	}
+	head := source.token(1).String()
+	if len(checkpoints.tokens) != 3 ||
+		checkpoints.tokens["main_projector#0/2"].String() != head ||
+		checkpoints.tokens["main_projector#1/2"].String() != head {
+		t.Fatalf("checkpoints after skip: %v", checkpoints.tokens)
+	}
+	if controls.paused("products_view") {
+		t.Fatal("skip must resume a projection that was running")
+	}
+
+	// Pause อยู่ก่อน -> Reset แล้วต้องยัง Pause
+	admin.Pause(ctx, "products_view")
+	if err := admin.Reset(ctx, "products_view"); err != nil {
+		t.Fatal(err)
+	}
+	if len(checkpoints.tokens) != 1 || checkpoints.tokens["main_projector_v2"] == nil {
+		t.Fatalf("checkpoints after reset: %v", checkpoints.tokens)
+	}
+	if !controls.paused("products_view") {
+		t.Fatal("reset must keep a paused projection paused")
+	}
+}
+
+// gapFillingProjection เติม Gap เองเหมือน products_view / stock_alerts
+type gapFillingProjection struct {
+	versionedProjection
+}
+
+func (p *gapFillingProjection) FillsGaps() {}
+
+func TestSkipRefusesGapFillingProjection(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"stream_id": "iphone-15", "type": "StockAdded"})
+	checkpoints := &fakeCheckpoints{}
+	checkpoints.Save(context.Background(), "stock_alerts", source.token(0), time.Time{})
+	registry := NewRegistry(source, checkpoints, &fakeDeadLetters{})
+	registry.Register(&gapFillingProjection{versionedProjection{recordingProjection: recordingProjection{name: "stock_alerts", checkpoint: "stock_alerts", sources: []string{"events"}}}})
+	controls := &fakeControls{}
+	admin := newTestAdmin(NewCoordinator(registry, &fakeLeases{}, "a", 1), controls, &fakeDeadLetters{})
+
+	err := admin.SkipToNow(context.Background(), "stock_alerts")
+	if !errors.Is(err, ErrSkipUnsupported) {
+		t.Fatalf("err = %v, want ErrSkipUnsupported", err)
+	}
+	if len(checkpoints.tokens) != 1 || checkpoints.tokens["stock_alerts"].String() != source.token(0).String() {
+		t.Fatalf("checkpoints must be left unchanged: %v", checkpoints.tokens)
+	}
+	if _, touched := controls.controls["stock_alerts"]; touched {
+		t.Fatal("skip must be refused before pausing the projection")
+	}
+}
+
+func TestResetRefusesWhileAnInstanceStillHoldsTheLease(t *testing.T) {
+	source := &fakeSource{}
+	checkpoints := &fakeCheckpoints{}
+	checkpoints.Save(context.Background(), "main_projector", source.token(0), time.Time{})
+	// Instance ที่ไม่สนใจ Pause (เช่นรุ่นเก่า) ยังถือ Lease อยู่
+	leases := &fakeLeases{leases: map[string]core.Lease{
+		leaseWorkPrefix + "main_projector": {Owner: "old", ExpiresAt: time.Now().Add(time.Minute)},
+	}}
+	controls := &fakeControls{}
+	c, _ := newTestCoordinator(source, checkpoints, leases, "a", 1)
+	admin := newTestAdmin(c, controls, &fakeDeadLetters{})
+
+	err := admin.Reset(context.Background(), "products_view")
+	if !errors.Is(err, ErrProjectionBusy) {
+		t.Fatalf("err = %v, want ErrProjectionBusy", err)
+	}
+	if checkpoints.tokens["main_projector"] == nil || controls.paused("products_view") {
+		t.Fatalf("busy reset touched state: checkpoints=%v paused=%v", checkpoints.tokens, controls.paused("products_view"))
+	}
+}
+
+func TestResetRefusesWhileAnotherInstanceRunsAnAdminCommand(t *testing.T) {
+	source := &fakeSource{}
+	checkpoints := &fakeCheckpoints{}
+	leases := &fakeLeases{}
+	controls := &fakeControls{}
+	a, _ := newTestCoordinator(source, checkpoints, leases, "a", 1)
+	b, _ := newTestCoordinator(source, checkpoints, leases, "b", 1)
+	first := newTestAdmin(a, controls, &fakeDeadLetters{})
+	first.Settle = 300 * time.Millisecond // ค้างอยู่ระหว่างรอให้ทุก Instance หยุด
+	second := newTestAdmin(b, controls, &fakeDeadLetters{})
+	ctx := context.Background()
+
+	done := make(chan error, 1)
+	go func() { done <- first.Reset(ctx, "products_view") }()
+	deadline := time.Now().Add(time.Second)
+	for !controls.paused("products_view") {
+		if time.Now().After(deadline) {
+			t.Fatal("first reset never paused the projection")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// ถ้าปล่อยให้ทำ คำสั่งแรกจะ Resume ระหว่างที่คำสั่งนี้ยังเขียน Checkpoint อยู่
+	if err := second.Reset(ctx, "products_view"); !errors.Is(err, ErrAdminInProgress) {
+		t.Fatalf("err = %v, want ErrAdminInProgress", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if controls.paused("products_view") {
+		t.Fatal("first reset must resume the projection")
+	}
+	if held, _ := leases.Live(ctx, leaseAdminPrefix); len(held) != 0 {
+		t.Fatalf("admin lease not released: %v", held)
+	}
+	if err := second.Reset(ctx, "products_view"); err != nil {
+		t.Fatalf("reset after the first finished: %v", err)
+	}
+}
+
+func TestStatusReportsLagFromSlowestPartition(t *testing.T) {
+	at := func(sec int) time.Time { return time.Date(2026, 10, 1, 0, 0, sec, 0, time.UTC) }
+	source := &fakeSource{}
+	source.add("events", bson.M{"stream_id": "iphone-15", "type": "StockAdded", "timestamp": at(0)})
+	source.add("events", bson.M{"stream_id": "ipad-air", "type": "StockAdded", "timestamp": at(30)})
+	checkpoints := &fakeCheckpoints{}
+	checkpoints.Save(context.Background(), "main_projector#0/2", source.token(1), at(30))
+	checkpoints.Save(context.Background(), "main_projector#1/2", source.token(0), at(0))
+	leases := &fakeLeases{leases: map[string]core.Lease{
+		leaseWorkPrefix + "main_projector#1/2": {Owner: "b", ExpiresAt: time.Now().Add(time.Minute)},
+	}}
+	deadLetters := &fakeDeadLetters{}
+	deadLetters.Add(context.Background(), core.DeadLetter{ID: "x", Projection: "products_view"})
+	c, _ := newTestCoordinator(source, checkpoints, leases, "a", 2)
+	admin := newTestAdmin(c, &fakeControls{}, deadLetters)
+
+	status, err := admin.StatusOf(context.Background(), "products_view")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != core.ProjectionRunning || status.Errors != 1 || status.LagSeconds == nil || *status.LagSeconds != 30 {
+		t.Fatalf("status = %+v", status)
+	}
+	if !status.LastEventAt.Equal(at(30)) || status.Partitions[1].Owner != "b" || status.Partitions[0].Owner != "" {
+		t.Fatalf("partitions = %+v", status.Partitions)
+	}
+
+	// Partition ที่ไม่รู้เวลา (เช่นหลัง Rebuild) -> ไม่เดา Lag
+	checkpoints.Save(context.Background(), "main_projector#1/2", source.token(1), time.Time{})
+	if status, _ = admin.StatusOf(context.Background(), "products_view"); status.LagSeconds != nil {
+		t.Fatalf("lag = %v, want unknown", *status.LagSeconds)
+	}
+}
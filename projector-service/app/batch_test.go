@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// batchingProjection รับทั้งกลุ่มผ่าน HandleBatch (failBatch = ไม่ผ่านทุกกลุ่ม)
+type batchingProjection struct {
+	recordingProjection
+	failBatch bool
+	batches   []int
+}
+
+func (p *batchingProjection) HandleBatch(ctx context.Context, events []core.Envelope) error {
+	if p.failBatch {
+		return errors.New("conflict")
+	}
+	p.batches = append(p.batches, len(events))
+	for _, e := range events {
+		p.seen = append(p.seen, e.Type)
+	}
+	return nil
+}
+
+func newBatchSource(n int) *fakeSource {
+	source := &fakeSource{}
+	for i := 1; i <= n; i++ {
+		source.add("events", bson.M{"stream_id": "iphone-15", "type": fmt.Sprintf("E%d", i), "version": int32(i)})
+	}
+	return source
+}
+
+func TestRegistryBatchesEventsAndCheckpointsOncePerBatch(t *testing.T) {
+	source := newBatchSource(5)
+	checkpoints := &fakeCheckpoints{}
+	p := &batchingProjection{recordingProjection: recordingProjection{name: "products_view", checkpoint: "main_projector", sources: []string{"events"}}}
+	registry := NewRegistry(source, checkpoints, &fakeDeadLetters{})
+	registry.Batch.Size = 2
+	registry.Register(p)
+
+	if err := registry.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(p.batches) != "[2 2]" || len(p.seen) != 5 {
+		// กลุ่มสุดท้ายมีตัวเดียว -> ส่งผ่าน Handle ตามปกติ
+		t.Fatalf("batches = %v, seen = %v", p.batches, p.seen)
+	}
+	if checkpoints.saves != 3 {
+		t.Fatalf("checkpoint saves = %d, want 3 (one per batch)", checkpoints.saves)
+	}
+	if got := checkpoints.tokens["main_projector"].Lookup("i").Int32(); got != 4 {
+		t.Fatalf("checkpoint = %d, want 4", got)
+	}
+}
+
+func TestRegistryFallsBackToSingleEventsWhenBatchFails(t *testing.T) {
+	source := newBatchSource(3)
+	checkpoints := &fakeCheckpoints{}
+	p := &batchingProjection{recordingProjection: recordingProjection{name: "products_view", checkpoint: "main_projector", sources: []string{"events"}}, failBatch: true}
+	registry := NewRegistry(source, checkpoints, &fakeDeadLetters{})
+	registry.Batch.Size = 10
+	registry.Register(p)
+
+	if err := registry.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(p.seen) != "[E1 E2 E3]" {
+		t.Fatalf("seen = %v, want every event through Handle", p.seen)
+	}
+	if checkpoints.saves != 1 {
+		t.Fatalf("checkpoint saves = %d, want 1", checkpoints.saves)
+	}
+}
+
+func TestRecoverAppliesHistoryInBatches(t *testing.T) {
+	source := newBatchSource(5)
+	source.watchErrs = []error{ports.ErrHistoryLost}
+	checkpoints := &fakeCheckpoints{}
+	p := &batchingProjection{recordingProjection: recordingProjection{name: "products_view", checkpoint: "main_projector", sources: []string{"events"}}}
+	registry := NewRegistry(source, checkpoints, &fakeDeadLetters{})
+	registry.Batch.Size = 3
+	registry.Register(p)
+
+	if err := registry.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// History 5 ตัว = กลุ่มละ 3 + 2 แล้วเปิด Stream ใหม่จาก Head (ไม่มีอะไรค้าง)
+	if fmt.Sprint(p.batches) != "[3 2]" {
+		t.Fatalf("batches = %v, want [3 2]", p.batches)
+	}
+}
@@ -0,0 +1,296 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// ชื่อ Lease ใน LeaseStore
+const (
+	leaseWorkPrefix     = "projection/" // projection/<checkpoint id>: สิทธิ์รัน Partition นั้น
+	leaseInstancePrefix = "instance/"   // instance/<owner>: Heartbeat ไว้นับว่ามีกี่ Instance ที่ยังอยู่
+	leaseAdminPrefix    = "admin/"      // admin/<projection>: Reset / Skip ที่กำลังเขียน Checkpoint (ทีละคำสั่งทุก Instance)
+)
+
+// ErrLeaseExpired: Instance นี้ต่ออายุ Lease ไม่ทันแล้ว ต้องหยุดงานนั้นก่อนตัวอื่นรับต่อ
+var ErrLeaseExpired = errors.New("lease expired")
+
+// Coordinator ให้ Projector รันได้หลาย Instance พร้อมกัน
+//
+// งานแบ่งเป็น Projection x Partition (Hash ของ stream_id) แต่ละชิ้นมี Checkpoint + Lease ของตัวเอง
+// ทุก Instance วนรอบละ Interval: ต่ออายุ Lease ที่ถืออยู่ แล้วแย่งงานที่ว่างจนได้ส่วนแบ่งเท่าๆ กัน
+// (จำนวนงาน / จำนวน Instance ที่ยังส่ง Heartbeat) ถือเกินส่วนแบ่งก็คืนให้ตัวที่เพิ่งเข้ามา
+//
+// ส่งมือต่อโดยไม่ข้ามและไม่ทำซ้ำ:
+//   - เจ้าของใหม่อ่านต่อจาก Checkpoint ที่เจ้าของเดิมบันทึกไว้ -> ไม่ข้าม Event
+//   - เจ้าของเดิมหยุด Handle / บันทึก Checkpoint เองเมื่อถึงเวลาที่ Lease อาจหมด (นับจากก่อนส่งคำขอต่ออายุ)
+//     ซึ่งมาก่อนเวลาที่ Store ยอมให้ตัวอื่นเอาไป -> ไม่มีช่วงที่ 2 ตัวทำงานเดียวกัน
+//   - Event ที่ Handle แล้วแต่ยังไม่ทันบันทึก Checkpoint (เช่นดับกลางทาง) จะถูกส่งซ้ำ: Handler ต้อง Idempotent อยู่แล้ว
+//
+// Projection ที่ Operator สั่ง Pause (Controls) จะถูกหยุดและคืน Lease ในรอบถัดไปของทุก Instance
+type Coordinator struct {
+	Registry   *Registry
+	Leases     ports.LeaseStore
+	Owner      string             // ID ของ Instance นี้ (ต้องไม่ซ้ำกัน)
+	Partitions int                // ต้องเท่ากันทุก Instance
+	TTL        time.Duration      // อายุ Lease: Instance ดับแล้วงานจะค้างนานสุดเท่านี้
+	Interval   time.Duration      // รอบต่ออายุ / แย่งงาน (ต้องสั้นกว่า TTL หลายเท่า)
+	Controls   ports.ControlStore // nil = ไม่มีการ Pause
+}
+
+func NewCoordinator(registry *Registry, leases ports.LeaseStore, owner string, partitions int) *Coordinator {
+	return &Coordinator{
+		Registry:   registry,
+		Leases:     leases,
+		Owner:      owner,
+		Partitions: partitions,
+		TTL:        15 * time.Second,
+		Interval:   5 * time.Second,
+	}
+}
+
+// held คืองานที่ Instance นี้ถือ Lease และกำลังรันอยู่
+type held struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu         sync.Mutex
+	validUntil time.Time
+}
+
+func (h *held) extend(until time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.validUntil = until
+}
+
+func (h *held) guard() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !time.Now().Before(h.validUntil) {
+		return ErrLeaseExpired
+	}
+	return nil
+}
+
+type workerResult struct {
+	lease string
+	h     *held
+	err   error
+}
+
+// Run แย่ง / ต่ออายุ Lease แล้วรันงานที่ได้ จนกว่า ctx จะถูกยกเลิก (คืน Lease ทั้งหมดก่อนจบ)
+// งานไหนพังด้วย Error อื่นที่ไม่ใช่ Lease หลุด จะหยุดทั้ง Instance แล้วคืน Error นั้น (เหมือน Registry.Run)
+func (c *Coordinator) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := c.workers()
+	running := map[string]*held{}
+	results := make(chan workerResult, len(workers))
+	defer c.stopAll(running)
+
+	log.Printf("🧭 Coordinator %s: %d projection(s) x %d partition(s)", c.Owner, len(c.Registry.projections), max(c.Partitions, 1))
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	for {
+		c.tick(ctx, workers, running, results)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case res := <-results:
+			if err := c.finished(running, res); err != nil {
+				return err
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Coordinator) workers() map[string]worker {
+	workers := map[string]worker{}
+	for _, p := range c.Registry.projections {
+		for i := 0; i < max(c.Partitions, 1); i++ {
+			w := worker{p: p, part: core.Partition{Index: i, Count: max(c.Partitions, 1)}}
+			workers[leaseWorkPrefix+w.checkpointID()] = w
+		}
+	}
+	return workers
+}
+
+func (c *Coordinator) tick(ctx context.Context, workers map[string]worker, running map[string]*held, results chan workerResult) {
+	// 1. Heartbeat ของ Instance นี้
+	if _, err := c.Leases.Acquire(ctx, leaseInstancePrefix+c.Owner, c.Owner, c.TTL); err != nil && ctx.Err() == nil {
+		log.Printf("⚠️ Heartbeat failed: %v", err)
+	}
+
+	// 2. ต่ออายุงานที่ถืออยู่ (ไม่ได้ = ตัวอื่นเอาไปแล้ว -> หยุดทันที)
+	for name, h := range running {
+		start := time.Now()
+		ok, err := c.Leases.Acquire(ctx, name, c.Owner, c.TTL)
+		switch {
+		case err != nil:
+			log.Printf("⚠️ [%s] Failed to renew lease: %v", name, err) // ถ้าต่อไม่ได้จนเลยเวลา guard จะหยุดงานเอง
+		case ok:
+			h.extend(start.Add(c.TTL))
+		default:
+			log.Printf("🔒 [%s] Lease taken by another instance, stopping.", name)
+			c.stop(name, running)
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	// 3. Projection ที่ถูก Pause: หยุดงานที่ถืออยู่แล้วไม่แย่งเพิ่ม
+	// (อ่านไม่ได้ = ไม่รู้ว่าถูก Pause ไหม -> ทำงานเดิมต่อแต่ไม่รับงานใหม่)
+	paused, err := c.paused(ctx)
+	if err != nil {
+		log.Printf("⚠️ Failed to read projector controls: %v", err)
+		return
+	}
+	names := make([]string, 0, len(workers))
+	for name, w := range workers {
+		if !paused[w.p.Name()] {
+			names = append(names, name)
+		} else if _, ok := running[name]; ok {
+			log.Printf("⏸️ [%s] Paused by operator, stopping.", w.name())
+			c.stop(name, running)
+		}
+	}
+	sort.Strings(names)
+
+	// 4. ส่วนแบ่งของ Instance นี้
+	instances, err := c.Leases.Live(ctx, leaseInstancePrefix)
+	if err != nil {
+		log.Printf("⚠️ Failed to list instances: %v", err)
+		return
+	}
+	share := (len(names) + max(len(instances), 1) - 1) / max(len(instances), 1)
+
+	// 5. ถือเกินส่วนแบ่ง (มี Instance ใหม่เข้ามา) -> คืนตัวท้ายๆ ให้ตัวอื่นรับไป
+	for i := len(names) - 1; i >= 0 && len(running) > share; i-- {
+		if _, ok := running[names[i]]; ok {
+			log.Printf("↪️ [%s] Handing over to rebalance (%d instance(s)).", names[i], len(instances))
+			c.stop(names[i], running)
+		}
+	}
+
+	// 6. ยังไม่ครบส่วนแบ่ง -> แย่งงานที่ว่าง (รวมงานของ Instance ที่ดับไปจน Lease หมดอายุ)
+	for _, name := range names {
+		if len(running) >= share {
+			return
+		}
+		if _, ok := running[name]; ok {
+			continue
+		}
+		start := time.Now()
+		ok, err := c.Leases.Acquire(ctx, name, c.Owner, c.TTL)
+		if err != nil {
+			log.Printf("⚠️ [%s] Failed to acquire lease: %v", name, err)
+			continue
+		}
+		if ok {
+			c.start(ctx, name, workers[name], start.Add(c.TTL), running, results)
+		}
+	}
+}
+
+// paused คืนชื่อ Projection ที่ถูกสั่ง Pause
+func (c *Coordinator) paused(ctx context.Context) (map[string]bool, error) {
+	paused := map[string]bool{}
+	if c.Controls == nil {
+		return paused, nil
+	}
+	controls, err := c.Controls.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for name, control := range controls {
+		paused[name] = control.Paused
+	}
+	return paused, nil
+}
+
+// workLeases คืน Lease ของงานทุก Partition ของ Projection (checkpoint = CheckpointID ของมัน)
+func workLeases(leases []core.Lease, checkpoint string) []core.Lease {
+	var out []core.Lease
+	for _, l := range leases {
+		id := strings.TrimPrefix(l.Name, leaseWorkPrefix)
+		if id == checkpoint || strings.HasPrefix(id, checkpoint+"#") {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+func (c *Coordinator) start(ctx context.Context, name string, w worker, validUntil time.Time, running map[string]*held, results chan workerResult) {
+	ctx, cancel := context.WithCancel(ctx)
+	h := &held{cancel: cancel, done: make(chan struct{}), validUntil: validUntil}
+	w.guard = h.guard
+	running[name] = h
+
+	log.Printf("🔑 [%s] Lease acquired by %s.", w.name(), c.Owner)
+	go func() {
+		err := c.Registry.run(ctx, w)
+		if err != nil {
+			err = fmt.Errorf("projection %s: %w", w.name(), err)
+		}
+		close(h.done)
+		select {
+		case results <- workerResult{lease: name, h: h, err: err}:
+		case <-ctx.Done(): // ถูกสั่งหยุด (stop / ปิดตัว) ไม่มีใครรอผลแล้ว
+		}
+	}()
+}
+
+// stop หยุดงานแล้วรอให้จบ (Checkpoint ล่าสุดถูกบันทึกแล้ว) ก่อนคืน Lease ให้ตัวอื่น
+func (c *Coordinator) stop(name string, running map[string]*held) {
+	h := running[name]
+	h.cancel()
+	<-h.done // ถ้าผลของงานนี้ค้างอยู่ใน results, finished จะข้ามไป (ไม่อยู่ใน running แล้ว)
+	delete(running, name)
+	c.release(name)
+}
+
+// finished จัดการงานที่จบเอง: คืน Lease แล้วให้รอบถัดไปแย่งใหม่ ยกเว้น Error จริงที่ต้องหยุดทั้ง Instance
+func (c *Coordinator) finished(running map[string]*held, res workerResult) error {
+	if running[res.lease] != res.h {
+		return nil // ถูกสั่งหยุดไปแล้ว
+	}
+	delete(running, res.lease)
+	c.release(res.lease)
+	if res.err == nil || errors.Is(res.err, ErrLeaseExpired) || errors.Is(res.err, context.Canceled) {
+		if res.err != nil {
+			log.Printf("🔒 [%s] Stopped: %v", res.lease, res.err)
+		}
+		return nil
+	}
+	return res.err
+}
+
+func (c *Coordinator) stopAll(running map[string]*held) {
+	for name := range running {
+		c.stop(name, running)
+	}
+	c.release(leaseInstancePrefix + c.Owner)
+}
+
+// release ใช้ Context ใหม่: ตอนปิดตัว ctx หลักถูกยกเลิกไปแล้วแต่ยังต้องคืน Lease ให้ตัวอื่นรับต่อทันที
+func (c *Coordinator) release(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Leases.Release(ctx, name, c.Owner); err != nil {
+		log.Printf("⚠️ [%s] Failed to release lease: %v", name, err)
+	}
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"projector-service/core"
+)
+
+// fakeLeases คือ LeaseStore ใน RAM (ใช้นาฬิกาจริง)
+type fakeLeases struct {
+	mu     sync.Mutex
+	leases map[string]core.Lease
+}
+
+func (l *fakeLeases) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.leases == nil {
+		l.leases = map[string]core.Lease{}
+	}
+	if cur, ok := l.leases[name]; ok && cur.Owner != owner && time.Now().Before(cur.ExpiresAt) {
+		return false, nil
+	}
+	l.leases[name] = core.Lease{Name: name, Owner: owner, ExpiresAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (l *fakeLeases) Release(ctx context.Context, name, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.leases[name].Owner == owner {
+		delete(l.leases, name)
+	}
+	return nil
+}
+
+func (l *fakeLeases) Live(ctx context.Context, prefix string) ([]core.Lease, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	live := []core.Lease{}
+	for name, lease := range l.leases {
+		if strings.HasPrefix(name, prefix) && time.Now().Before(lease.ExpiresAt) {
+			lease.Name = name
+			live = append(live, lease)
+		}
+	}
+	return live, nil
+}
+
+// owners คืนจำนวนงานที่แต่ละ Instance ถืออยู่
+func (l *fakeLeases) owners() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	owners := map[string]int{}
+	for name, lease := range l.leases {
+		if strings.HasPrefix(name, leaseWorkPrefix) && time.Now().Before(lease.ExpiresAt) {
+			owners[lease.Owner]++
+		}
+	}
+	return owners
+}
+
+func (p *recordingProjection) streams() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var streams []string
+	for _, e := range p.envelopes {
+		streams = append(streams, e.StreamID)
+	}
+	return streams
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newTestCoordinator(source *fakeSource, checkpoints *fakeCheckpoints, leases *fakeLeases, owner string, partitions int) (*Coordinator, *recordingProjection) {
+	p := &recordingProjection{name: "products_view", checkpoint: "main_projector", sources: []string{"events"}}
+	registry := NewRegistry(source, checkpoints, &fakeDeadLetters{})
+	registry.Register(p)
+	c := NewCoordinator(registry, leases, owner, partitions)
+	c.TTL = time.Second
+	c.Interval = 10 * time.Millisecond
+	return c, p
+}
+
+func TestCoordinatorsSplitPartitionsWithoutDuplicates(t *testing.T) {
+	source := &fakeSource{}
+	for i := 0; i < 20; i++ {
+		source.add("events", bson.M{"stream_id": fmt.Sprintf("product-%d", i), "type": "StockAdded"})
+	}
+	checkpoints := &fakeCheckpoints{}
+	leases := &fakeLeases{}
+	a, seenA := newTestCoordinator(source, checkpoints, leases, "a", 4)
+	b, seenB := newTestCoordinator(source, checkpoints, leases, "b", 4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); a.Run(ctx) }()
+	waitFor(t, "a to own every partition", func() bool { return leases.owners()["a"] == 4 })
+	go func() { defer wg.Done(); b.Run(ctx) }()
+
+	// b เข้ามา -> a คืนครึ่งหนึ่งให้ b
+	waitFor(t, "rebalance", func() bool {
+		owners := leases.owners()
+		return owners["a"] == 2 && owners["b"] == 2
+	})
+	cancel()
+	wg.Wait()
+
+	// ทุก Event ถูก Handle ครั้งเดียว: b ต่อจาก Checkpoint ของ Partition ที่ a ทำไว้แล้ว
+	count := map[string]int{}
+	for _, s := range append(seenA.streams(), seenB.streams()...) {
+		count[s]++
+	}
+	for i := 0; i < 20; i++ {
+		if id := fmt.Sprintf("product-%d", i); count[id] != 1 {
+			t.Fatalf("%s handled %d times", id, count[id])
+		}
+	}
+	// ปิดตัวแล้วคืน Lease หมด
+	if owners := leases.owners(); len(owners) != 0 {
+		t.Fatalf("leases not released: %v", owners)
+	}
+	if _, ok := checkpoints.tokens["main_projector#3/4"]; !ok {
+		t.Fatalf("missing partition checkpoint: %v", checkpoints.tokens)
+	}
+}
+
+func TestCoordinatorTakesOverAfterLeaseExpires(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"stream_id": "iphone-15", "type": "StockAdded"})
+	source.add("events", bson.M{"stream_id": "iphone-15", "type": "StockReserved"})
+
+	// Instance ที่ดับไปแล้ว: ทำ Event แรกจนบันทึก Checkpoint แต่ไม่ได้คืน Lease
+	checkpoints := &fakeCheckpoints{}
+	checkpoints.Save(context.Background(), "main_projector", source.token(0), time.Time{})
+	leases := &fakeLeases{leases: map[string]core.Lease{
+		leaseWorkPrefix + "main_projector": {Owner: "crashed", ExpiresAt: time.Now().Add(100 * time.Millisecond)},
+	}}
+
+	c, seen := newTestCoordinator(source, checkpoints, leases, "survivor", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Run(ctx) }()
+
+	waitFor(t, "takeover", func() bool { return leases.owners()["survivor"] == 1 })
+	waitFor(t, "catch up", func() bool { return len(seen.streams()) > 0 })
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// ต่อจาก Checkpoint ของเจ้าของเดิม: ไม่ทำ Event แรกซ้ำ ไม่ข้าม Event ที่สอง
+	if len(seen.seen) != 1 || seen.seen[0] != "StockReserved" {
+		t.Fatalf("want [StockReserved], got %v", seen.seen)
+	}
+}
+
+func TestWorkerStopsWhenLeaseExpires(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"stream_id": "iphone-15", "type": "StockAdded"})
+
+	checkpoints := &fakeCheckpoints{}
+	p := &recordingProjection{name: "products_view", checkpoint: "main_projector", sources: []string{"events"}}
+	registry := NewRegistry(source, checkpoints, &fakeDeadLetters{})
+
+	err := registry.run(context.Background(), worker{p: p, part: core.Whole, guard: func() error { return ErrLeaseExpired }})
+	if !errors.Is(err, ErrLeaseExpired) {
+		t.Fatalf("want ErrLeaseExpired, got %v", err)
+	}
+	// ไม่ Handle และไม่เลื่อน Checkpoint: เจ้าของใหม่จะทำ Event นี้เอง
+	if len(p.seen) != 0 || checkpoints.tokens["main_projector"] != nil {
+		t.Fatalf("handled after lease expired: seen=%v checkpoints=%v", p.seen, checkpoints.tokens)
+	}
+}
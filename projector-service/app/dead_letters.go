@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// DeadLetterService ให้ Operator ดู / Replay / ทิ้ง Event ที่ Projection Handle ไม่ผ่าน
+type DeadLetterService struct {
+	Store    ports.DeadLetterStore
+	Registry *Registry
+}
+
+func NewDeadLetterService(store ports.DeadLetterStore, registry *Registry) *DeadLetterService {
+	return &DeadLetterService{Store: store, Registry: registry}
+}
+
+func (s *DeadLetterService) List(ctx context.Context, projection string) ([]core.DeadLetter, error) {
+	return s.Store.List(ctx, projection)
+}
+
+// Replay ส่ง Event เข้า Projection เดิมอีกรอบ (ผ่าน = ปิดเคส, ไม่ผ่าน = เคสยังเปิดอยู่ attempts + 1)
+func (s *DeadLetterService) Replay(ctx context.Context, id string) error {
+	letter, err := s.open(ctx, id)
+	if err != nil {
+		return err
+	}
+	p := s.Registry.Find(letter.Projection)
+	if p == nil {
+		return fmt.Errorf("dead letter %s: projection %q is not registered", id, letter.Projection)
+	}
+	event, err := letter.Envelope()
+	if err != nil {
+		return fmt.Errorf("dead letter %s: %w", id, err)
+	}
+
+	if err := p.Handle(ctx, event); err != nil {
+		if recErr := s.Store.RecordFailure(ctx, id, err.Error()); recErr != nil {
+			return fmt.Errorf("replay failed: %w (record failure: %v)", err, recErr)
+		}
+		return fmt.Errorf("replay failed: %w", err)
+	}
+	return s.Store.Close(ctx, id, core.DeadLetterReplayed)
+}
+
+// ReplayAll Replay ทุกเคสที่เปิดอยู่ของ Projection ตามลำดับเวลา (ตัวไหนไม่ผ่านก็ทำตัวถัดไปต่อ)
+func (s *DeadLetterService) ReplayAll(ctx context.Context, projection string) (replayed int, failed int, err error) {
+	letters, err := s.Store.List(ctx, projection)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, letter := range letters {
+		if err := s.Replay(ctx, letter.ID); err != nil {
+			failed++
+			continue
+		}
+		replayed++
+	}
+	return replayed, failed, nil
+}
+
+// Discard ปิดเคสโดยไม่ Replay (Operator ยืนยันว่า Event นี้ไม่ต้องเข้า Read Model)
+func (s *DeadLetterService) Discard(ctx context.Context, id string) error {
+	if _, err := s.open(ctx, id); err != nil {
+		return err
+	}
+	return s.Store.Close(ctx, id, core.DeadLetterDiscarded)
+}
+
+func (s *DeadLetterService) open(ctx context.Context, id string) (*core.DeadLetter, error) {
+	letter, err := s.Store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if letter == nil {
+		return nil, fmt.Errorf("dead letter %s not found", id)
+	}
+	if letter.Status != core.DeadLetterOpen {
+		return nil, fmt.Errorf("dead letter %s is already %s", id, letter.Status)
+	}
+	return letter, nil
+}
@@ -0,0 +1,277 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"projector-service/core"
+	"projector-service/projections"
+)
+
+type fakeDeadLetters struct {
+	letters map[string]*core.DeadLetter
+	addErr  error
+}
+
+func (d *fakeDeadLetters) Add(ctx context.Context, letter core.DeadLetter) error {
+	if d.addErr != nil {
+		return d.addErr
+	}
+	if d.letters == nil {
+		d.letters = map[string]*core.DeadLetter{}
+	}
+	letter.Status = core.DeadLetterOpen
+	d.letters[letter.ID] = &letter
+	return nil
+}
+
+func (d *fakeDeadLetters) List(ctx context.Context, projection string) ([]core.DeadLetter, error) {
+	var out []core.DeadLetter
+	for _, l := range d.letters {
+		if l.Status == core.DeadLetterOpen && (projection == "" || l.Projection == projection) {
+			out = append(out, *l)
+		}
+	}
+	return out, nil
+}
+
+func (d *fakeDeadLetters) Get(ctx context.Context, id string) (*core.DeadLetter, error) {
+	l, ok := d.letters[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *l
+	return &copied, nil
+}
+
+func (d *fakeDeadLetters) RecordFailure(ctx context.Context, id string, reason string) error {
+	d.letters[id].Attempts++
+	d.letters[id].Error = reason
+	return nil
+}
+
+func (d *fakeDeadLetters) Close(ctx context.Context, id string, status string) error {
+	d.letters[id].Status = status
+	return nil
+}
+
+// flakyProjection ล้มเหลว failures ครั้งแรกแล้วค่อยผ่าน (failures < 0 = ล้มตลอด)
+type flakyProjection struct {
+	recordingProjection
+	failures int
+	calls    int
+}
+
+func (p *flakyProjection) Handle(ctx context.Context, event core.Envelope) error {
+	p.calls++
+	if p.failures < 0 || p.calls <= p.failures {
+		return errors.New("view unavailable")
+	}
+	return p.recordingProjection.Handle(ctx, event)
+}
+
+func newTestRegistry(source *fakeSource, checkpoints *fakeCheckpoints, deadLetters *fakeDeadLetters) *Registry {
+	r := NewRegistry(source, checkpoints, deadLetters)
+	r.Retry = RetryPolicy{MaxAttempts: 3}
+	return r
+}
+
+func TestRegistryRetriesBeforeSucceeding(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"type": "StockAdded"})
+	deadLetters := &fakeDeadLetters{}
+
+	p := &flakyProjection{recordingProjection: recordingProjection{name: "products_view", checkpoint: "cp", sources: []string{"events"}}, failures: 2}
+	r := newTestRegistry(source, &fakeCheckpoints{}, deadLetters)
+	r.Register(p)
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.calls != 3 || len(p.seen) != 1 {
+		t.Fatalf("calls=%d seen=%v, want 3 calls and the event applied", p.calls, p.seen)
+	}
+	if len(deadLetters.letters) != 0 {
+		t.Fatalf("unexpected dead letters: %v", deadLetters.letters)
+	}
+}
+
+func TestRegistryDeadLettersAfterMaxAttempts(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"type": "StockAdded"})
+	checkpoints := &fakeCheckpoints{}
+	deadLetters := &fakeDeadLetters{}
+
+	p := &flakyProjection{recordingProjection: recordingProjection{name: "products_view", checkpoint: "cp", sources: []string{"events"}}, failures: -1}
+	r := newTestRegistry(source, checkpoints, deadLetters)
+	r.Register(p)
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	letters, _ := deadLetters.List(context.Background(), "products_view")
+	if len(letters) != 1 {
+		t.Fatalf("want 1 dead letter, got %d", len(letters))
+	}
+	l := letters[0]
+	if l.Attempts != 3 || l.Error != "view unavailable" || l.Source != "events" {
+		t.Fatalf("unexpected dead letter: %+v", l)
+	}
+	if event, ok := l.Event(); !ok || event.Lookup("type").StringValue() != "StockAdded" {
+		t.Fatal("dead letter should keep the raw change event")
+	}
+	// Dead-letter แล้ว = เลื่อน Checkpoint ผ่านได้
+	if checkpoints.tokens["cp"] == nil {
+		t.Fatal("checkpoint should advance past a dead-lettered event")
+	}
+}
+
+func TestRegistryDeadLettersUndecodableChange(t *testing.T) {
+	source := &fakeSource{corrupt: map[int]error{1: errors.New("decode change event: truncated document")}}
+	source.add("events", bson.M{"type": "StockAdded"})
+	source.add("events", bson.M{"type": "StockReserved"})
+	source.add("events", bson.M{"type": "StockReleased"})
+	checkpoints := &fakeCheckpoints{}
+	deadLetters := &fakeDeadLetters{}
+
+	p := &flakyProjection{recordingProjection: recordingProjection{name: "products_view", checkpoint: "cp", sources: []string{"events"}}}
+	r := newTestRegistry(source, checkpoints, deadLetters)
+	r.Register(p)
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// ตัวที่ Decode ไม่ได้ไม่ถึง Projection และไม่ถูก Retry แต่ตัวถัดไปยังไปต่อได้
+	if p.calls != 2 || len(p.seen) != 2 || p.seen[1] != "StockReleased" {
+		t.Fatalf("calls=%d seen=%v, want the 2 decodable events", p.calls, p.seen)
+	}
+	letters, _ := deadLetters.List(context.Background(), "products_view")
+	if len(letters) != 1 {
+		t.Fatalf("want 1 dead letter, got %d", len(letters))
+	}
+	if l := letters[0]; l.Attempts != 1 || !strings.Contains(l.Error, "truncated document") || l.Change == nil {
+		t.Fatalf("unexpected dead letter: %+v", l)
+	}
+	if got := checkpoints.tokens["cp"].Lookup("i").Int32(); got != 2 {
+		t.Fatalf("checkpoint = %d, want 2", got)
+	}
+}
+
+// gapProjection ขยับ Version ทีละ 1 เหมือน products_view ที่เติม Gap จาก Event Store ไม่ได้
+type gapProjection struct {
+	recordingProjection
+	versions map[string]int
+	calls    int
+}
+
+func (p *gapProjection) Handle(ctx context.Context, event core.Envelope) error {
+	p.calls++
+	if last := p.versions[event.StreamID]; event.Version != last+1 {
+		return fmt.Errorf("%w: %s has v.%d, cannot apply v.%d", projections.ErrVersionGap, event.StreamID, last, event.Version)
+	}
+	p.versions[event.StreamID] = event.Version
+	return p.recordingProjection.Handle(ctx, event)
+}
+
+func TestRegistryDeadLettersUnfillableVersionGap(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"stream_id": "iphone-15", "type": "StockAdded", "version": 1})
+	source.add("events", bson.M{"stream_id": "iphone-15", "type": "StockCommitted", "version": 3}) // v.2 ไม่มีใน Event Store
+	source.add("events", bson.M{"stream_id": "ipad-air", "type": "StockAdded", "version": 1})
+	checkpoints := &fakeCheckpoints{}
+	deadLetters := &fakeDeadLetters{}
+
+	p := &gapProjection{recordingProjection: recordingProjection{name: "products_view", checkpoint: "cp", sources: []string{"events"}}, versions: map[string]int{}}
+	r := newTestRegistry(source, checkpoints, deadLetters)
+	r.Register(p)
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	letters, _ := deadLetters.List(context.Background(), "products_view")
+	if len(letters) != 1 {
+		t.Fatalf("want 1 dead letter, got %d", len(letters))
+	}
+	l := letters[0]
+	if l.Attempts != 3 || !strings.Contains(l.Error, projections.ErrVersionGap.Error()) {
+		t.Fatalf("unexpected dead letter: %+v", l)
+	}
+	if event, ok := l.Event(); !ok || event.Lookup("version").Int32() != 3 {
+		t.Fatal("dead letter should keep the event after the gap")
+	}
+	// Gap ของสินค้าหนึ่งไม่ขวางสินค้าอื่น
+	if len(p.seen) != 2 || p.seen[1] != "StockAdded" || p.versions["ipad-air"] != 1 {
+		t.Fatalf("seen=%v versions=%v, want the other events applied", p.seen, p.versions)
+	}
+	if got := checkpoints.tokens["cp"].Lookup("i").Int32(); got != 2 {
+		t.Fatalf("checkpoint = %d, want 2", got)
+	}
+}
+
+func TestRegistryKeepsCheckpointWhenDeadLetterFails(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"type": "StockAdded"})
+	checkpoints := &fakeCheckpoints{}
+
+	p := &flakyProjection{recordingProjection: recordingProjection{name: "products_view", checkpoint: "cp", sources: []string{"events"}}, failures: -1}
+	r := newTestRegistry(source, checkpoints, &fakeDeadLetters{addErr: errors.New("mongo down")})
+	r.Register(p)
+
+	err := r.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "mongo down") {
+		t.Fatalf("want dead-letter error, got %v", err)
+	}
+	if checkpoints.tokens["cp"] != nil {
+		t.Fatal("checkpoint must not advance past an event that was neither applied nor dead-lettered")
+	}
+}
+
+func TestDeadLetterReplayAndDiscard(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"type": "StockAdded"})
+	source.add("events", bson.M{"type": "StockReserved"})
+	deadLetters := &fakeDeadLetters{}
+
+	p := &flakyProjection{recordingProjection: recordingProjection{name: "products_view", checkpoint: "cp", sources: []string{"events"}}, failures: -1}
+	r := newTestRegistry(source, &fakeCheckpoints{}, deadLetters)
+	r.Register(p)
+	r.Run(context.Background())
+
+	service := NewDeadLetterService(deadLetters, r)
+	letters, _ := service.List(context.Background(), "")
+	if len(letters) != 2 {
+		t.Fatalf("want 2 dead letters, got %d", len(letters))
+	}
+
+	// ยังพังอยู่ -> เคสยังเปิด attempts เพิ่ม
+	if err := service.Replay(context.Background(), letters[0].ID); err == nil {
+		t.Fatal("replay should fail while the projection still fails")
+	}
+	if got := deadLetters.letters[letters[0].ID]; got.Status != core.DeadLetterOpen || got.Attempts != 4 {
+		t.Fatalf("failed replay should keep the letter open with attempts+1: %+v", got)
+	}
+
+	// แก้แล้ว -> Replay ผ่าน ปิดเคส
+	p.failures = 0
+	if err := service.Replay(context.Background(), letters[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := deadLetters.letters[letters[0].ID].Status; got != core.DeadLetterReplayed {
+		t.Fatalf("status = %s, want REPLAYED", got)
+	}
+	if err := service.Replay(context.Background(), letters[0].ID); err == nil {
+		t.Fatal("replaying a closed letter should fail")
+	}
+
+	if err := service.Discard(context.Background(), letters[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := deadLetters.letters[letters[1].ID].Status; got != core.DeadLetterDiscarded {
+		t.Fatalf("status = %s, want DISCARDED", got)
+	}
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// Rebuilder สร้าง Read Model ใหม่ทั้งก้อนแบบ Blue/Green
+//
+//  1. จด Head ของ Source ไว้ก่อน
+//  2. Project History ทั้งหมดลง Shadow Collection (<view>_rebuild)
+//  3. Catch-up Event ที่เข้ามาหลัง Head ลง Shadow
+//  4. Swap: Rename Shadow ทับ View เดิม (ผู้อ่านเห็นแค่ View เก่าที่ครบ หรือ View ใหม่ที่ครบ)
+//  5. Catch-up อีกรอบบน View ใหม่: Event ที่ Projector ตัวหลักเขียนลง View เก่าระหว่าง 3-4 หายไปกับ View เก่า
+//  6. บันทึก Checkpoint ใหม่
+//
+// Projector ตัวหลักรันต่อได้ระหว่าง Rebuild เพราะ Handler ต้อง Idempotent อยู่แล้ว (Event ซ้ำจะถูกข้าม)
+type Rebuilder struct {
+	Source      ports.EventSource
+	Checkpoints ports.CheckpointStore
+	Views       ports.ViewAdmin
+	Partitions  int // เท่ากับของ Coordinator: Checkpoint ใหม่ต้องบันทึกให้ทุก Partition
+	BatchSize   int // Event ต่อกลุ่มตอน Project History (<= 1 = ทีละตัว)
+}
+
+func NewRebuilder(source ports.EventSource, checkpoints ports.CheckpointStore, views ports.ViewAdmin) *Rebuilder {
+	return &Rebuilder{Source: source, Checkpoints: checkpoints, Views: views}
+}
+
+// ShadowName คือชื่อ Collection ชั่วคราวระหว่าง Rebuild
+func ShadowName(view string) string {
+	return view + "_rebuild"
+}
+
+func (r *Rebuilder) Rebuild(ctx context.Context, p ports.Rebuildable) error {
+	shadowName := ShadowName(p.View())
+
+	// เศษจาก Rebuild รอบก่อนที่พังกลางทาง -> ทิ้งแล้วเริ่มใหม่
+	if err := r.Views.Drop(ctx, shadowName); err != nil {
+		return fmt.Errorf("drop old shadow: %w", err)
+	}
+	shadow, err := p.Shadow(ctx, shadowName)
+	if err != nil {
+		return fmt.Errorf("create shadow: %w", err)
+	}
+
+	// 1. Head ก่อนอ่าน History: Event ที่เข้ามาระหว่างอ่านจะถูกเก็บตอน Catch-up (ถ้าซ้ำกับ History ก็ถูกข้าม)
+	head, err := r.Source.Head(ctx, p.Sources())
+	if err != nil {
+		return fmt.Errorf("read head: %w", err)
+	}
+
+	// 2. History (Event พังตัวเดียว = ยกเลิกทั้งหมด ห้าม Swap View ที่ไม่ครบ)
+	log.Printf("🏗️ [%s] Projecting history into %s...", p.Name(), shadowName)
+	projected := 0
+	batch := &batcher{size: r.BatchSize, flush: func(changes []core.Change) error {
+		return handleChanges(ctx, shadow, changes)
+	}}
+	err = r.Source.History(ctx, p.Sources(), p.Filter(), nil, func(c core.Change) error {
+		projected++
+		return batch.add(c)
+	})
+	if err == nil {
+		err = batch.done()
+	}
+	if err != nil {
+		return fmt.Errorf("project history (event #%d): %w", projected, err)
+	}
+
+	// 3. Catch-up ลง Shadow
+	token, err := r.Source.CatchUp(ctx, p.Sources(), p.Filter(), head, func(c core.Change) error {
+		projected++
+		return handleChange(ctx, shadow, c)
+	})
+	if err != nil {
+		return fmt.Errorf("catch up shadow: %w", err)
+	}
+
+	// 4. Swap
+	if err := r.Views.Swap(ctx, shadowName, p.View()); err != nil {
+		return fmt.Errorf("swap %s -> %s: %w", shadowName, p.View(), err)
+	}
+	log.Printf("🔀 [%s] Swapped %s -> %s (%d events)", p.Name(), shadowName, p.View(), projected)
+
+	// 5. Catch-up บน View ใหม่ (p เขียนลงชื่อ View เดิม ซึ่งตอนนี้คือ Collection ที่เพิ่ง Swap เข้ามา)
+	token, err = r.Source.CatchUp(ctx, p.Sources(), p.Filter(), token, func(c core.Change) error {
+		return handleChange(ctx, p, c)
+	})
+	if err != nil {
+		return fmt.Errorf("catch up after swap: %w", err)
+	}
+
+	// 6. Checkpoint ใหม่ (Projector ที่ Start หลังจากนี้จะทำต่อจากตรงนี้ ไม่ย้อนทั้ง History)
+	for i := 0; i < max(r.Partitions, 1); i++ {
+		id := core.Partition{Index: i, Count: max(r.Partitions, 1)}.CheckpointID(p.CheckpointID())
+		if err := r.Checkpoints.Save(ctx, id, token, time.Time{}); err != nil { // ไม่รู้เวลาของ Event สุดท้าย: Lag จะกลับมาเมื่อมี Event ถัดไป
+			return fmt.Errorf("save checkpoint: %w", err)
+		}
+	}
+	log.Printf("✅ [%s] Rebuild completed.", p.Name())
+	return nil
+}
+
+// handleChanges ส่งทั้งกลุ่มให้ Projection ที่เป็น Batched ถ้าไม่ผ่านก็ส่งทีละตัว (Idempotent: ตัวที่ Apply แล้วถูกข้าม)
+func handleChanges(ctx context.Context, p ports.Projection, changes []core.Change) error {
+	if b, ok := p.(ports.Batched); ok && len(changes) > 1 {
+		if err := handleBatch(ctx, b, changes); err == nil {
+			return nil
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	for _, c := range changes {
+		if err := handleChange(ctx, p, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleChange ส่ง Event ให้ Projection ตรงๆ (ไม่มี Retry / Dead Letter: Rebuild พัง = ไม่ Swap)
+func handleChange(ctx context.Context, p ports.Projection, c core.Change) error {
+	event, err := c.Envelope()
+	if err != nil {
+		return err
+	}
+	return p.Handle(ctx, event)
+}
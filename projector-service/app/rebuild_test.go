@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// memoryViews คือ Collection ใน RAM: ชื่อ -> (product -> ยอด)
+type memoryViews struct {
+	collections map[string]map[string]int
+	swaps       []string
+}
+
+func (v *memoryViews) collection(name string) map[string]int {
+	if v.collections == nil {
+		v.collections = map[string]map[string]int{}
+	}
+	if v.collections[name] == nil {
+		v.collections[name] = map[string]int{}
+	}
+	return v.collections[name]
+}
+
+func (v *memoryViews) Drop(ctx context.Context, collection string) error {
+	delete(v.collections, collection)
+	return nil
+}
+
+func (v *memoryViews) Swap(ctx context.Context, from, to string) error {
+	v.collections[to] = v.collection(from)
+	delete(v.collections, from)
+	v.swaps = append(v.swaps, from+"->"+to)
+	return nil
+}
+
+// stockProjection บวกยอดตาม qty (ไม่สนใจ Version) ลง Collection ชื่อ view
+type stockProjection struct {
+	views  *memoryViews
+	view   string
+	onSeen func() // เรียกหลัง Handle แต่ละครั้ง (จำลอง Event ที่เข้ามาระหว่าง Rebuild)
+}
+
+func (p *stockProjection) Name() string         { return "products_view" }
+func (p *stockProjection) CheckpointID() string { return "main_projector" }
+func (p *stockProjection) Sources() []string    { return []string{"events"} }
+func (p *stockProjection) Filter() bson.D       { return nil }
+func (p *stockProjection) View() string         { return p.view }
+
+func (p *stockProjection) Shadow(ctx context.Context, collection string) (ports.Projection, error) {
+	return &stockProjection{views: p.views, view: collection, onSeen: p.onSeen}, nil
+}
+
+func (p *stockProjection) Handle(ctx context.Context, event core.Envelope) error {
+	p.views.collection(p.view)[event.StreamID] += int(event.Document.Lookup("qty").Int32())
+	if p.onSeen != nil {
+		p.onSeen()
+	}
+	return nil
+}
+
+func TestRebuildProjectsIntoShadowThenSwaps(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"stream_id": "iphone-15", "qty": int32(100)})
+	source.add("events", bson.M{"stream_id": "iphone-15", "qty": int32(-1)})
+
+	views := &memoryViews{}
+	// View เดิมนับซ้ำไว้ (เหมือนข้อมูล Seed ที่ผิด) -> ต้องถูกแทนที่ทั้งก้อน
+	views.collection("products_view")["iphone-15"] = 199
+
+	// มี Event ใหม่เข้ามาระหว่างอ่าน History -> ต้องถูกเก็บตอน Catch-up
+	added := false
+	live := &stockProjection{views: views, view: "products_view"}
+	live.onSeen = func() {
+		if !added {
+			added = true
+			source.add("events", bson.M{"stream_id": "iphone-15", "qty": int32(-2)})
+		}
+	}
+
+	checkpoints := &fakeCheckpoints{}
+	if err := NewRebuilder(source, checkpoints, views).Rebuild(context.Background(), live); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := views.collections["products_view"]["iphone-15"]; got != 97 {
+		t.Fatalf("products_view = %d, want 97", got)
+	}
+	if _, ok := views.collections[ShadowName("products_view")]; ok {
+		t.Fatal("shadow collection should be gone after swap")
+	}
+	if len(views.swaps) != 1 || views.swaps[0] != "products_view_rebuild->products_view" {
+		t.Fatalf("unexpected swaps: %v", views.swaps)
+	}
+	if got := checkpoints.tokens["main_projector"].Lookup("i").Int32(); got != 2 {
+		t.Fatalf("checkpoint = %d, want 2 (last event)", got)
+	}
+}
@@ -0,0 +1,406 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// Registry รวม Projection ทั้งหมดแล้วรันขนานกัน (1 Goroutine / 1 Change Stream ต่อ Projection แม้จะฟังหลาย Source)
+// แต่ละตัวมี Checkpoint ของตัวเอง: ตัวไหนช้าหรือพังไม่ลากตัวอื่นไปด้วย
+type Registry struct {
+	Source      ports.EventSource
+	Checkpoints ports.CheckpointStore
+	DeadLetters ports.DeadLetterStore
+	Retry       RetryPolicy
+	Batch       BatchPolicy
+	projections []ports.Projection
+}
+
+// RetryPolicy คือจำนวนครั้งที่ลอง Handle Event เดิมก่อนส่งเข้า Dead Letter
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration // รอก่อนลองรอบที่ 2 แล้วเพิ่มเท่าตัวทุกรอบ
+	MaxBackoff     time.Duration
+}
+
+// BatchPolicy คือโหมด Batch: รวม Event เป็นกลุ่ม Apply ทีเดียว (Projection ที่เป็น Batched) และบันทึก Checkpoint ครั้งเดียวต่อกลุ่ม
+// Size <= 1 = ปิด (ทีละ Event) เหมาะกับตอนไล่ Event จำนวนมาก เช่น Replay ทั้งหมด / Recover
+type BatchPolicy struct {
+	Size   int           // Event สูงสุดต่อกลุ่ม
+	Window time.Duration // รอรวมกลุ่มนานสุดเท่านี้นับจาก Event แรก (ไล่ทันแล้วจะส่งทันทีไม่รอ)
+}
+
+func NewRegistry(source ports.EventSource, checkpoints ports.CheckpointStore, deadLetters ports.DeadLetterStore) *Registry {
+	return &Registry{
+		Source:      source,
+		Checkpoints: checkpoints,
+		DeadLetters: deadLetters,
+		Retry:       RetryPolicy{MaxAttempts: 5, InitialBackoff: 200 * time.Millisecond, MaxBackoff: 5 * time.Second},
+		Batch:       BatchPolicy{Size: 1, Window: 200 * time.Millisecond},
+	}
+}
+
+// Register เพิ่ม Projection (Name และ CheckpointID ต้องไม่ซ้ำกับตัวที่มีอยู่)
+func (r *Registry) Register(p ports.Projection) error {
+	for _, existing := range r.projections {
+		if existing.Name() == p.Name() {
+			return fmt.Errorf("projection %q already registered", p.Name())
+		}
+		if existing.CheckpointID() == p.CheckpointID() {
+			return fmt.Errorf("projection %q: checkpoint %q already used by %q", p.Name(), p.CheckpointID(), existing.Name())
+		}
+	}
+	r.projections = append(r.projections, p)
+	return nil
+}
+
+// Projections คืนรายชื่อ Projection ตามลำดับที่ Register
+func (r *Registry) Projections() []ports.Projection {
+	return r.projections
+}
+
+// Find คืน Projection ตามชื่อ (nil ถ้าไม่เจอ)
+func (r *Registry) Find(name string) ports.Projection {
+	for _, p := range r.projections {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Run รันทุก Projection จนกว่า ctx จะถูกยกเลิก (Instance เดียว: ไม่แบ่ง Partition ไม่ใช้ Lease)
+// ถ้าตัวไหนพัง (เช่น Stream Error) จะหยุดตัวอื่นแล้วคืน Error ตัวแรก
+// รันหลาย Instance ต้องใช้ Coordinator แทน (ไม่งั้นทุกตัวแย่งกันเขียน Checkpoint เดียวกัน)
+func (r *Registry) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(r.projections))
+	for _, p := range r.projections {
+		go func(w worker) {
+			err := r.run(ctx, w)
+			if err != nil {
+				err = fmt.Errorf("projection %s: %w", w.name(), err)
+			}
+			errs <- err
+		}(worker{p: p, part: core.Whole})
+	}
+
+	var firstErr error
+	for range r.projections {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	return firstErr
+}
+
+// worker คืองาน 1 ชิ้นที่รันได้อิสระ: Partition หนึ่งของ Projection (Stream + Checkpoint ของตัวเอง)
+type worker struct {
+	p    ports.Projection
+	part core.Partition
+	// guard คืน Error เมื่อ Instance นี้ไม่ได้ถือ Lease ของงานนี้แล้ว (nil = ไม่ใช้ Lease)
+	// เช็กก่อน Handle และก่อนบันทึก Checkpoint ทุกครั้ง
+	guard func() error
+}
+
+func (w worker) name() string {
+	if w.part.Count <= 1 {
+		return w.p.Name()
+	}
+	return fmt.Sprintf("%s[%s]", w.p.Name(), w.part)
+}
+
+func (w worker) checkpointID() string {
+	return w.part.CheckpointID(w.p.CheckpointID())
+}
+
+func (w worker) check() error {
+	if w.guard == nil {
+		return nil
+	}
+	return w.guard()
+}
+
+// owns: Event นี้เป็นของ Partition นี้ไหม (Decode ไม่ได้ -> Key ว่าง ตกไป Partition เดียวเสมอ แล้วไปจบที่ Dead Letter)
+func (w worker) owns(change core.Change) bool {
+	if w.part.Count <= 1 {
+		return true
+	}
+	event, _ := change.Envelope()
+	key := event.StreamID
+	if p, ok := w.p.(ports.Partitioned); ok {
+		key = p.PartitionKey(event)
+	}
+	return w.part.Owns(key)
+}
+
+func (r *Registry) run(ctx context.Context, w worker) error {
+	for {
+		err := r.watch(ctx, w)
+		if !errors.Is(err, ports.ErrHistoryLost) && !errors.Is(err, ports.ErrStreamInvalidated) {
+			return err
+		}
+		// Token ใช้ต่อไม่ได้แล้ว -> อ่าน Event Store ตรงๆ ให้ทัน แล้วเปิด Stream ใหม่จาก "ตอนนี้" (ไม่ต้องมีคนมาแก้)
+		log.Printf("🚑 [%s] %v -> recovering from %s", w.name(), err, sourceNames(w.p))
+		if err := r.recover(ctx, w); err != nil {
+			return fmt.Errorf("recover: %w", err)
+		}
+	}
+}
+
+func (r *Registry) watch(ctx context.Context, w worker) error {
+	// Load Resume Token (กู้คืนจุดล่าสุดที่อ่านค้างไว้)
+	token, err := r.Checkpoints.Load(ctx, w.checkpointID())
+	if err != nil {
+		return err
+	}
+	if token == nil && w.part.Count > 1 {
+		// เพิ่งเริ่มแบ่ง Partition -> ต่อจาก Checkpoint ตอนรันตัวเดียว (ไม่ต้องย้อนทั้ง History)
+		if token, err = r.Checkpoints.Load(ctx, w.p.CheckpointID()); err != nil {
+			return err
+		}
+	}
+	if token != nil {
+		log.Printf("🔄 [%s] Resumed from last checkpoint.", w.name())
+	} else {
+		log.Printf("🆕 [%s] No checkpoint found. Replaying ALL history from beginning...", w.name())
+	}
+	log.Printf("👀 [%s] Watching %s for events...", w.name(), sourceNames(w.p))
+
+	if r.Batch.Size > 1 {
+		err = r.Source.WatchBatches(ctx, w.p.Sources(), w.p.Filter(), token, r.Batch.Size, r.Batch.Window, func(changes []core.Change) error {
+			return r.processBatch(ctx, w, changes)
+		})
+	} else {
+		err = r.Source.Watch(ctx, w.p.Sources(), w.p.Filter(), token, func(change core.Change) error {
+			return r.processBatch(ctx, w, []core.Change{change})
+		})
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("👋 [%s] Stream closed gracefully.", w.name())
+	return nil
+}
+
+// recover ใช้เมื่อ Resume Token หลุดจาก Oplog หรือ Stream ถูก Invalidate
+//  1. จด Head ของ Source ไว้ก่อน (Event ที่เข้ามาระหว่าง Catch-up จะอยู่หลัง Head -> Stream ใหม่ส่งมาให้)
+//  2. อ่าน Event Store ตรงๆ ต่อจาก Version ล่าสุดของแต่ละ Stream ที่ View มี (ถ้า Projection บอกได้)
+//     ไม่งั้นอ่านทั้งหมด (Handler Idempotent อยู่แล้ว)
+//  3. บันทึก Head เป็น Checkpoint แล้วให้ run เปิด Stream ใหม่จากตรงนั้น
+func (r *Registry) recover(ctx context.Context, w worker) error {
+	head, err := r.Source.Head(ctx, w.p.Sources())
+	if err != nil {
+		return fmt.Errorf("read head: %w", err)
+	}
+
+	var after map[string]int
+	if v, ok := w.p.(ports.Versioned); ok {
+		if after, err = v.Positions(ctx); err != nil {
+			return fmt.Errorf("read positions: %w", err)
+		}
+	}
+
+	caughtUp := 0
+	var lastEventAt time.Time
+	batch := &batcher{size: r.Batch.Size, flush: func(changes []core.Change) error {
+		if err := w.check(); err != nil {
+			return err
+		}
+		return r.applyBatch(ctx, w, changes)
+	}}
+	err = r.Source.History(ctx, w.p.Sources(), w.p.Filter(), after, func(change core.Change) error {
+		lastEventAt = later(lastEventAt, eventAt(change))
+		if !w.owns(change) {
+			return nil
+		}
+		caughtUp++
+		return batch.add(change)
+	})
+	if err == nil {
+		err = batch.done()
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := w.check(); err != nil {
+		return err
+	}
+	if err := r.Checkpoints.Save(ctx, w.checkpointID(), head, lastEventAt); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	log.Printf("✅ [%s] Caught up %d event(s) from %s, reopening change stream at current time.", w.name(), caughtUp, sourceNames(w.p))
+	return nil
+}
+
+// processBatch Apply กลุ่มของ Event (เฉพาะของ Partition นี้) แล้วบันทึก Checkpoint ครั้งเดียวที่ Token ของตัวสุดท้าย
+// ถ้าโปรแกรมดับ เปิดมาใหม่จะได้ทำต่อจากตรงนี้ (Lease หลุดแล้วห้ามบันทึก: เจ้าของใหม่อาจทำไปไกลกว่านี้)
+func (r *Registry) processBatch(ctx context.Context, w worker, changes []core.Change) error {
+	owned := make([]core.Change, 0, len(changes))
+	for _, change := range changes {
+		if w.owns(change) {
+			owned = append(owned, change)
+		}
+	}
+	if len(owned) > 0 {
+		if err := w.check(); err != nil {
+			return err
+		}
+		if err := r.applyBatch(ctx, w, owned); err != nil {
+			return err
+		}
+	}
+
+	if err := w.check(); err != nil {
+		return err
+	}
+	last := changes[len(changes)-1]
+	if err := r.Checkpoints.Save(ctx, w.checkpointID(), last.Token, eventAt(last)); err != nil {
+		log.Printf("⚠️ [%s] Failed to save checkpoint: %v", w.name(), err)
+	}
+	return nil
+}
+
+// applyBatch: Projection ที่เป็น Batched ได้ทั้งกลุ่มในครั้งเดียว
+// ไม่ผ่าน (หรือไม่ใช่ Batched) -> ส่งทีละตัวตามปกติพร้อม Retry / Dead Letter (ตัวที่ Apply ไปแล้วถูกข้ามเพราะ Idempotent)
+func (r *Registry) applyBatch(ctx context.Context, w worker, changes []core.Change) error {
+	if b, ok := w.p.(ports.Batched); ok && len(changes) > 1 {
+		err := handleBatch(ctx, b, changes)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("⚠️ [%s] Batch of %d event(s) failed, applying one by one: %v", w.name(), len(changes), err)
+	}
+	for _, change := range changes {
+		if err := r.process(ctx, w, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleBatch แปลงทั้งกลุ่มเป็น Envelope แล้วส่งให้ Projection ครั้งเดียว (Decode ไม่ได้สักตัว = ไม่ผ่านทั้งกลุ่ม)
+func handleBatch(ctx context.Context, p ports.Batched, changes []core.Change) error {
+	events := make([]core.Envelope, 0, len(changes))
+	for _, change := range changes {
+		event, err := change.Envelope()
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+	return p.HandleBatch(ctx, events)
+}
+
+// batcher สะสม Change ที่อ่านจาก History แล้วส่งทีละกลุ่ม (size <= 1 = ส่งทีละตัว)
+type batcher struct {
+	size    int
+	flush   func([]core.Change) error
+	pending []core.Change
+}
+
+func (b *batcher) add(change core.Change) error {
+	b.pending = append(b.pending, change)
+	if len(b.pending) < max(b.size, 1) {
+		return nil
+	}
+	return b.done()
+}
+
+// done ส่งที่ค้างอยู่ (เรียกหลังอ่าน History ครบ)
+func (b *batcher) done() error {
+	if len(b.pending) == 0 {
+		return nil
+	}
+	pending := b.pending
+	b.pending = nil
+	return b.flush(pending)
+}
+
+// process ส่ง Event ให้ Projection (พร้อม Retry) ถ้ายังไม่ผ่านจะเก็บลง Dead Letter
+// คืน Error เฉพาะกรณีที่ห้ามเลื่อน Checkpoint ผ่าน Event นี้
+func (r *Registry) process(ctx context.Context, w worker, change core.Change) error {
+	p := w.p
+	attempts, err := r.handle(ctx, p, change)
+	if ctx.Err() != nil {
+		return ctx.Err() // กำลังปิดตัว: Event นี้จะถูกส่งมาใหม่หลัง Restart
+	}
+	if err == nil {
+		return nil
+	}
+
+	// ลองครบแล้วยังไม่ผ่าน -> Dead Letter (Projector ไม่ควรหยุดเพราะ Event ตัวเดียว)
+	log.Printf("☠️ [%s] Dead-lettering event after %d attempts: %v", w.name(), attempts, err)
+	if dlErr := r.DeadLetters.Add(ctx, core.DeadLetter{
+		ID:         core.DeadLetterID(p.Name(), change.Key()),
+		Projection: p.Name(),
+		Source:     change.Source,
+		Token:      change.Token,
+		Change:     change.Raw,
+		Error:      err.Error(),
+		Attempts:   attempts,
+	}); dlErr != nil {
+		// ห้ามเลื่อน Checkpoint ผ่าน Event ที่ทั้งไม่สำเร็จและไม่ได้เก็บไว้ -> หยุด Stream
+		return fmt.Errorf("dead-letter event: %w (handle error: %v)", dlErr, err)
+	}
+	return nil
+}
+
+// handle เรียก Projection ซ้ำตาม RetryPolicy (Backoff เพิ่มเท่าตัว) คืนจำนวนครั้งที่ลองไป
+func (r *Registry) handle(ctx context.Context, p ports.Projection, change core.Change) (int, error) {
+	event, err := change.Envelope()
+	if err != nil {
+		return 1, err // Decode ไม่ได้ ลองใหม่ก็ไม่หาย -> Dead Letter ทันที
+	}
+
+	backoff := r.Retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := p.Handle(ctx, event)
+		if err == nil || attempt >= r.Retry.MaxAttempts {
+			return attempt, err
+		}
+		log.Printf("❌ [%s] Failed to process event (attempt %d/%d), retry in %s: %v", p.Name(), attempt, r.Retry.MaxAttempts, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, r.Retry.MaxBackoff)
+	}
+}
+
+// eventAt คือเวลาของ Event (Decode ไม่ได้ = ไม่รู้) บันทึกคู่กับ Checkpoint ไว้คำนวณ Lag
+func eventAt(change core.Change) time.Time {
+	event, err := change.Envelope()
+	if err != nil {
+		return time.Time{}
+	}
+	return event.Timestamp
+}
+
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// sourceNames ใช้ใน Log
+func sourceNames(p ports.Projection) string {
+	return strings.Join(p.Sources(), "+")
+}
@@ -0,0 +1,327 @@
+package app
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// fakeSource คือ Event Store ใน RAM: Log เดียวของทุก Collection ตามลำดับที่เขียน (เหมือน Oplog)
+// Token ของ Event ตัวที่ i ใน Log คือ {"i": i}
+type fakeSource struct {
+	log       []fakeEvent
+	watchErrs []error       // Error ที่ Watch คืนทันทีทีละตัว (จำลอง Token หลุด / Invalidate)
+	corrupt   map[int]error // Event ตัวที่ i Decode ไม่ได้ (ส่งเป็น Change ที่มี Err แบบ decodeChange)
+}
+
+type fakeEvent struct {
+	source string
+	doc    bson.Raw
+}
+
+func (s *fakeSource) add(source string, doc bson.M) {
+	raw, _ := bson.Marshal(doc)
+	s.log = append(s.log, fakeEvent{source: source, doc: raw})
+}
+
+func (s *fakeSource) Watch(ctx context.Context, sources []string, filter bson.D, resumeAfter bson.Raw, handle func(core.Change) error) error {
+	if len(s.watchErrs) > 0 {
+		err := s.watchErrs[0]
+		s.watchErrs = s.watchErrs[1:]
+		return err
+	}
+	start := 0
+	if resumeAfter != nil {
+		start = int(resumeAfter.Lookup("i").Int32()) + 1
+	}
+	for i := start; i < len(s.log); i++ {
+		e := s.log[i]
+		if !slices.Contains(sources, e.source) {
+			continue
+		}
+		raw, _ := bson.Marshal(bson.M{"_id": s.token(i), "ns": bson.M{"coll": e.source}, "fullDocument": e.doc})
+		change := core.Change{Source: e.source, Token: s.token(i), Document: e.doc, Raw: raw}
+		if err := s.corrupt[i]; err != nil {
+			change = core.Change{Source: e.source, Token: s.token(i), Raw: raw, Err: err}
+		}
+		if err := handle(change); err != nil {
+			return err
+		}
+	}
+	return nil // Stream ปิด
+}
+
+// WatchBatches ตัดกลุ่มตามจำนวนอย่างเดียว (ไม่มี Event ค้างใน RAM ที่ต้องรอ)
+func (s *fakeSource) WatchBatches(ctx context.Context, sources []string, filter bson.D, resumeAfter bson.Raw, size int, window time.Duration, handle func([]core.Change) error) error {
+	var batch []core.Change
+	err := s.Watch(ctx, sources, filter, resumeAfter, func(c core.Change) error {
+		batch = append(batch, c)
+		if len(batch) < size {
+			return nil
+		}
+		defer func() { batch = nil }()
+		return handle(batch)
+	})
+	if err != nil || len(batch) == 0 {
+		return err
+	}
+	return handle(batch)
+}
+
+func (s *fakeSource) token(i int) bson.Raw {
+	raw, _ := bson.Marshal(bson.M{"i": int32(i)})
+	return raw
+}
+
+func (s *fakeSource) Head(ctx context.Context, sources []string) (bson.Raw, error) {
+	return s.token(len(s.log) - 1), nil
+}
+
+func (s *fakeSource) Latest(ctx context.Context, sources []string, filter bson.D) (time.Time, error) {
+	var latest time.Time
+	for _, e := range s.log {
+		if at, ok := e.doc.Lookup("timestamp").TimeOK(); ok && slices.Contains(sources, e.source) && at.After(latest) {
+			latest = at
+		}
+	}
+	return latest, nil
+}
+
+func (s *fakeSource) CatchUp(ctx context.Context, sources []string, filter bson.D, resumeAfter bson.Raw, handle func(core.Change) error) (bson.Raw, error) {
+	last := resumeAfter
+	err := s.Watch(ctx, sources, filter, resumeAfter, func(c core.Change) error {
+		last = c.Token
+		return handle(c)
+	})
+	return last, err
+}
+
+func (s *fakeSource) History(ctx context.Context, sources []string, filter bson.D, after map[string]int, handle func(core.Change) error) error {
+	for _, source := range sources {
+		for _, e := range s.log {
+			if e.source != source {
+				continue
+			}
+			if after != nil {
+				streamID, _ := e.doc.Lookup("stream_id").StringValueOK()
+				version, _ := e.doc.Lookup("version").Int32OK()
+				if last, ok := after[streamID]; ok && int(version) <= last {
+					continue
+				}
+			}
+			if err := handle(core.HistoryChange(source, e.doc)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type fakeCheckpoints struct {
+	mu      sync.Mutex
+	tokens  map[string]bson.Raw
+	eventAt map[string]time.Time
+	saves   int
+}
+
+func (c *fakeCheckpoints) Load(ctx context.Context, id string) (bson.Raw, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens[id], nil
+}
+
+func (c *fakeCheckpoints) Save(ctx context.Context, id string, token bson.Raw, eventAt time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tokens == nil {
+		c.tokens = map[string]bson.Raw{}
+		c.eventAt = map[string]time.Time{}
+	}
+	c.saves++
+	c.tokens[id] = token
+	c.eventAt[id] = eventAt
+	return nil
+}
+
+func (c *fakeCheckpoints) List(ctx context.Context, base string) ([]core.Checkpoint, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []core.Checkpoint
+	for id, token := range c.tokens {
+		if id == base || strings.HasPrefix(id, base+"#") {
+			out = append(out, core.Checkpoint{ID: id, Token: token, EventAt: c.eventAt[id]})
+		}
+	}
+	return out, nil
+}
+
+func (c *fakeCheckpoints) Delete(ctx context.Context, base string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id := range c.tokens {
+		if id == base || strings.HasPrefix(id, base+"#") {
+			delete(c.tokens, id)
+			delete(c.eventAt, id)
+		}
+	}
+	return nil
+}
+
+// recordingProjection จำ Type ของ Event ที่ได้รับไว้
+type recordingProjection struct {
+	name, checkpoint string
+	sources          []string
+	mu               sync.Mutex
+	seen             []string
+	envelopes        []core.Envelope
+}
+
+func (p *recordingProjection) Name() string         { return p.name }
+func (p *recordingProjection) CheckpointID() string { return p.checkpoint }
+func (p *recordingProjection) Sources() []string    { return p.sources }
+func (p *recordingProjection) Filter() bson.D       { return nil }
+
+func (p *recordingProjection) Handle(ctx context.Context, event core.Envelope) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seen = append(p.seen, event.Type)
+	p.envelopes = append(p.envelopes, event)
+	return nil
+}
+
+func TestRegistryRunsProjectionsWithOwnCheckpoints(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"type": "StockAdded"})
+	source.add("events", bson.M{"type": "StockReserved"})
+	source.add("catalog_events", bson.M{"type": "ProductCreated"})
+
+	checkpoints := &fakeCheckpoints{}
+	// products อ่านไปแล้ว 1 ตัว -> ต้องได้แค่ตัวที่ 2
+	token, _ := bson.Marshal(bson.M{"i": int32(0)})
+	checkpoints.Save(context.Background(), "products", token, time.Time{})
+
+	products := &recordingProjection{name: "products_view", checkpoint: "products", sources: []string{"events"}}
+	catalog := &recordingProjection{name: "catalog_view", checkpoint: "catalog", sources: []string{"catalog_events"}}
+
+	registry := NewRegistry(source, checkpoints, &fakeDeadLetters{})
+	for _, p := range []*recordingProjection{products, catalog} {
+		if err := registry.Register(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := registry.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(products.seen) != 1 || products.seen[0] != "StockReserved" {
+		t.Fatalf("products_view should resume after its checkpoint, got %v", products.seen)
+	}
+	if len(catalog.seen) != 1 || catalog.seen[0] != "ProductCreated" {
+		t.Fatalf("catalog_view should replay from the beginning, got %v", catalog.seen)
+	}
+	if got := checkpoints.tokens["products"].Lookup("i").Int32(); got != 1 {
+		t.Fatalf("products checkpoint = %d, want 1", got)
+	}
+	if got := checkpoints.tokens["catalog"].Lookup("i").Int32(); got != 2 {
+		t.Fatalf("catalog checkpoint = %d, want 2", got)
+	}
+}
+
+func TestRegistryRejectsDuplicates(t *testing.T) {
+	registry := NewRegistry(&fakeSource{}, &fakeCheckpoints{}, &fakeDeadLetters{})
+	if err := registry.Register(&recordingProjection{name: "a", checkpoint: "cp-a", sources: []string{"events"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(&recordingProjection{name: "a", checkpoint: "cp-b", sources: []string{"events"}}); err == nil {
+		t.Fatal("expected error for duplicate name")
+	}
+	if err := registry.Register(&recordingProjection{name: "b", checkpoint: "cp-a", sources: []string{"events"}}); err == nil {
+		t.Fatal("expected error for duplicate checkpoint")
+	}
+}
+
+// versionedProjection บอก Positions ที่ View มีอยู่แล้วได้ (ใช้ทดสอบการ Recover)
+type versionedProjection struct {
+	recordingProjection
+	positions map[string]int
+}
+
+func (p *versionedProjection) Positions(ctx context.Context) (map[string]int, error) {
+	return p.positions, nil
+}
+
+func TestRegistryRecoversWhenHistoryIsLost(t *testing.T) {
+	source := &fakeSource{watchErrs: []error{ports.ErrHistoryLost}}
+	source.add("events", bson.M{"stream_id": "iphone-15", "version": int32(1), "type": "StockAdded"})
+	source.add("events", bson.M{"stream_id": "iphone-15", "version": int32(2), "type": "StockReserved"})
+	source.add("events", bson.M{"stream_id": "macbook-pro", "version": int32(1), "type": "ProductAdded"})
+
+	checkpoints := &fakeCheckpoints{}
+	stale, _ := bson.Marshal(bson.M{"i": int32(0)})
+	checkpoints.Save(context.Background(), "cp", stale, time.Time{})
+
+	// View มี iphone-15 ถึง v.1 แล้ว -> ต้องอ่านแค่ iphone-15 v.2 และ macbook-pro ทั้งหมด
+	p := &versionedProjection{
+		recordingProjection: recordingProjection{name: "products_view", checkpoint: "cp", sources: []string{"events"}},
+		positions:           map[string]int{"iphone-15": 1},
+	}
+	registry := NewRegistry(source, checkpoints, &fakeDeadLetters{})
+	registry.Register(p)
+	if err := registry.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.seen) != 2 || p.seen[0] != "StockReserved" || p.seen[1] != "ProductAdded" {
+		t.Fatalf("catch-up should read only unprojected versions, got %v", p.seen)
+	}
+	// Checkpoint ใหม่ = Head ตอนเริ่ม Recover (Stream เปิดใหม่จาก "ตอนนี้")
+	if got := checkpoints.tokens["cp"].Lookup("i").Int32(); got != 2 {
+		t.Fatalf("checkpoint = %d, want head (2)", got)
+	}
+}
+
+func TestRegistryWatchesSeveralSourcesAsEnvelopes(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"stream_id": "iphone-15", "order_id": "ORD-1", "type": "StockReserved", "version": int32(2)})
+	source.add("catalog_events", bson.M{"stream_id": "iphone-15", "type": "PriceChanged"})
+	source.add("payment_events", bson.M{"order_id": "ORD-1", "type": "PaymentProcessed", "amount": int32(10000)})
+
+	checkpoints := &fakeCheckpoints{}
+	orders := &recordingProjection{name: "orders_view", checkpoint: "orders", sources: []string{"events", "payment_events"}}
+	registry := NewRegistry(source, checkpoints, &fakeDeadLetters{})
+	registry.Register(orders)
+	if err := registry.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// ได้เฉพาะ Source ที่ฟัง ตามลำดับที่เขียน และรู้ว่าแต่ละตัวมาจากไหน
+	if len(orders.envelopes) != 2 {
+		t.Fatalf("want 2 events, got %v", orders.seen)
+	}
+	stock, payment := orders.envelopes[0], orders.envelopes[1]
+	if stock.Source != "events" || stock.StreamID != "iphone-15" || stock.OrderID != "ORD-1" || stock.Version != 2 {
+		t.Fatalf("unexpected stock envelope: %+v", stock)
+	}
+	// payment_events ไม่มี stream_id -> Stream ของมันคือ order_id
+	if payment.Source != "payment_events" || payment.Type != "PaymentProcessed" || payment.StreamID != "ORD-1" {
+		t.Fatalf("unexpected payment envelope: %+v", payment)
+	}
+	var body struct {
+		Amount int `bson:"amount"`
+	}
+	if err := payment.Decode(&body); err != nil || body.Amount != 10000 {
+		t.Fatalf("decode payment: %v %+v", err, body)
+	}
+	// Checkpoint เดียวครอบทุก Source
+	if got := checkpoints.tokens["orders"].Lookup("i").Int32(); got != 2 {
+		t.Fatalf("checkpoint = %d, want 2", got)
+	}
+}
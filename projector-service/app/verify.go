@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// StockVerifier ตรวจว่า products_view ตรงกับการ Replay events ไหม แล้วซ่อมถ้าสั่ง
+//
+// เทียบที่ last_version ของแต่ละเอกสาร (Replay เฉพาะ Version ที่ View ทำไปแล้ว)
+// -> View ที่แค่ตามหลัง (Projector ยังไม่ทัน) ไม่นับเป็น Drift รันระหว่างที่ Projector ทำงานได้
+// ซ่อม = เขียนทับเอกสารด้วยค่าที่ถูกต้อง ณ Version เดิม Projector จึงทำ Version ถัดไปต่อได้ตามปกติ
+type StockVerifier struct {
+	Source ports.EventSource
+	Views  ports.StockViewStore
+	Events string // Collection ของ Event สต็อก
+}
+
+func NewStockVerifier(source ports.EventSource, views ports.StockViewStore) *StockVerifier {
+	return &StockVerifier{Source: source, Views: views, Events: "events"}
+}
+
+// Verify คืนสินค้าที่ Drift (เรียงตาม product_id) และจำนวนสินค้าที่ตรวจ
+func (v *StockVerifier) Verify(ctx context.Context, repair bool) ([]core.StockDrift, int, error) {
+	docs, err := v.Views.List(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read view: %w", err)
+	}
+	views := make(map[string]core.StockState, len(docs))
+	for _, doc := range docs {
+		views[doc.ProductID] = doc
+	}
+
+	// History เรียงตาม stream_id + version -> Replay ทีละสินค้าจนจบก่อนขึ้นตัวถัดไป
+	var drifts []core.StockDrift
+	checked := 0
+	var streamID string
+	var atView, latest core.StockState
+	finish := func() {
+		if streamID == "" {
+			return
+		}
+		checked++
+		if drift := compare(views, streamID, atView, latest); drift != nil {
+			drifts = append(drifts, *drift)
+		}
+		delete(views, streamID)
+	}
+
+	err = v.Source.History(ctx, []string{v.Events}, nil, nil, func(change core.Change) error {
+		var event core.StockEvent
+		env, err := change.Envelope()
+		if err == nil {
+			err = env.Decode(&event)
+		}
+		if err != nil {
+			return fmt.Errorf("decode event: %w", err)
+		}
+		if event.StreamID != streamID {
+			finish()
+			streamID = event.StreamID
+			atView = core.StockState{ProductID: streamID, Totals: map[string]int{}}
+			latest = atView
+			latest.Totals = map[string]int{}
+		}
+		if view, ok := views[streamID]; ok && event.Version <= view.LastVersion {
+			atView.Apply(event)
+		}
+		latest.Apply(event)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("replay %s: %w", v.Events, err)
+	}
+	finish()
+
+	// เหลือใน View แต่ไม่มี Event เลย
+	for id, view := range views {
+		checked++
+		drifts = append(drifts, core.StockDrift{ProductID: id, Kind: core.DriftOrphan, Version: view.LastVersion})
+	}
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].ProductID < drifts[j].ProductID })
+
+	if repair {
+		for i := range drifts {
+			v.repair(ctx, &drifts[i])
+		}
+	}
+	return drifts, checked, nil
+}
+
+// compare คืน nil ถ้าเอกสารตรงกับ Replay ที่ Version เดียวกัน
+func compare(views map[string]core.StockState, id string, atView, latest core.StockState) *core.StockDrift {
+	view, ok := views[id]
+	switch {
+	case !ok:
+		return &core.StockDrift{ProductID: id, Kind: core.DriftMissing, Version: latest.LastVersion, Expected: &latest}
+	case view.LastVersion > latest.LastVersion:
+		return &core.StockDrift{ProductID: id, Kind: core.DriftAhead, Version: view.LastVersion, Expected: &latest}
+	}
+	if fields := core.CompareStock(view, atView); len(fields) > 0 {
+		return &core.StockDrift{ProductID: id, Kind: core.DriftMismatch, Version: view.LastVersion, Fields: fields, Expected: &atView}
+	}
+	return nil
+}
+
+// repair เขียนทับ (หรือลบ) เอกสารโดยมีเงื่อนไขว่า View ยังอยู่ที่ Version ที่ตรวจไว้
+func (v *StockVerifier) repair(ctx context.Context, drift *core.StockDrift) {
+	atVersion := drift.Version
+	if drift.Kind == core.DriftMissing {
+		atVersion = 0
+	}
+
+	var ok bool
+	var err error
+	if drift.Expected == nil {
+		ok, err = v.Views.Delete(ctx, drift.ProductID, atVersion)
+	} else {
+		ok, err = v.Views.Replace(ctx, *drift.Expected, atVersion)
+	}
+	switch {
+	case err != nil:
+		drift.Error = err.Error()
+	case !ok:
+		drift.Error = "view changed during verify, run again"
+	default:
+		drift.Repaired = true
+		log.Printf("🔧 [products_view] Repaired %s (%s at v.%d)", drift.ProductID, drift.Kind, atVersion)
+	}
+}
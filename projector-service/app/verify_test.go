@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"projector-service/core"
+)
+
+// fakeStockViews คือ products_view ใน RAM
+type fakeStockViews struct {
+	docs map[string]core.StockState
+}
+
+func (v *fakeStockViews) List(ctx context.Context) ([]core.StockState, error) {
+	out := []core.StockState{}
+	for _, doc := range v.docs {
+		out = append(out, doc)
+	}
+	return out, nil
+}
+
+func (v *fakeStockViews) Replace(ctx context.Context, state core.StockState, atVersion int) (bool, error) {
+	doc, ok := v.docs[state.ProductID]
+	if (atVersion == 0 && ok) || (atVersion != 0 && (!ok || doc.LastVersion != atVersion)) {
+		return false, nil
+	}
+	v.docs[state.ProductID] = state
+	return true, nil
+}
+
+func (v *fakeStockViews) Delete(ctx context.Context, productID string, atVersion int) (bool, error) {
+	if doc, ok := v.docs[productID]; !ok || doc.LastVersion != atVersion {
+		return false, nil
+	}
+	delete(v.docs, productID)
+	return true, nil
+}
+
+func TestStockVerifierReportsAndRepairsDrift(t *testing.T) {
+	source := &fakeSource{}
+	stock := func(id, eventType string, qty, version int) {
+		source.add("events", bson.M{"stream_id": id, "type": eventType, "qty": int32(qty), "version": int32(version)})
+	}
+	stock("iphone-15", "StockAdded", 100, 1)
+	stock("iphone-15", "StockReserved", 2, 2)
+	stock("iphone-15", "StockCommitted", 2, 3)
+	stock("ipad-air", "StockAdded", 10, 1)
+	stock("ipad-air", "StockReserved", 1, 2) // View ยังไม่ทำ v.2 = ตามหลัง ไม่ใช่ Drift
+	stock("macbook-pro", "StockAdded", 50, 1)
+	stock("airpods", "StockAdded", 5, 1)
+
+	views := &fakeStockViews{docs: map[string]core.StockState{
+		// ยอดถูกนับซ้ำ (เช่น Seed ค่าไว้ล่วงหน้าแล้ว Projector Replay ทับอีกรอบ)
+		"iphone-15": {ProductID: "iphone-15", Available: 198, OnHand: 198, Committed: 2,
+			Totals: map[string]int{"added": 100, "reserved": 2, "committed": 2}, LastVersion: 3},
+		"ipad-air":    {ProductID: "ipad-air", Available: 10, OnHand: 10, Totals: map[string]int{"added": 10}, LastVersion: 1},
+		"airpods":     {ProductID: "airpods", Available: 5, OnHand: 5, Totals: map[string]int{"added": 5}, LastVersion: 4},
+		"apple-watch": {ProductID: "apple-watch", Available: 7, LastVersion: 1},
+		// macbook-pro ไม่มีเอกสาร
+	}}
+	verifier := NewStockVerifier(source, views)
+
+	drifts, checked, err := verifier.Verify(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checked != 5 {
+		t.Fatalf("checked = %d, want 5", checked)
+	}
+	got := map[string]core.StockDrift{}
+	for _, d := range drifts {
+		got[d.ProductID] = d
+	}
+	want := map[string]string{"airpods": core.DriftAhead, "apple-watch": core.DriftOrphan, "iphone-15": core.DriftMismatch, "macbook-pro": core.DriftMissing}
+	if len(got) != len(want) {
+		t.Fatalf("drifts = %+v", drifts)
+	}
+	for id, kind := range want {
+		if got[id].Kind != kind {
+			t.Fatalf("%s: kind = %q, want %q", id, got[id].Kind, kind)
+		}
+	}
+	if fields := fmt.Sprint(got["iphone-15"].Fields); fields != "[available_stock=198 (want 98) on_hand=198 (want 98)]" {
+		t.Fatalf("iphone-15 fields = %s", fields)
+	}
+
+	// Repair: เขียนทับ ณ Version เดิมของ View แล้วตรวจซ้ำต้องสะอาด
+	if _, _, err := verifier.Verify(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	if doc := views.docs["iphone-15"]; doc.Available != 98 || doc.OnHand != 98 || doc.LastVersion != 3 {
+		t.Fatalf("iphone-15 not repaired: %+v", doc)
+	}
+	if doc := views.docs["ipad-air"]; doc.LastVersion != 1 {
+		t.Fatalf("lagging view must be left alone: %+v", doc)
+	}
+	if _, ok := views.docs["apple-watch"]; ok {
+		t.Fatal("orphan not deleted")
+	}
+	drifts, _, err = verifier.Verify(context.Background(), false)
+	if err != nil || len(drifts) != 0 {
+		t.Fatalf("drift after repair: %+v %v", drifts, err)
+	}
+}
+
+func TestStockVerifierDoesNotOverwriteNewerView(t *testing.T) {
+	source := &fakeSource{}
+	source.add("events", bson.M{"stream_id": "iphone-15", "type": "StockAdded", "qty": int32(10), "version": int32(1)})
+	views := &fakeStockViews{docs: map[string]core.StockState{
+		"iphone-15": {ProductID: "iphone-15", Available: 99, LastVersion: 1},
+	}}
+	verifier := NewStockVerifier(source, views)
+
+	// Projector เขียน v.2 ระหว่างตรวจ -> ซ่อมไม่ได้ ต้องแจ้งให้รันใหม่
+	drift := core.StockDrift{ProductID: "iphone-15", Kind: core.DriftMismatch, Version: 1, Expected: &core.StockState{ProductID: "iphone-15", Available: 10, LastVersion: 1}}
+	views.docs["iphone-15"] = core.StockState{ProductID: "iphone-15", Available: 98, LastVersion: 2}
+	verifier.repair(context.Background(), &drift)
+	if drift.Repaired || drift.Error == "" || views.docs["iphone-15"].Available != 98 {
+		t.Fatalf("repair overwrote newer view: %+v %+v", drift, views.docs["iphone-15"])
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"projector-service/app"
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+const usage = `usage:
+  projector-service rebuild <projection>        rebuild a read model (blue/green swap)
+  projector-service verify <view> [--repair]    compare products_view with a replay of events; --repair fixes drift
+  projector-service dlq list [projection]       list open dead letters
+  projector-service dlq replay <id>             apply the event to its projection again
+  projector-service dlq replay-all <projection> replay every open dead letter of a projection
+  projector-service dlq discard <id>            close without replaying`
+
+// commands คือคำสั่งของ Operator ที่ทำครั้งเดียวแล้วจบ (ใช้ Wiring เดียวกับตอนรันปกติ)
+type commands struct {
+	registry    *app.Registry
+	rebuilder   *app.Rebuilder
+	deadLetters *app.DeadLetterService
+	verifier    *app.StockVerifier
+}
+
+func (c commands) run(ctx context.Context, args []string) {
+	switch {
+	case args[0] == "rebuild" && len(args) == 2:
+		c.rebuild(ctx, args[1])
+	case args[0] == "verify" && len(args) >= 2 && len(args) <= 3:
+		c.verify(ctx, args[1], args[2:])
+	case args[0] == "dlq" && len(args) >= 2:
+		c.dlq(ctx, args[1], args[2:])
+	default:
+		fatalUsage()
+	}
+}
+
+func (c commands) rebuild(ctx context.Context, name string) {
+	p, ok := c.registry.Find(name).(ports.Rebuildable)
+	if !ok {
+		log.Fatalf("❌ Projection %q not found or cannot be rebuilt", name)
+	}
+	if err := c.rebuilder.Rebuild(ctx, p); err != nil {
+		log.Fatalf("❌ Rebuild %s failed: %v", name, err)
+	}
+}
+
+func (c commands) verify(ctx context.Context, name string, flags []string) {
+	repair := len(flags) == 1 && flags[0] == "--repair"
+	if len(flags) == 1 && !repair {
+		fatalUsage()
+	}
+	if name != "products_view" {
+		log.Fatalf("❌ Projection %q cannot be verified (only products_view)", name)
+	}
+
+	drifts, checked, err := c.verifier.Verify(ctx, repair)
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+	if len(drifts) == 0 {
+		fmt.Printf("✅ %d product(s) checked, no drift.\n", checked)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PRODUCT\tDRIFT\tVERSION\tDETAILS\tREPAIR")
+	unrepaired := 0
+	for _, d := range drifts {
+		status := "-"
+		switch {
+		case d.Repaired:
+			status = "repaired"
+		case d.Error != "":
+			status = "failed: " + d.Error
+		}
+		if !d.Repaired {
+			unrepaired++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", d.ProductID, d.Kind, d.Version, driftDetails(d), status)
+	}
+	w.Flush()
+	fmt.Printf("⚠️ %d product(s) checked, %d drifted, %d not repaired.\n", checked, len(drifts), unrepaired)
+	if unrepaired > 0 {
+		os.Exit(1) // ใช้เป็น Job ได้: Exit Code บอกว่ายังมี Drift
+	}
+}
+
+func driftDetails(d core.StockDrift) string {
+	switch d.Kind {
+	case core.DriftMismatch:
+		details := make([]string, 0, len(d.Fields))
+		for _, f := range d.Fields {
+			details = append(details, f.String())
+		}
+		return strings.Join(details, ", ")
+	case core.DriftMissing:
+		return fmt.Sprintf("no document, events at v.%d", d.Expected.LastVersion)
+	case core.DriftAhead:
+		return fmt.Sprintf("events only reach v.%d", d.Expected.LastVersion)
+	case core.DriftOrphan:
+		return "no events for this product"
+	}
+	return ""
+}
+
+func (c commands) dlq(ctx context.Context, sub string, args []string) {
+	switch {
+	case sub == "list" && len(args) <= 1:
+		projection := ""
+		if len(args) == 1 {
+			projection = args[0]
+		}
+		letters, err := c.deadLetters.List(ctx, projection)
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		if len(letters) == 0 {
+			fmt.Println("✅ No open dead letters.")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tPROJECTION\tATTEMPTS\tCREATED\tERROR")
+		for _, l := range letters {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", l.ID, l.Projection, l.Attempts, l.CreatedAt.Format("2006-01-02 15:04:05"), oneLine(l.Error))
+		}
+		w.Flush()
+
+	case sub == "replay" && len(args) == 1:
+		if err := c.deadLetters.Replay(ctx, args[0]); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		fmt.Printf("✅ Dead letter %s replayed.\n", args[0])
+
+	case sub == "replay-all" && len(args) == 1:
+		replayed, failed, err := c.deadLetters.ReplayAll(ctx, args[0])
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		fmt.Printf("✅ Replayed %d, still failing %d.\n", replayed, failed)
+		if failed > 0 {
+			os.Exit(1)
+		}
+
+	case sub == "discard" && len(args) == 1:
+		if err := c.deadLetters.Discard(ctx, args[0]); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		fmt.Printf("🗑️ Dead letter %s discarded.\n", args[0])
+
+	default:
+		fatalUsage()
+	}
+}
+
+func oneLine(s string) string {
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func fatalUsage() {
+	fmt.Fprintln(os.Stderr, usage)
+	os.Exit(2)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"projector-service/adapters/notify"
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// loadReorderPoints อ่านจุดสั่งซื้อจากไฟล์ STOCK_ALERT_FILE (path ว่าง = 0 ทุกสินค้า: แจ้งเฉพาะตอนของหมด)
+//
+//	{"default_reorder_point": 5, "products": {"iphone-15": 10}}
+func loadReorderPoints(path string) (core.ReorderPoints, error) {
+	var points core.ReorderPoints
+	if path == "" {
+		return points, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return points, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields() // พิมพ์ชื่อ Field ผิด = Error ไม่ใช่เงียบแล้วไม่มี Alert
+	if err := dec.Decode(&points); err != nil {
+		return points, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := points.Validate(); err != nil {
+		return points, fmt.Errorf("%s: %w", path, err)
+	}
+	return points, nil
+}
+
+// alertSinks สร้าง Sink ตามรายชื่อคั่นด้วยจุลภาค (log, webhook, email)
+func alertSinks(names, webhookURL, emailTo string) ([]ports.Notifier, error) {
+	var sinks []ports.Notifier
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "log":
+			sinks = append(sinks, notify.NewLogNotifier())
+		case "webhook":
+			if webhookURL == "" {
+				return nil, fmt.Errorf("webhook sink needs STOCK_ALERT_WEBHOOK_URL")
+			}
+			sinks = append(sinks, notify.NewWebhookNotifier(webhookURL))
+		case "email":
+			if emailTo == "" {
+				return nil, fmt.Errorf("email sink needs STOCK_ALERT_EMAIL_TO")
+			}
+			sinks = append(sinks, notify.NewEmailNotifier(strings.Split(emailTo, ",")))
+		default:
+			return nil, fmt.Errorf("unknown sink %q (want log, webhook or email)", name)
+		}
+	}
+	return sinks, nil
+}
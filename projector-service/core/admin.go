@@ -0,0 +1,48 @@
+package core
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Checkpoint คือจุดที่ Projection (หรือ Partition หนึ่งของมัน) อ่านถึงแล้ว
+type Checkpoint struct {
+	ID        string    `bson:"_id"`                  // CheckpointID ของ Projection (+ #i/n ถ้าแบ่ง Partition)
+	Token     bson.Raw  `bson:"resume_token"`         // Token ของ MongoDB Change Stream
+	EventAt   time.Time `bson:"event_at,omitempty"`   // เวลาของ Event สุดท้ายที่อ่านถึง (ว่าง = ไม่รู้ เช่นหลัง Rebuild)
+	UpdatedAt time.Time `bson:"updated_at,omitempty"` // เวลาที่บันทึก
+}
+
+// Control คือคำสั่งของ Operator ต่อ Projection หนึ่ง ใช้ร่วมกันทุก Instance (ไม่มีเอกสาร = รันปกติ)
+type Control struct {
+	Projection string    `bson:"_id"`
+	Paused     bool      `bson:"paused"`
+	UpdatedAt  time.Time `bson:"updated_at"`
+}
+
+// สถานะของ Projection ใน Admin API
+const (
+	ProjectionRunning = "RUNNING"
+	ProjectionPaused  = "PAUSED"
+)
+
+// ProjectionStatus คือภาพรวมของ Projection 1 ตัวจากทุก Instance (อ่านจาก checkpoints / leases / dead letters)
+type ProjectionStatus struct {
+	Name        string            `json:"name"`
+	Sources     []string          `json:"sources"`
+	State       string            `json:"state"`
+	LagSeconds  *float64          `json:"lag_seconds"`   // Event ใหม่สุดใน Source - Event ที่ Partition ช้าสุดอ่านถึง (null = ไม่รู้)
+	LastEventAt *time.Time        `json:"last_event_at"` // Event ใหม่สุดที่อ่านถึงแล้ว
+	Errors      int               `json:"errors"`        // Dead Letter ที่ยังเปิดอยู่
+	Partitions  []PartitionStatus `json:"partitions"`
+}
+
+// PartitionStatus คือ Checkpoint + เจ้าของ Lease ของ Partition หนึ่ง
+type PartitionStatus struct {
+	Checkpoint string     `json:"checkpoint"`
+	Owner      string     `json:"owner,omitempty"` // Instance ที่ถือ Lease อยู่ (ว่าง = ไม่มีใครรัน)
+	LastToken  string     `json:"last_token,omitempty"`
+	EventAt    *time.Time `json:"event_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
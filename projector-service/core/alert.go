@@ -0,0 +1,118 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// ระดับสต็อกของสินค้า เทียบ available_stock กับจุดสั่งซื้อ (Reorder Point)
+const (
+	StockOK       = "OK"
+	StockLow      = "LOW"      // ต่ำกว่าจุดสั่งซื้อ
+	StockDepleted = "DEPLETED" // ขายไม่ได้แล้ว (available_stock <= 0)
+)
+
+// ชนิดของการแจ้งเตือน
+const (
+	AlertLowStock      = "LowStockDetected"
+	AlertStockDepleted = "StockDepleted"
+)
+
+// สถานะการส่งของ Alert
+const (
+	AlertPending = "PENDING" // ยังส่งไม่ครบทุก Sink
+	AlertSent    = "SENT"
+	AlertSkipped = "SKIPPED" // เก่าเกินไป (เจอตอนไล่ History) บันทึกไว้แต่ไม่ส่ง
+)
+
+// ReorderPoints คือจุดสั่งซื้อของแต่ละสินค้า (ไม่มีใน Products = ใช้ Default)
+type ReorderPoints struct {
+	Default  int            `json:"default_reorder_point"`
+	Products map[string]int `json:"products"`
+}
+
+func (r ReorderPoints) For(productID string) int {
+	if point, ok := r.Products[productID]; ok {
+		return point
+	}
+	return r.Default
+}
+
+func (r ReorderPoints) Validate() error {
+	if r.Default < 0 {
+		return fmt.Errorf("default_reorder_point must not be negative")
+	}
+	for id, point := range r.Products {
+		if point < 0 {
+			return fmt.Errorf("products.%s: reorder point must not be negative", id)
+		}
+	}
+	return nil
+}
+
+// StockLevelOf จัดระดับยอดที่ขายได้ (จุดสั่งซื้อ 0 = แจ้งเฉพาะตอนของหมด)
+func StockLevelOf(available, reorderPoint int) string {
+	switch {
+	case available <= 0:
+		return StockDepleted
+	case available < reorderPoint:
+		return StockLow
+	}
+	return StockOK
+}
+
+// Crossing คืนชนิด Alert เมื่อระดับแย่ลงข้ามเส้น ("" = ไม่ต้องแจ้ง)
+// ระดับที่ดีขึ้นไม่แจ้ง แต่ทำให้ข้ามเส้นเดิมแจ้งได้อีกครั้ง (เช่น เติมของจนพ้นจุดสั่งซื้อแล้วขายจนต่ำกว่าอีก)
+func Crossing(from, to string) string {
+	if from == "" {
+		from = StockOK // สินค้าใหม่
+	}
+	switch {
+	case from == to:
+		return ""
+	case to == StockDepleted:
+		return AlertStockDepleted
+	case to == StockLow && from == StockOK:
+		return AlertLowStock
+	}
+	return ""
+}
+
+// StockAlert คือการข้ามเส้น 1 ครั้ง (Event ที่ทำให้ข้ามเส้นระบุด้วย Version -> Replay ได้ _id เดิมเสมอ)
+type StockAlert struct {
+	ID           string     `bson:"_id" json:"id"`
+	Type         string     `bson:"type" json:"type"`
+	ProductID    string     `bson:"product_id" json:"product_id"`
+	Available    int        `bson:"available_stock" json:"available_stock"`
+	ReorderPoint int        `bson:"reorder_point" json:"reorder_point"`
+	Version      int        `bson:"version" json:"version"`
+	EventAt      time.Time  `bson:"event_at" json:"event_at"`
+	Status       string     `bson:"status" json:"-"`
+	SentTo       []string   `bson:"sent_to,omitempty" json:"-"` // Sink ที่ส่งสำเร็จแล้ว (Retry ส่งเฉพาะที่ยังไม่ได้)
+	LastError    string     `bson:"last_error,omitempty" json:"-"`
+	CreatedAt    time.Time  `bson:"created_at" json:"-"`
+	SentAt       *time.Time `bson:"sent_at,omitempty" json:"-"`
+}
+
+func NewStockAlert(alertType, productID string, available, reorderPoint int, event StockEvent) StockAlert {
+	return StockAlert{
+		ID:           fmt.Sprintf("%s|%s|v.%d", productID, alertType, event.Version),
+		Type:         alertType,
+		ProductID:    productID,
+		Available:    available,
+		ReorderPoint: reorderPoint,
+		Version:      event.Version,
+		EventAt:      event.Timestamp,
+		Status:       AlertPending,
+	}
+}
+
+// Delivered บอกว่า Sink นี้ส่งไปแล้วหรือยัง
+func (a StockAlert) Delivered(sink string) bool {
+	for _, s := range a.SentTo {
+		if s == sink {
+			return true
+		}
+	}
+	return false
+}
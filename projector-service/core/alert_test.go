@@ -0,0 +1,37 @@
+package core
+
+import "testing"
+
+func TestCrossingAlertsOnlyWhenLevelGetsWorse(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"", StockOK, ""},
+		{"", StockLow, AlertLowStock}, // สินค้าใหม่เติมของมาน้อยกว่าจุดสั่งซื้อ
+		{StockOK, StockLow, AlertLowStock},
+		{StockOK, StockDepleted, AlertStockDepleted},
+		{StockLow, StockDepleted, AlertStockDepleted},
+		{StockLow, StockLow, ""},
+		{StockDepleted, StockLow, ""}, // เติมของแต่ยังไม่พ้นจุดสั่งซื้อ
+		{StockLow, StockOK, ""},
+	}
+	for _, tt := range tests {
+		if got := Crossing(tt.from, tt.to); got != tt.want {
+			t.Fatalf("Crossing(%q, %q) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestReorderPointsPerProduct(t *testing.T) {
+	points := ReorderPoints{Default: 5, Products: map[string]int{"macbook-pro": 0}}
+	if points.For("iphone-15") != 5 || points.For("macbook-pro") != 0 {
+		t.Fatalf("For = %d / %d, want 5 / 0", points.For("iphone-15"), points.For("macbook-pro"))
+	}
+	if StockLevelOf(4, 5) != StockLow || StockLevelOf(5, 5) != StockOK || StockLevelOf(0, 0) != StockDepleted {
+		t.Fatal("unexpected stock level")
+	}
+	if (ReorderPoints{Products: map[string]int{"x": -1}}).Validate() == nil {
+		t.Fatal("expected error for negative reorder point")
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// สถานะของ Dead Letter
+const (
+	DeadLetterOpen      = "OPEN"      // รอคนมาดู
+	DeadLetterReplayed  = "REPLAYED"  // Replay ผ่านแล้ว
+	DeadLetterDiscarded = "DISCARDED" // Operator สั่งทิ้ง
+)
+
+// DeadLetter คือ Event ที่ Projection Handle ไม่ผ่านจนหมดจำนวนครั้งที่ Retry
+// Checkpoint เลื่อนผ่าน Event นี้ไปแล้ว -> ต้อง Replay จากที่นี่เท่านั้น
+type DeadLetter struct {
+	ID         string     `bson:"_id"`
+	Projection string     `bson:"projection"`
+	Source     string     `bson:"source"`
+	Token      bson.Raw   `bson:"token"`
+	Change     bson.Raw   `bson:"change"` // Change Event ทั้งก้อนจาก Change Stream
+	Error      string     `bson:"error"`
+	Attempts   int        `bson:"attempts"` // รวมรอบที่ Replay ไม่ผ่านด้วย
+	Status     string     `bson:"status"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at"`
+	ClosedAt   *time.Time `bson:"closed_at,omitempty"`
+}
+
+// DeadLetterID ได้ค่าเดิมเสมอสำหรับ Event เดิมของ Projection เดิม (key = Change.Key())
+// (Event ถูกส่งซ้ำหลัง Restart -> อัปเดตเคสเดิม ไม่เกิดเคสซ้อน)
+func DeadLetterID(projection string, key bson.Raw) string {
+	sum := sha256.Sum256(append([]byte(projection+"/"), key...))
+	return hex.EncodeToString(sum[:12])
+}
+
+// Event คือ fullDocument ใน Change Event (ok = false ถ้าไม่มี)
+func (d DeadLetter) Event() (bson.Raw, bool) {
+	return d.Change.Lookup("fullDocument").DocumentOK()
+}
+
+// Envelope คือ Event ในรูปที่ส่งให้ Projection ได้ทันที (ใช้ตอน Replay)
+func (d DeadLetter) Envelope() (Envelope, error) {
+	event, ok := d.Event()
+	if !ok {
+		return Envelope{}, errors.New("change event has no fullDocument")
+	}
+	return NewEnvelope(d.Source, event)
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Change คือ Event 1 ตัวที่อ่านได้จาก Change Stream
+type Change struct {
+	Source   string   // Collection ที่ Event นี้มาจาก (ns.coll)
+	Token    bson.Raw // Resume Token ของ Event นี้ (บันทึกเป็น Checkpoint หลัง Handle)
+	Document bson.Raw // fullDocument = Event จริงๆ
+	Raw      bson.Raw // Change Event ทั้งก้อน (เก็บลง Dead Letter)
+	Err      error    // Decode Change Event ไม่ได้ (Registry จะเก็บลง Dead Letter โดยไม่ส่งให้ Projection)
+}
+
+// HistoryChange ห่อ Event ที่อ่านจาก Event Store ตรงๆ (ไม่ได้มาจาก Change Stream จึงไม่มี Token)
+// ให้หน้าตาเหมือน Change Event แบบ Insert
+func HistoryChange(source string, event bson.Raw) Change {
+	raw, _ := bson.Marshal(bson.D{
+		{Key: "operationType", Value: "insert"},
+		{Key: "ns", Value: bson.D{{Key: "coll", Value: source}}},
+		{Key: "fullDocument", Value: event},
+	})
+	return Change{Source: source, Document: event, Raw: raw}
+}
+
+// Key ระบุ Event นี้แบบไม่ซ้ำ: Resume Token ถ้ามี ไม่งั้นใช้ตัว Event เอง
+func (c Change) Key() bson.Raw {
+	if c.Token != nil {
+		return c.Token
+	}
+	return c.Document
+}
+
+// Envelope คือหน้าตากลางของ Event ทุก Source ที่ Registry ส่งให้ Projection
+// Field ที่ทุก Event Store มีเหมือนกันถูกอ่านไว้ให้แล้ว ส่วนที่เหลือ Decode จาก Document ตาม Source
+type Envelope struct {
+	Source    string // Collection ที่ Event นี้มาจาก (events / payment_events / catalog_events)
+	Type      string
+	StreamID  string // stream_id (payment_events ไม่มี -> ใช้ order_id ซึ่งเป็น Stream ของมัน)
+	OrderID   string // Correlation: Order ที่ทำให้เกิด Event (ว่าง = ไม่ผูกกับ Order)
+	Version   int    // 0 = Source นี้ไม่มี Version
+	Timestamp time.Time
+	Document  bson.Raw // Event ทั้งก้อน
+}
+
+// NewEnvelope อ่าน Field กลางจาก Event (Error = Event ไม่ใช่ BSON Document ที่ถูกต้อง)
+func NewEnvelope(source string, event bson.Raw) (Envelope, error) {
+	var header struct {
+		Type      string    `bson:"type"`
+		StreamID  string    `bson:"stream_id"`
+		OrderID   string    `bson:"order_id"`
+		Version   int       `bson:"version"`
+		Timestamp time.Time `bson:"timestamp"`
+	}
+	if err := bson.Unmarshal(event, &header); err != nil {
+		return Envelope{}, fmt.Errorf("decode %s event: %w", source, err)
+	}
+	streamID := header.StreamID
+	if streamID == "" {
+		streamID = header.OrderID
+	}
+	return Envelope{
+		Source:    source,
+		Type:      header.Type,
+		StreamID:  streamID,
+		OrderID:   header.OrderID,
+		Version:   header.Version,
+		Timestamp: header.Timestamp,
+		Document:  event,
+	}, nil
+}
+
+// Envelope ของ Change นี้
+func (c Change) Envelope() (Envelope, error) {
+	if c.Err != nil {
+		return Envelope{}, c.Err
+	}
+	return NewEnvelope(c.Source, c.Document)
+}
+
+// Decode แปลง Event เป็น Struct ของ Source นั้น (เช่น StockEvent / PaymentEvent)
+func (e Envelope) Decode(v any) error {
+	return bson.Unmarshal(e.Document, v)
+}
+
+// StockEvent หน้าตาของ Event ที่เราจะอ่านจาก Stream (collection: events)
+type StockEvent struct {
+	StreamID  string    `bson:"stream_id"` // Product ID
+	OrderID   string    `bson:"order_id"`  // Order ที่ทำให้เกิด Event (ว่าง = เติมของ / Event รุ่นเก่า)
+	Type      string    `bson:"type"`
+	Qty       int       `bson:"qty"`
+	Version   int       `bson:"version"` // ใช้กัน Process ซ้ำ (Idempotent)
+	Timestamp time.Time `bson:"timestamp"`
+}
+
+// CatalogEvent หน้าตาของ Event ราคาสินค้า (collection: catalog_events)
+type CatalogEvent struct {
+	StreamID  string    `bson:"stream_id"` // Product ID
+	Type      string    `bson:"type"`      // ProductCreated / PriceChanged
+	Name      string    `bson:"name"`
+	Price     int       `bson:"price"`
+	Version   int       `bson:"version"`
+	Timestamp time.Time `bson:"timestamp"`
+}
+
+// PaymentEvent หน้าตาของ Event การจ่ายเงิน (collection: payment_events)
+type PaymentEvent struct {
+	OrderID   string    `bson:"order_id"`
+	Type      string    `bson:"type"` // PaymentProcessed / PaymentFailed
+	Amount    int       `bson:"amount"`
+	Status    string    `bson:"status"` // SUCCESS / FAILED
+	Reason    string    `bson:"reason"`
+	Timestamp time.Time `bson:"timestamp"`
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// Lease คือสิทธิ์ในการรันงาน 1 ชิ้น (Partition ของ Projection) ของ Projector ตัวเดียวในช่วงเวลาหนึ่ง
+// เจ้าของต้องต่ออายุก่อน ExpiresAt ไม่งั้นตัวอื่นเอาไปได้ (เช่นตอนเจ้าของเดิมดับ)
+type Lease struct {
+	Name      string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expires_at"` // เวลาของ MongoDB ไม่ใช่ของเครื่อง Projector
+}
+
+// Partition แบ่ง Event ของ Projection ตาม Hash ของ Key (ปกติคือ stream_id)
+// Stream เดียวกันอยู่ Partition เดียวกันเสมอ -> ลำดับ Version ใน Stream ไม่เพี้ยนแม้รันหลาย Instance
+type Partition struct {
+	Index int
+	Count int
+}
+
+// Whole คือไม่แบ่ง (Partition เดียวได้ทุก Event)
+var Whole = Partition{Index: 0, Count: 1}
+
+// Owns บอกว่า Event ที่มี key นี้เป็นของ Partition นี้ไหม
+func (p Partition) Owns(key string) bool {
+	if p.Count <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32()%uint32(p.Count)) == p.Index
+}
+
+// CheckpointID ของ Partition: ไม่แบ่ง = ใช้ ID เดิม (Checkpoint ที่มีอยู่แล้วใช้ต่อได้)
+func (p Partition) CheckpointID(base string) string {
+	if p.Count <= 1 {
+		return base
+	}
+	return fmt.Sprintf("%s#%d/%d", base, p.Index, p.Count)
+}
+
+func (p Partition) String() string {
+	return fmt.Sprintf("%d/%d", p.Index, p.Count)
+}
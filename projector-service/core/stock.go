@@ -0,0 +1,106 @@
+package core
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// StockState คือยอดของสินค้า 1 ตัว ณ Version หนึ่ง (หน้าตาเดียวกับเอกสารใน products_view)
+type StockState struct {
+	ProductID   string         `bson:"product_id"`
+	Available   int            `bson:"available_stock"`
+	OnHand      int            `bson:"on_hand"`
+	Reserved    int            `bson:"reserved"`
+	Committed   int            `bson:"committed"`
+	Totals      map[string]int `bson:"totals"`
+	LastVersion int            `bson:"last_version"`
+	UpdatedAt   time.Time      `bson:"updated_at"`
+}
+
+// Apply ใส่ Event 1 ตัวตามกติกาของ InventoryAggregate (inventory-service): ยอดที่ขายได้
+// = เติม - จอง + คืน (ขายจริงไม่เปลี่ยนยอดที่ขายได้ เพราะหักไปแล้วตอนจอง) แล้วแตกเป็นยอดในคลัง / ที่จอง / ที่ขาย
+//
+// เขียนแยกจาก Projection โดยตั้งใจ: ใช้ตรวจ products_view ถ้าใช้โค้ดชุดเดียวกัน Bug ใน Projection จะไม่มีวันเห็นเป็น Drift
+func (s *StockState) Apply(event StockEvent) {
+	if s.Totals == nil {
+		s.Totals = map[string]int{}
+	}
+	switch event.Type {
+	case "StockAdded":
+		s.Available += event.Qty
+		s.OnHand += event.Qty
+		s.Totals["added"] += event.Qty
+	case "StockReserved":
+		s.Available -= event.Qty
+		s.Reserved += event.Qty
+		s.Totals["reserved"] += event.Qty
+	case "StockReleased":
+		s.Available += event.Qty
+		s.Reserved -= event.Qty
+		s.Totals["released"] += event.Qty
+	case "StockCommitted":
+		s.OnHand -= event.Qty
+		s.Reserved -= event.Qty
+		s.Committed += event.Qty
+		s.Totals["committed"] += event.Qty
+	case "StockReservationRejected":
+		s.Totals["rejected"] += event.Qty
+	}
+	s.LastVersion = event.Version
+	s.UpdatedAt = event.Timestamp
+}
+
+// ชนิดของ Drift ระหว่าง products_view กับ events
+const (
+	DriftMismatch = "MISMATCH" // ยอดใน View ไม่ตรงกับ Replay ที่ Version เดียวกัน
+	DriftMissing  = "MISSING"  // มี Event แต่ไม่มีเอกสารใน View
+	DriftOrphan   = "ORPHAN"   // มีเอกสารใน View แต่ไม่มี Event เลย
+	DriftAhead    = "AHEAD"    // View อยู่ Version ที่ Event Store ยังไม่มี
+)
+
+// StockDrift คือสินค้า 1 ตัวที่ View ไม่ตรงกับ Event Store
+type StockDrift struct {
+	ProductID string
+	Kind      string
+	Version   int          // Version ที่ใช้เทียบ (= last_version ของ View, MISSING = Version ล่าสุดใน Event Store)
+	Fields    []FieldDrift // เฉพาะ MISMATCH
+	Expected  *StockState  // ค่าที่ถูกต้อง (nil = ต้องลบทิ้ง)
+	Repaired  bool
+	Error     string // Repair ไม่สำเร็จเพราะอะไร
+}
+
+// FieldDrift คือ Field ที่ค่าใน View ต่างจากที่ควรเป็น
+type FieldDrift struct {
+	Field    string
+	View     int
+	Expected int
+}
+
+func (f FieldDrift) String() string {
+	return fmt.Sprintf("%s=%d (want %d)", f.Field, f.View, f.Expected)
+}
+
+// CompareStock เทียบเอกสารใน View กับผล Replay ที่ Version เดียวกัน (ไม่เทียบ updated_at)
+func CompareStock(view, expected StockState) []FieldDrift {
+	var drift []FieldDrift
+	check := func(field string, got, want int) {
+		if got != want {
+			drift = append(drift, FieldDrift{Field: field, View: got, Expected: want})
+		}
+	}
+	check("available_stock", view.Available, expected.Available)
+	check("on_hand", view.OnHand, expected.OnHand)
+	check("reserved", view.Reserved, expected.Reserved)
+	check("committed", view.Committed, expected.Committed)
+	reasons := maps.Clone(expected.Totals)
+	if reasons == nil {
+		reasons = map[string]int{}
+	}
+	maps.Copy(reasons, view.Totals)
+	for _, reason := range slices.Sorted(maps.Keys(reasons)) {
+		check("totals."+reason, view.Totals[reason], expected.Totals[reason])
+	}
+	return drift
+}
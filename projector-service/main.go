@@ -1,3 +1,7 @@
+// projector-service อ่าน Event จาก Change Stream แล้วอัปเดต Read Model
+//
+//	projector-service              รัน Projection ทั้งหมด
+//	projector-service <command>    คำสั่งของ Operator (ดู commands.go) แล้วจบ
 package main
 
 import (
@@ -5,230 +9,148 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-)
-
-// ---------------------------------------------------------
-// 1. Data Structures
-// ---------------------------------------------------------
-
-// Checkpoint เอาไว้เก็บว่าอ่านถึงไหนแล้ว (Resume Token)
-type Checkpoint struct {
-	ID          string      `bson:"_id"`          // ID ของ Projector (เผื่อมีหลายตัว)
-	ResumeToken interface{} `bson:"resume_token"` // Token ของ MongoDB Change Stream
-}
 
-// StockEvent หน้าตาของ Event ที่เราจะอ่านจาก Stream
-type StockEvent struct {
-	StreamID  string    `bson:"stream_id"` // Product ID
-	Type      string    `bson:"type"`
-	Qty       int       `bson:"qty"`
-	Version   int       `bson:"version"` // เก็บไว้ดูเล่น (ไม่ได้ใช้คำนวณใน view)
-	Timestamp time.Time `bson:"timestamp"`
-}
-
-// ---------------------------------------------------------
-// 2. Main Logic
-// ---------------------------------------------------------
+	httpAdapter "projector-service/adapters/http"
+	mongoAdapter "projector-service/adapters/mongo"
+	"projector-service/app"
+	"projector-service/ports"
+	"projector-service/projections"
+)
 
 func main() {
 	// A. Connect MongoDB
 	// หมายเหตุ: ต้องใช้ directConnection=true เสมอ เมื่อต่อ Replica Set จากเครื่อง Local
 
 	mongoURI := getEnv("MONGO_URI", "mongodb://localhost:27017/?directConnection=true")
-
-	fmt.Printf("🔧 Config: Mongo=%s \n", mongoURI)
-	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mongoURI))
-	if err != nil {
-		log.Fatal("❌ Connection Failed:", err)
+	maxAttempts, err := strconv.Atoi(getEnv("PROJECTION_MAX_ATTEMPTS", "5")) // ลองกี่ครั้งก่อนส่งเข้า Dead Letter
+	if err != nil || maxAttempts < 1 {
+		log.Fatal("Invalid PROJECTION_MAX_ATTEMPTS: ", getEnv("PROJECTION_MAX_ATTEMPTS", ""))
 	}
-	defer client.Disconnect(context.Background())
-
-	// B. Setup Collections
-	db := client.Database("shop_db")
-	eventsCol := db.Collection("events")          // Source (Event Store)
-	viewCol := db.Collection("products_view")     // Target (Read Model)
-	checkpointCol := db.Collection("checkpoints") // State (Resume Token)
-
-	fmt.Println("🚀 Projector Service Starting...")
-
-	// C. Load Resume Token (กู้คืนจุดล่าสุดที่อ่านค้างไว้)
-	var resumeToken interface{}
-	var checkpoint Checkpoint
-
-	// พยายามหา Checkpoint ชื่อ "main_projector"
-	err = checkpointCol.FindOne(context.Background(), bson.M{"_id": "main_projector"}).Decode(&checkpoint)
-	if err == nil {
-		resumeToken = checkpoint.ResumeToken
-		fmt.Println("🔄 Resumed from last checkpoint.")
-	} else {
-		fmt.Println("🆕 No checkpoint found. Starting from now (or beginning).")
+	// หลาย Instance: ทุกตัวต้องใช้ PROJECTOR_PARTITIONS เท่ากัน, Instance ID ต้องไม่ซ้ำ (Default = Hostname ของ Container)
+	partitions, err := strconv.Atoi(getEnv("PROJECTOR_PARTITIONS", "1"))
+	if err != nil || partitions < 1 {
+		log.Fatal("Invalid PROJECTOR_PARTITIONS: ", getEnv("PROJECTOR_PARTITIONS", ""))
 	}
-
-	// D. Configure Change Stream
-	streamOpts := options.ChangeStream()
-	if resumeToken != nil {
-		// กรณี 1: มี Token (เคยรันแล้ว) -> ทำต่อจากเดิม
-		fmt.Println("🔄 Resuming from Checkpoint...")
-		streamOpts.SetResumeAfter(resumeToken)
-	} else {
-		// กรณี 2: ไม่มี Token (เพิ่งลบ Checkpoint หรือรันครั้งแรก)
-		// 💥 ต้องสั่งให้เริ่มอ่านตั้งแต่ "จุดเริ่มต้นของเวลา" (Timestamp 1, 0)
-		fmt.Println("🆕 No Checkpoint. Replaying ALL history from beginning...")
-
-		// ต้อง import "go.mongodb.org/mongo-driver/bson/primitive" ข้างบนด้วย
-		startOfTime := primitive.Timestamp{T: 1, I: 0}
-		streamOpts.SetStartAtOperationTime(&startOfTime)
+	leaseTTL, err := time.ParseDuration(getEnv("PROJECTOR_LEASE_TTL", "15s"))
+	if err != nil || leaseTTL < time.Second {
+		log.Fatal("Invalid PROJECTOR_LEASE_TTL: ", getEnv("PROJECTOR_LEASE_TTL", ""))
 	}
-	// ถ้าไม่มี Token โดย default มันจะเริ่มอ่าน Event ใหม่ที่เกิดขึ้นหลังจากนี้ (Real-time)
-	// แต่ถ้าอยากให้อ่านย้อนหลังทั้งหมดตั้งแต่เริ่มโลก ให้ใช้:
-	// streamOpts.SetStartAtOperationTime(&primitive.Timestamp{T: 1, I: 0})
-
-	// Filter: สนใจแค่การ Insert ข้อมูลใหม่ลง Event Store
-	pipeline := mongo.Pipeline{
-		// {{"$match", bson.D{{"operationType", "insert"}}}},
+	// โหมด Batch (1 = ปิด): ไล่ Event จำนวนมากเร็วขึ้น, Window = รอรวมกลุ่มนานสุด
+	batchSize, err := strconv.Atoi(getEnv("PROJECTOR_BATCH_SIZE", "1"))
+	if err != nil || batchSize < 1 {
+		log.Fatal("Invalid PROJECTOR_BATCH_SIZE: ", getEnv("PROJECTOR_BATCH_SIZE", ""))
 	}
-
-	stream, err := eventsCol.Watch(context.Background(), pipeline, streamOpts)
+	batchWindow, err := time.ParseDuration(getEnv("PROJECTOR_BATCH_WINDOW", "200ms"))
+	if err != nil || batchWindow <= 0 {
+		log.Fatal("Invalid PROJECTOR_BATCH_WINDOW: ", getEnv("PROJECTOR_BATCH_WINDOW", ""))
+	}
+	adminAddr := getEnv("PROJECTOR_ADMIN_ADDR", ":8090") // Admin HTTP API (ว่าง = ปิด)
+	// Alert สต็อกต่ำ: จุดสั่งซื้อจากไฟล์, ส่งไปที่ Sink ตามรายชื่อ, Event ที่เก่ากว่า MaxAge (ไล่ History) ไม่ส่ง
+	reorderPoints, err := loadReorderPoints(getEnv("STOCK_ALERT_FILE", ""))
 	if err != nil {
-		log.Fatal("❌ Error starting stream:", err)
+		log.Fatal("Invalid STOCK_ALERT_FILE: ", err)
 	}
-	defer stream.Close(context.Background())
-
-	fmt.Println("👀 Watching for events...")
-
-	// E. Infinite Loop (Processing)
-	for stream.Next(context.Background()) {
-		// โครงสร้างข้อมูลที่ Change Stream ส่งมา
-		var changeEvent struct {
-			ID           interface{} `bson:"_id"`          // นี่คือ Resume Token ของ Event นี้
-			FullDocument StockEvent  `bson:"fullDocument"` // ข้อมูล Event จริงๆ
-		}
-
-		if err := stream.Decode(&changeEvent); err != nil {
-			log.Println("⚠️ Error decoding event:", err)
-			continue
-		}
-
-		event := changeEvent.FullDocument
-		token := changeEvent.ID
-
-		// 1. Process Logic (อัปเดต Read Model)
-		err := processEvent(viewCol, event)
-		if err != nil {
-			log.Printf("❌ Failed to process event %s: %v\n", event.StreamID, err)
-			// ใน Production: อาจจะ Retry หรือส่งเข้า Dead Letter Queue
-			// แต่ Projector ไม่ควรหยุดทำงาน
-		}
-
-		// 2. Save Checkpoint (บันทึกว่าทำถึงไหนแล้ว)
-		// บันทึก Token ลง DB เพื่อที่ถ้าโปรแกรมดับ เปิดมาใหม่จะได้ทำต่อจากตรงนี้
-		_, err = checkpointCol.UpdateOne(context.Background(),
-			bson.M{"_id": "main_projector"},
-			bson.M{"$set": bson.M{"resume_token": token}},
-			options.Update().SetUpsert(true), // ถ้าไม่มีให้สร้างใหม่
-		)
-		if err != nil {
-			log.Printf("⚠️ Warning: Failed to save checkpoint: %v\n", err)
-		}
+	alertNotifiers, err := alertSinks(getEnv("STOCK_ALERT_SINKS", "log"), getEnv("STOCK_ALERT_WEBHOOK_URL", ""), getEnv("STOCK_ALERT_EMAIL_TO", ""))
+	if err != nil {
+		log.Fatal("Invalid STOCK_ALERT_SINKS: ", err)
 	}
-	if err := stream.Err(); err != nil {
-		log.Fatal("❌ Stream Error: ", err) // <--- มันฟ้องว่าอะไรครับ?
+	alertMaxAge, err := time.ParseDuration(getEnv("STOCK_ALERT_MAX_AGE", "1h"))
+	if err != nil || alertMaxAge < 0 {
+		log.Fatal("Invalid STOCK_ALERT_MAX_AGE: ", getEnv("STOCK_ALERT_MAX_AGE", ""))
 	}
-	fmt.Println("👋 Stream closed gracefully (Invalidate?).")
-}
-
-// ---------------------------------------------------------
-// 3. Business Logic (Safe Idempotent Version)
-// ---------------------------------------------------------
-
-func processEvent(viewCol *mongo.Collection, event StockEvent) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	// 1. คำนวณยอดที่จะเปลี่ยนแปลง (Change)
-	change := 0
-	switch event.Type {
-	case "StockReserved":
-		change = -event.Qty // จองของ = ลบ
-	case "StockReleased", "StockAdded":
-		change = event.Qty // คืนของ/เติมของ = บวก
-	default:
-		return nil // Event ที่ไม่รู้จัก ข้ามไป
+	salesAppliedTTL, err := time.ParseDuration(getEnv("SALES_ANALYTICS_APPLIED_TTL", "0"))
+	if err != nil || salesAppliedTTL < 0 {
+		log.Fatal("Invalid SALES_ANALYTICS_APPLIED_TTL: ", getEnv("SALES_ANALYTICS_APPLIED_TTL", ""))
 	}
+	hostname, _ := os.Hostname()
+	instanceID := getEnv("PROJECTOR_INSTANCE_ID", fmt.Sprintf("%s-%d", hostname, os.Getpid()))
 
-	fmt.Printf("⚡ Processing Event: %s (v.%d) | Change: %d | Product: %s\n",
-		event.Type, event.Version, change, event.StreamID)
-
-	// 2. เช็คข้อมูลปัจจุบันใน DB ก่อน (Check Phase)
-	filter := bson.M{"product_id": event.StreamID}
-
-	var currentDoc struct {
-		LastVersion int `bson:"last_version"`
+	fmt.Printf("🔧 Config: Mongo=%s | MaxAttempts=%d | Instance=%s | Partitions=%d | LeaseTTL=%s | Batch=%d/%s | Admin=%s\n",
+		mongoURI, maxAttempts, instanceID, partitions, leaseTTL, batchSize, batchWindow, adminAddr)
+	fmt.Printf("🔔 Stock Alerts: Sinks=%s | DefaultReorderPoint=%d | Overrides=%d | MaxAge=%s\n",
+		getEnv("STOCK_ALERT_SINKS", "log"), reorderPoints.Default, len(reorderPoints.Products), alertMaxAge)
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		log.Fatal("❌ Connection Failed:", err)
 	}
+	defer client.Disconnect(context.Background())
 
-	// พยายามหาเอกสารเก่า
-	err := viewCol.FindOne(ctx, filter).Decode(&currentDoc)
-
-	if err == nil {
-		// ---------------------------------------------------
-		// กรณี A: เจอข้อมูลเดิม (Found)
-		// ---------------------------------------------------
-
-		// กฎเหล็ก: ห้ามถอยหลังลงคลอง
-		// ถ้า Version ใน DB ใหม่กว่าหรือเท่ากับ Event ที่กำลังเข้ามา แปลว่าเราเคย Process ไปแล้ว
-		if currentDoc.LastVersion >= event.Version {
-			log.Printf("   ⚠️ Skipped: Event v.%d is older/equal to DB v.%d\n", event.Version, currentDoc.LastVersion)
-			return nil // จบการทำงานแบบปกติ (ถือว่าสำเร็จ)
+	db := client.Database("shop_db")
+	if salesAppliedTTL > 0 {
+		if err := projections.ExpireSalesApplied(context.Background(), db, salesAppliedTTL); err != nil {
+			log.Fatal("❌ sales_analytics_applied TTL: ", err)
 		}
+		fmt.Printf("🧹 sales_analytics_applied keys expire after %s\n", salesAppliedTTL)
+	}
 
-		// ถ้า Event ใหม่กว่า -> อัปเดตยอด
-		update := bson.M{
-			"$inc": bson.M{"available_stock": change},
-			"$set": bson.M{"last_version": event.Version},
-		}
+	fmt.Println("🚀 Projector Service Starting...")
 
-		_, err := viewCol.UpdateOne(ctx, filter, update)
-		if err != nil {
-			return fmt.Errorf("failed to update view: %w", err)
+	// B. Wiring: Source (Change Stream) + Checkpoint / Dead Letter Store ใช้ร่วมกันทุก Projection
+	source := mongoAdapter.NewChangeStreamSource(db)
+	checkpoints := mongoAdapter.NewMongoCheckpointStore(db)
+	deadLetters := mongoAdapter.NewMongoDeadLetterStore(db)
+	registry := app.NewRegistry(source, checkpoints, deadLetters)
+	registry.Retry.MaxAttempts = maxAttempts
+	registry.Batch = app.BatchPolicy{Size: batchSize, Window: batchWindow}
+
+	// C. Read Model ทั้งหมด (เพิ่มตัวใหม่ = เขียน Projection ใน projections/ แล้ว Register ตรงนี้)
+	stockAlerts := projections.NewStockAlerts(db, reorderPoints, alertNotifiers, alertMaxAge)
+	for _, p := range []ports.Projection{
+		projections.NewProductsView(db),   // events -> products_view
+		projections.NewCatalogView(db),    // catalog_events -> catalog_view
+		projections.NewOrdersView(db),     // events + payment_events -> orders_view
+		projections.NewSalesAnalytics(db), // events + payment_events -> sales_analytics
+		stockAlerts,                       // events -> stock_levels + stock_alerts -> Sink
+	} {
+		if err := registry.Register(p); err != nil {
+			log.Fatal("❌ ", err)
 		}
+	}
 
-	} else if err == mongo.ErrNoDocuments {
-		// ---------------------------------------------------
-		// กรณี B: ไม่เจอข้อมูลเดิม (Not Found) -> สินค้าใหม่
-		// ---------------------------------------------------
-
-		newDoc := bson.M{
-			"product_id":      event.StreamID,
-			"available_stock": change, // ยอดตั้งต้นเท่ากับค่า change เลย
-			"last_version":    event.Version,
-			// คุณอาจเพิ่ม field อื่นๆ เช่น updated_at ตรงนี้
+	if len(os.Args) > 1 {
+		rebuilder := app.NewRebuilder(source, checkpoints, mongoAdapter.NewMongoViewAdmin(db))
+		rebuilder.Partitions = partitions
+		rebuilder.BatchSize = batchSize
+		cmd := commands{
+			registry:    registry,
+			rebuilder:   rebuilder,
+			deadLetters: app.NewDeadLetterService(deadLetters, registry),
+			verifier:    app.NewStockVerifier(source, mongoAdapter.NewMongoStockViewStore(db)),
 		}
+		cmd.run(context.Background(), os.Args[1:])
+		return
+	}
 
-		_, err := viewCol.InsertOne(ctx, newDoc)
-		if err != nil {
-			// กันเหนียว: กรณีมี Race Condition (Projector 2 ตัวแย่งกันสร้าง)
-			if mongo.IsDuplicateKeyError(err) {
-				log.Println("   ⚠️ Insert skipped (Duplicate Key). Another worker processed it.")
-				return nil
+	// D. รันผ่าน Lease: เปิดกี่ Instance ก็ได้ งานจะถูกแบ่งกันเอง (ตัวไหนดับ ตัวที่เหลือรับต่อเมื่อ Lease หมดอายุ)
+	coordinator := app.NewCoordinator(registry, mongoAdapter.NewMongoLeaseStore(db), instanceID, partitions)
+	coordinator.TTL = leaseTTL
+	coordinator.Interval = leaseTTL / 3
+	controls := mongoAdapter.NewMongoControlStore(db)
+	coordinator.Controls = controls
+
+	// ปิดตัวด้วย SIGTERM (docker stop / scale down) -> คืน Lease ทันที ตัวอื่นไม่ต้องรอจนหมดอายุ
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// E. Admin API: Pause / Resume / Reset / Skip ได้โดยไม่ต้อง Restart (เปิดไม่ได้ก็ยัง Project ต่อ)
+	if adminAddr != "" {
+		admin := httpAdapter.NewAdminServer(app.NewProjectorAdmin(coordinator, controls, deadLetters))
+		go func() {
+			if err := admin.Run(ctx, adminAddr); err != nil {
+				log.Printf("⚠️ Admin API stopped: %v", err)
 			}
-			return fmt.Errorf("failed to insert view: %w", err)
-		}
-
-	} else {
-		// ---------------------------------------------------
-		// กรณี C: Error อื่นๆ (เช่น DB Connection หลุด)
-		// ---------------------------------------------------
-		return fmt.Errorf("error finding document: %w", err)
+		}()
+	}
+	if err := coordinator.Run(ctx); err != nil {
+		log.Fatal("❌ ", err)
 	}
-
-	fmt.Println("   ✅ View Updated Successfully.")
-	return nil
 }
 
 func getEnv(key, fallback string) string {
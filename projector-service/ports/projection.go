@@ -0,0 +1,163 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"infra/changefeed"
+	"projector-service/core"
+)
+
+// Projection คือ Read Model 1 ตัว: ฟัง Event จาก Source (1 หรือหลาย Collection) แล้วอัปเดต View ของตัวเอง
+// เพิ่ม Read Model ใหม่ = เขียน Projection ใหม่แล้ว Register ใน main.go
+type Projection interface {
+	// Name ชื่อ Read Model (ใช้ใน Log และต้องไม่ซ้ำกัน)
+	Name() string
+	// CheckpointID คือ _id ใน checkpoints (แยกจาก Name เพื่อคง Checkpoint เดิมไว้ได้เวลาเปลี่ยนชื่อ)
+	CheckpointID() string
+	// Sources คือ Collection ของ Event ที่ต้องฟัง (หลายตัว = Stream เดียวระดับ Database, Checkpoint เดียว)
+	Sources() []string
+	// Filter คือเงื่อนไขเพิ่มเติมบน Change Stream เช่น {{Key: "fullDocument.type", Value: ...}} (nil = ทุก Insert)
+	// ใช้กับทุก Source
+	Filter() bson.D
+	// Handle อัปเดต View จาก Event 1 ตัว (ต้อง Idempotent เพราะ Event อาจมาซ้ำหลัง Restart)
+	// แยกงานตาม event.Source + event.Type ได้เลย ไม่ต้องเดาจากหน้าตาของ Document
+	// หลาย Source: ห้ามพึ่งลำดับข้าม Source (History อ่านทีละ Collection)
+	Handle(ctx context.Context, event core.Envelope) error
+}
+
+// Versioned คือ Projection ที่รู้ว่าตัวเองทำถึง Version ไหนของแต่ละ Stream แล้ว
+// ใช้ตอน Recover: อ่าน Event Store เฉพาะส่วนที่ยังไม่ได้ทำ แทนที่จะอ่านทั้งหมด
+type Versioned interface {
+	Projection
+	// Positions คืน stream_id -> Version ล่าสุดที่ View มี
+	Positions(ctx context.Context) (map[string]int, error)
+}
+
+// GapFilling คือ Versioned ที่ขยับ Version ของแต่ละ Stream ทีละ 1 เสมอ: เจอ Gap จะอ่าน Version ที่ขาดจาก Event Store มาใส่เอง
+// Skip to now จึงไม่มีผล (Event ถัดไปของแต่ละ Stream ดึง Event ที่ถูกข้ามกลับมา Apply อยู่ดี)
+type GapFilling interface {
+	Versioned
+	// FillsGaps ไม่ทำอะไร มีไว้บอกว่า Projection นี้เติม Gap เอง
+	FillsGaps()
+}
+
+// Batched คือ Projection ที่ Apply Event หลายตัวในครั้งเดียวได้ (ใช้เมื่อเปิดโหมด Batch ตอนไล่ Event จำนวนมาก)
+type Batched interface {
+	Projection
+	// HandleBatch Apply Event ตามลำดับที่ได้รับ (ยัง Idempotent ตาม Version ของแต่ละ Stream เหมือน Handle)
+	// คืน Error = ทั้ง Batch ไม่ผ่าน -> Registry จะส่งทีละตัวผ่าน Handle แทน (ตัวที่ Apply ไปแล้วจะถูกข้าม)
+	HandleBatch(ctx context.Context, events []core.Envelope) error
+}
+
+// Partitioned คือ Projection ที่บอกเองว่า Event ไหนต้องอยู่ Partition เดียวกัน (ไม่ Implement = ใช้ stream_id)
+// Event ที่ต้องทำตามลำดับกันต้องได้ Key เดียวกัน เช่น orders_view รวมตาม order_id
+type Partitioned interface {
+	Projection
+	PartitionKey(event core.Envelope) string
+}
+
+// Rebuildable คือ Projection ที่สร้าง View ใหม่ทั้งก้อนใน Collection อื่นได้ (Blue/Green Rebuild)
+type Rebuildable interface {
+	Projection
+	// View คือชื่อ Collection ของ Read Model ที่ผู้ใช้อ่าน
+	View() string
+	// Shadow คืน Projection ตัวเดียวกันที่เขียนลง collection แทน (สร้าง Index ที่ View ต้องมีให้ด้วย)
+	Shadow(ctx context.Context, collection string) (Projection, error)
+}
+
+// Error ที่ EventSource คืนเมื่อ Stream ใช้ต่อไม่ได้ (Registry จะ Recover เอง)
+// ตัวเดียวกับของ infra/changefeed ที่ Subscriber ของ Service อื่นใช้ (Error Code ของ Server อยู่ที่นั่นที่เดียว)
+var (
+	ErrHistoryLost       = changefeed.ErrHistoryLost       // Resume Token หลุดจาก Oplog ไปแล้ว
+	ErrStreamInvalidated = changefeed.ErrStreamInvalidated // Collection ถูก Drop / Rename
+)
+
+// EventSource ส่ง Event ใหม่ของ Collection (1 หรือหลายตัว) ให้ทีละตัวตามลำดับที่เขียน
+type EventSource interface {
+	// Watch อ่านต่อจาก resumeAfter (nil = ย้อนอ่านตั้งแต่ต้น) จนกว่า ctx จะถูกยกเลิก
+	// หรือ Stream ใช้ต่อไม่ได้ (ErrHistoryLost / ErrStreamInvalidated)
+	Watch(ctx context.Context, sources []string, filter bson.D, resumeAfter bson.Raw, handle func(core.Change) error) error
+	// WatchBatches เหมือน Watch แต่ส่งทีละกลุ่ม: ครบ size ตัว, ครบ window นับจากตัวแรกของกลุ่ม
+	// หรือไม่มี Event ค้างใน Stream แล้ว (ไล่ทันแล้วไม่ต้องรอให้ครบ) อย่างใดอย่างหนึ่งก่อน
+	WatchBatches(ctx context.Context, sources []string, filter bson.D, resumeAfter bson.Raw, size int, window time.Duration, handle func([]core.Change) error) error
+	// Head คืน Token ของ "ตอนนี้": Event ที่เข้ามาหลังจากนี้จะอยู่หลัง Token นี้
+	Head(ctx context.Context, sources []string) (bson.Raw, error)
+	// CatchUp เหมือน Watch แต่หยุดเมื่อไม่มี Event ค้างแล้ว คืน Token ล่าสุดที่อ่านถึง
+	CatchUp(ctx context.Context, sources []string, filter bson.D, resumeAfter bson.Raw, handle func(core.Change) error) (bson.Raw, error)
+	// History อ่าน Event ที่มีอยู่แล้วจาก Collection โดยตรง (ไม่พึ่ง Oplog ที่อาจถูกตัดทิ้ง) ทีละ Source
+	// เรียงตาม stream_id + version, after = อ่านเฉพาะ Version ที่มากกว่านี้ของแต่ละ Stream (nil = ทั้งหมด)
+	History(ctx context.Context, sources []string, filter bson.D, after map[string]int, handle func(core.Change) error) error
+	// Latest คืนเวลาของ Event ใหม่สุดที่ตรง filter ในทุก Source (ว่าง = ยังไม่มี Event) ใช้คำนวณ Lag
+	Latest(ctx context.Context, sources []string, filter bson.D) (time.Time, error)
+}
+
+// ViewAdmin จัดการ Collection ของ Read Model
+type ViewAdmin interface {
+	Drop(ctx context.Context, collection string) error
+	// Swap เปลี่ยนชื่อ from -> to แทนที่ของเดิมในคำสั่งเดียว (Atomic: ผู้อ่านเห็นแค่ View เก่าหรือใหม่)
+	Swap(ctx context.Context, from, to string) error
+}
+
+// StockViewStore อ่าน / ซ่อมเอกสารใน products_view ตรงๆ (ใช้ตรวจ Drift เทียบกับ events)
+type StockViewStore interface {
+	// List คืนทุกเอกสารใน View
+	List(ctx context.Context) ([]core.StockState, error)
+	// Replace เขียนทับเอกสารของสินค้าด้วย state ถ้า View ยังอยู่ที่ atVersion (0 = ยังไม่มีเอกสาร -> สร้างใหม่)
+	// คืน false ถ้า View ขยับไปแล้ว (Projector เขียนระหว่างตรวจ)
+	Replace(ctx context.Context, state core.StockState, atVersion int) (bool, error)
+	// Delete ลบเอกสารของสินค้าถ้า View ยังอยู่ที่ atVersion
+	Delete(ctx context.Context, productID string, atVersion int) (bool, error)
+}
+
+// CheckpointStore เก็บว่าแต่ละ Projection อ่านถึงไหนแล้ว
+type CheckpointStore interface {
+	// Load คืน nil ถ้ายังไม่เคยมี Checkpoint
+	Load(ctx context.Context, id string) (bson.Raw, error)
+	// Save บันทึก Token พร้อมเวลาของ Event สุดท้ายที่อ่านถึง (eventAt ว่าง = ไม่รู้)
+	Save(ctx context.Context, id string, token bson.Raw, eventAt time.Time) error
+	// List คืน Checkpoint ของ Projection ทั้งแบบตัวเดียว (base) และทุก Partition (base#i/n)
+	List(ctx context.Context, base string) ([]core.Checkpoint, error)
+	// Delete ลบ Checkpoint ทั้งหมดของ Projection (base และทุก Partition) -> ครั้งหน้าอ่านตั้งแต่ต้น
+	Delete(ctx context.Context, base string) error
+}
+
+// ControlStore เก็บคำสั่งของ Operator (Pause / Resume) ที่ทุก Instance ต้องทำตาม
+type ControlStore interface {
+	// List คืน Control ของทุก Projection ที่เคยถูกสั่ง (key = ชื่อ Projection)
+	List(ctx context.Context) (map[string]core.Control, error)
+	SetPaused(ctx context.Context, projection string, paused bool) error
+}
+
+// LeaseStore แจก Lease ให้ Projector หลาย Instance (เวลาหมดอายุใช้นาฬิกาของ Store ตัวเดียว ไม่ใช่ของแต่ละเครื่อง)
+type LeaseStore interface {
+	// Acquire ได้ Lease ถ้ายังไม่มีเจ้าของ / หมดอายุแล้ว / เป็นของ owner อยู่แล้ว (= ต่ออายุ) คืน false ถ้าตัวอื่นถืออยู่
+	Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	// Release คืน Lease ทันที (เฉพาะของ owner) ให้ตัวอื่นรับต่อได้โดยไม่ต้องรอหมดอายุ
+	Release(ctx context.Context, name, owner string) error
+	// Live คืน Lease ที่ยังไม่หมดอายุซึ่งชื่อขึ้นต้นด้วย prefix
+	Live(ctx context.Context, prefix string) ([]core.Lease, error)
+}
+
+// DeadLetterStore เก็บ Event ที่ Handle ไม่ผ่านหลัง Retry ครบ
+type DeadLetterStore interface {
+	// Add ใช้ Upsert ด้วย ID (Event เดิมถูกส่งซ้ำ = อัปเดตเคสเดิม)
+	Add(ctx context.Context, letter core.DeadLetter) error
+	// List คืนเคส OPEN เรียงจากเก่าไปใหม่ (projection ว่าง = ทุก Projection)
+	List(ctx context.Context, projection string) ([]core.DeadLetter, error)
+	// Get คืน nil ถ้าไม่เจอ
+	Get(ctx context.Context, id string) (*core.DeadLetter, error)
+	// RecordFailure บันทึกว่า Replay ไม่ผ่านอีกรอบ (attempts + 1)
+	RecordFailure(ctx context.Context, id string, reason string) error
+	// Close ปิดเคสด้วยสถานะ REPLAYED / DISCARDED
+	Close(ctx context.Context, id string, status string) error
+}
+
+// Notifier ส่ง Alert ออกไปข้างนอก (Webhook / Log / Email) ต้องทนการส่งซ้ำได้: ส่งสำเร็จแล้วแต่บันทึกไม่ทัน = ส่งอีกรอบ
+type Notifier interface {
+	// Name ระบุ Sink (บันทึกใน sent_to ของ Alert จึงต้องไม่เปลี่ยนระหว่าง Restart)
+	Name() string
+	Notify(ctx context.Context, alert core.StockAlert) error
+}
@@ -0,0 +1,95 @@
+package projections
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// CatalogView: catalog_events -> catalog_view (ชื่อ + ราคาปัจจุบันของสินค้า)
+type CatalogView struct {
+	Collection *mongo.Collection
+}
+
+func NewCatalogView(db *mongo.Database) ports.Projection {
+	return &CatalogView{Collection: db.Collection("catalog_view")}
+}
+
+func (p *CatalogView) Name() string         { return "catalog_view" }
+func (p *CatalogView) CheckpointID() string { return "catalog_projector" }
+func (p *CatalogView) Sources() []string    { return []string{"catalog_events"} }
+func (p *CatalogView) View() string         { return p.Collection.Name() }
+
+// Positions คือ Version ล่าสุดของแต่ละสินค้าที่ View มี (ใช้ตอน Recover)
+func (p *CatalogView) Positions(ctx context.Context) (map[string]int, error) {
+	return positions(ctx, p.Collection)
+}
+
+// Shadow เขียนลง Collection อื่นด้วย Logic เดียวกัน (ใช้ตอน Rebuild)
+// Unique Index บน product_id จำเป็น: Upsert ที่ Version เก่ากว่าต้องชน Index แทนที่จะสร้างเอกสารซ้ำ
+func (p *CatalogView) Shadow(ctx context.Context, collection string) (ports.Projection, error) {
+	view := p.Collection.Database().Collection(collection)
+	_, err := view.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "product_id", Value: 1}}, Options: options.Index().SetUnique(true)})
+	if err != nil {
+		return nil, err
+	}
+	return &CatalogView{Collection: view}, nil
+}
+
+func (p *CatalogView) Filter() bson.D {
+	return bson.D{{Key: "fullDocument.type", Value: bson.M{"$in": bson.A{"ProductCreated", "PriceChanged"}}}}
+}
+
+// Handle อัปเดตราคาใน catalog_view แบบ Idempotent ด้วย Version
+func (p *CatalogView) Handle(ctx context.Context, env core.Envelope) error {
+	var event core.CatalogEvent
+	if err := env.Decode(&event); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter, set, ok := catalogUpdate(event)
+	if !ok {
+		return nil // Event ที่ไม่รู้จัก ข้ามไป
+	}
+
+	fmt.Printf("⚡ Processing Event: %s (v.%d) | Price: %d | Product: %s\n",
+		event.Type, event.Version, event.Price, event.StreamID)
+
+	_, err := p.Collection.UpdateOne(ctx, filter, bson.M{"$set": set}, options.Update().SetUpsert(true))
+	if err != nil {
+		// เอกสารมีอยู่แล้วและ Version ใหม่กว่า -> Upsert ชน Unique Index = เคย Process ไปแล้ว
+		if mongo.IsDuplicateKeyError(err) {
+			log.Printf("   ⚠️ Skipped: Event v.%d is older/equal to view\n", event.Version)
+			return nil
+		}
+		return fmt.Errorf("failed to update catalog view: %w", err)
+	}
+
+	fmt.Println("   ✅ Catalog View Updated Successfully.")
+	return nil
+}
+
+// catalogUpdate คือ Filter + $set ของ Event หนึ่ง (ok = false: Event ที่ไม่รู้จัก)
+// อัปเดตเฉพาะเมื่อ Event ใหม่กว่าที่มีอยู่ (ถ้ายังไม่มีเอกสาร -> Upsert สร้างใหม่)
+func catalogUpdate(event core.CatalogEvent) (filter, set bson.M, ok bool) {
+	set = bson.M{"price": event.Price, "last_version": event.Version}
+	switch event.Type {
+	case "ProductCreated":
+		set["name"] = event.Name
+	case "PriceChanged":
+	default:
+		return nil, nil, false
+	}
+	filter = bson.M{"product_id": event.StreamID, "last_version": bson.M{"$lt": event.Version}}
+	return filter, set, true
+}
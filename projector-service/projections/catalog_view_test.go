@@ -0,0 +1,57 @@
+package projections
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"projector-service/core"
+)
+
+func TestCatalogViewKeepsNewestPriceWhateverTheArrivalOrder(t *testing.T) {
+	created := core.CatalogEvent{StreamID: "airpods", Type: "ProductCreated", Name: "AirPods", Price: 5990, Version: 1}
+	cut := core.CatalogEvent{StreamID: "airpods", Type: "PriceChanged", Price: 4990, Version: 2}
+	raised := core.CatalogEvent{StreamID: "airpods", Type: "PriceChanged", Price: 5490, Version: 3}
+
+	tests := []struct {
+		name        string
+		events      []core.CatalogEvent
+		wantPrice   int
+		wantVersion int
+		wantName    string
+	}{
+		{name: "in order", events: []core.CatalogEvent{created, cut, raised}, wantPrice: 5490, wantVersion: 3, wantName: "AirPods"},
+		{name: "stale price change after a newer one", events: []core.CatalogEvent{created, raised, cut}, wantPrice: 5490, wantVersion: 3, wantName: "AirPods"},
+		{name: "redelivered creation does not reset the price", events: []core.CatalogEvent{created, cut, created}, wantPrice: 4990, wantVersion: 2, wantName: "AirPods"},
+		{name: "creation arriving after a newer price change is skipped", events: []core.CatalogEvent{cut, created}, wantPrice: 4990, wantVersion: 2},
+		{name: "unknown type is ignored", events: []core.CatalogEvent{created, {StreamID: "airpods", Type: "ProductRenamed", Name: "X", Version: 4}}, wantPrice: 5990, wantVersion: 1, wantName: "AirPods"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc bson.M // nil = ยังไม่มีเอกสาร
+			for _, event := range tt.events {
+				filter, set, ok := catalogUpdate(event)
+				if !ok {
+					continue
+				}
+				if filter["product_id"] != event.StreamID {
+					t.Fatalf("filter = %v, want product_id %s", filter, event.StreamID)
+				}
+				// Filter ไม่ Match = Upsert ชน Unique Index บน product_id (Handle ถือว่าเคย Process แล้ว)
+				if doc != nil && doc["last_version"].(int) >= filter["last_version"].(bson.M)["$lt"].(int) {
+					continue
+				}
+				if doc == nil {
+					doc = bson.M{"product_id": event.StreamID}
+				}
+				for field, value := range set {
+					doc[field] = value
+				}
+			}
+			name, _ := doc["name"].(string)
+			if doc["price"] != tt.wantPrice || doc["last_version"] != tt.wantVersion || name != tt.wantName {
+				t.Fatalf("view = %v, want price %d v.%d name %q", doc, tt.wantPrice, tt.wantVersion, tt.wantName)
+			}
+		})
+	}
+}
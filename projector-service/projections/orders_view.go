@@ -0,0 +1,194 @@
+package projections
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// OrdersView: events + payment_events -> orders_view (1 เอกสารต่อ Order รวมกันด้วย order_id)
+//
+// ฟัง 2 Source ใน Stream เดียว (Checkpoint เดียว) แล้วแยกงานตาม Source + Type ของ Envelope
+// History (Rebuild / Recover) อ่านทีละ Collection ไม่มีลำดับข้าม Source จึงเก็บเป็น "ข้อเท็จจริง" (reserved_at, paid_at, ...)
+// แล้วคำนวณ status ใหม่จากข้อเท็จจริงทุกครั้งใน Update เดียวกัน -> มาก่อนมาหลังได้ผลเหมือนกัน และ Apply ซ้ำได้
+type OrdersView struct {
+	Collection *mongo.Collection
+}
+
+// สถานะของ Order ใน orders_view (ชื่อเดียวกับ Order Stream ของ Orchestrator)
+const (
+	OrderStatusPlaced        = "PLACED"
+	OrderStatusStockReserved = "STOCK_RESERVED"
+	OrderStatusPaid          = "PAID"
+	OrderStatusCompleted     = "COMPLETED"
+	OrderStatusFailed        = "FAILED"
+	OrderStatusCancelled     = "CANCELLED"
+)
+
+func NewOrdersView(db *mongo.Database) ports.Projection {
+	return &OrdersView{Collection: db.Collection("orders_view")}
+}
+
+func (p *OrdersView) Name() string         { return "orders_view" }
+func (p *OrdersView) CheckpointID() string { return "orders_view" }
+func (p *OrdersView) Sources() []string    { return []string{"events", "payment_events"} }
+func (p *OrdersView) View() string         { return p.Collection.Name() }
+
+// Filter = เฉพาะ Event ที่ผูกกับ Order (เติมของไม่มี order_id) ชื่อ Type ไม่ซ้ำกันข้าม Source จึงใช้ Filter เดียวได้
+func (p *OrdersView) Filter() bson.D {
+	return bson.D{
+		{Key: "fullDocument.order_id", Value: bson.M{"$nin": bson.A{nil, ""}}},
+		{Key: "fullDocument.type", Value: bson.M{"$in": bson.A{
+			"StockReserved", "StockReservationRejected", "StockReleased", "StockCommitted",
+			"PaymentProcessed", "PaymentFailed",
+		}}},
+	}
+}
+
+// PartitionKey: Event ของ Order เดียวกันมาจากหลาย Stream (สินค้า / การจ่ายเงิน) -> แบ่งตาม order_id
+func (p *OrdersView) PartitionKey(event core.Envelope) string { return event.OrderID }
+
+// Shadow เขียนลง Collection อื่นด้วย Logic เดียวกัน (ใช้ตอน Rebuild)
+// Index ที่ GET /orders ใช้ต้องสร้างบน Shadow ด้วย เพราะ Rename เอา Index ของ Shadow ไปแทนของเดิม
+func (p *OrdersView) Shadow(ctx context.Context, collection string) (ports.Projection, error) {
+	view := p.Collection.Database().Collection(collection)
+	_, err := view.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "order_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "order_id", Value: -1}}},
+		{Keys: bson.D{{Key: "items.product_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "order_id", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "order_id", Value: -1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &OrdersView{Collection: view}, nil
+}
+
+func (p *OrdersView) Handle(ctx context.Context, env core.Envelope) error {
+	if env.OrderID == "" {
+		return nil
+	}
+	facts, err := orderFacts(env)
+	if err != nil || facts == nil {
+		return err // Source / Type ที่ไม่รู้จัก ข้ามไป
+	}
+
+	fmt.Printf("⚡ Processing Event: %s/%s | Order: %s\n", env.Source, env.Type, env.OrderID)
+	return updateOrder(ctx, p.Collection, env.OrderID, facts, env.Timestamp)
+}
+
+// orderFacts คือข้อเท็จจริงที่ Event นี้บอกเกี่ยวกับ Order (nil = Event ที่ไม่เกี่ยว)
+func orderFacts(env core.Envelope) (bson.M, error) {
+	switch env.Source {
+	case "events":
+		return stockFacts(env)
+	case "payment_events":
+		return paymentFacts(env)
+	}
+	return nil, nil
+}
+
+// stockFacts: items, จอง / คืน / ยืนยันการขาย
+func stockFacts(env core.Envelope) (bson.M, error) {
+	var event core.StockEvent
+	if err := env.Decode(&event); err != nil {
+		return nil, err
+	}
+	items := bson.A{bson.M{"product_id": event.StreamID, "qty": event.Qty}}
+	switch event.Type {
+	case "StockReserved":
+		return bson.M{"items": items, "reserved_at": event.Timestamp}, nil
+	case "StockReservationRejected":
+		return bson.M{"items": items, "rejected_at": event.Timestamp}, nil
+	case "StockReleased":
+		return bson.M{"released_at": event.Timestamp}, nil
+	case "StockCommitted":
+		return bson.M{"committed_at": event.Timestamp}, nil
+	}
+	return nil, nil
+}
+
+// paymentFacts: ยอดเงิน / ผลการตัดเงิน
+func paymentFacts(env core.Envelope) (bson.M, error) {
+	var event core.PaymentEvent
+	if err := env.Decode(&event); err != nil {
+		return nil, err
+	}
+	switch event.Type {
+	case "PaymentProcessed":
+		return bson.M{"amount": event.Amount, "payment_status": "SUCCESS", "paid_at": event.Timestamp}, nil
+	case "PaymentFailed":
+		facts := bson.M{"amount": event.Amount, "payment_status": "FAILED", "payment_failed_at": event.Timestamp}
+		if event.Reason != "" {
+			facts["payment_reason"] = event.Reason
+		}
+		return facts, nil
+	}
+	return nil, nil
+}
+
+// updateOrder บันทึกข้อเท็จจริงของ Order แล้วคำนวณ status / failure_reason ใหม่ใน Update เดียว (Pipeline)
+// Upsert พร้อมกัน (เช่น Projector ตัวหลักกับ Rebuild) อาจชน Unique Index -> คืน Error ให้ Retry (รอบถัดไปจะ Match)
+func updateOrder(ctx context.Context, view *mongo.Collection, orderID string, facts bson.M, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := view.UpdateOne(ctx, bson.M{"order_id": orderID}, orderPipeline(facts, at), options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to update orders view: %w", err)
+	}
+	fmt.Println("   ✅ Orders View Updated Successfully.")
+	return nil
+}
+
+// orderPipeline: Stage แรกเขียนข้อเท็จจริง Stage ที่สองคำนวณ status / failure_reason จากเอกสารที่ได้
+func orderPipeline(facts bson.M, at time.Time) mongo.Pipeline {
+	set := bson.M{
+		"created_at": bson.M{"$min": bson.A{"$created_at", at}}, // $min / $max ข้าม Field ที่ยังไม่มี
+		"updated_at": bson.M{"$max": bson.A{"$updated_at", at}},
+	}
+	for field, value := range facts {
+		set[field] = bson.M{"$literal": value} // ค่าจาก Event อาจขึ้นต้นด้วย "$" -> ห้ามตีความเป็น Expression
+	}
+	return mongo.Pipeline{
+		{{Key: "$set", Value: set}},
+		{{Key: "$set", Value: bson.M{"status": orderStatusExpr(), "failure_reason": failureReasonExpr()}}},
+	}
+}
+
+// orderStatusExpr: ข้อเท็จจริงที่ "ไปไกลกว่า" ชนะเสมอ ไม่ว่า Event จะมาถึงลำดับไหน
+func orderStatusExpr() bson.M {
+	return bson.M{"$switch": bson.M{
+		"branches": bson.A{
+			bson.M{"case": has("rejected_at"), "then": OrderStatusFailed},
+			bson.M{"case": bson.M{"$and": bson.A{paymentIs("SUCCESS"), has("committed_at")}}, "then": OrderStatusCompleted},
+			bson.M{"case": paymentIs("SUCCESS"), "then": OrderStatusPaid},
+			bson.M{"case": paymentIs("FAILED"), "then": OrderStatusFailed},
+			bson.M{"case": has("released_at"), "then": OrderStatusCancelled}, // คืนของโดยไม่มีผลตัดเงิน = ยกเลิก / หมดเวลา
+			bson.M{"case": has("reserved_at"), "then": OrderStatusStockReserved},
+		},
+		"default": OrderStatusPlaced,
+	}}
+}
+
+func failureReasonExpr() bson.M {
+	return bson.M{"$switch": bson.M{
+		"branches": bson.A{
+			bson.M{"case": has("rejected_at"), "then": "out of stock"},
+			bson.M{"case": paymentIs("FAILED"), "then": bson.M{"$ifNull": bson.A{"$payment_reason", "payment failed"}}},
+			bson.M{"case": bson.M{"$and": bson.A{has("released_at"), bson.M{"$not": bson.A{has("payment_status")}}}}, "then": "reservation released"},
+		},
+		"default": "$$REMOVE",
+	}}
+}
+
+func has(field string) bson.M { return bson.M{"$gt": bson.A{"$" + field, nil}} }
+
+func paymentIs(status string) bson.M { return bson.M{"$eq": bson.A{"$payment_status", status}} }
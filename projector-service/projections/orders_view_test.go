@@ -0,0 +1,233 @@
+package projections
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"projector-service/core"
+)
+
+func TestOrdersViewStatusIgnoresArrivalOrderAndDuplicates(t *testing.T) {
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	stock := func(eventType string, minute int) core.Envelope {
+		return envelope(t, "events", bson.M{"stream_id": "iphone-15", "order_id": "ORD-1", "type": eventType, "qty": 2, "version": minute, "timestamp": at.Add(time.Duration(minute) * time.Minute)})
+	}
+	payment := func(eventType, reason string, minute int) core.Envelope {
+		return envelope(t, "payment_events", bson.M{"order_id": "ORD-1", "type": eventType, "amount": 20000, "reason": reason, "timestamp": at.Add(time.Duration(minute) * time.Minute)})
+	}
+	reserved, rejected := stock("StockReserved", 1), stock("StockReservationRejected", 1)
+	released, committed := stock("StockReleased", 3), stock("StockCommitted", 3)
+	paid, declined, failed := payment("PaymentProcessed", "", 2), payment("PaymentFailed", "card declined", 2), payment("PaymentFailed", "", 2)
+
+	items := bson.A{bson.M{"product_id": "iphone-15", "qty": 2}}
+	tests := []struct {
+		name       string
+		events     []core.Envelope
+		wantStatus string
+		wantReason string // "" = ไม่มี failure_reason
+		wantItems  bool
+		wantAmount int
+	}{
+		{name: "completed with payment and commit before the reservation", events: []core.Envelope{paid, committed, reserved, paid}, wantStatus: OrderStatusCompleted, wantItems: true, wantAmount: 20000},
+		{name: "paid waiting for commit", events: []core.Envelope{paid, reserved, reserved}, wantStatus: OrderStatusPaid, wantItems: true, wantAmount: 20000},
+		{name: "payment failed with reason", events: []core.Envelope{released, declined, reserved, released}, wantStatus: OrderStatusFailed, wantReason: "card declined", wantItems: true, wantAmount: 20000},
+		{name: "payment failed without reason", events: []core.Envelope{failed, reserved}, wantStatus: OrderStatusFailed, wantReason: "payment failed", wantItems: true, wantAmount: 20000},
+		{name: "reservation rejected", events: []core.Envelope{rejected, rejected}, wantStatus: OrderStatusFailed, wantReason: "out of stock", wantItems: true},
+		{name: "released without payment", events: []core.Envelope{released, reserved}, wantStatus: OrderStatusCancelled, wantReason: "reservation released", wantItems: true},
+		{name: "reserved only", events: []core.Envelope{reserved, reserved}, wantStatus: OrderStatusStockReserved, wantItems: true},
+		{name: "payment only", events: []core.Envelope{paid}, wantStatus: OrderStatusPaid, wantAmount: 20000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reversed := slices.Clone(tt.events)
+			slices.Reverse(reversed)
+
+			for _, events := range [][]core.Envelope{tt.events, reversed} {
+				doc := projectOrder(t, events)
+
+				if doc["status"] != tt.wantStatus {
+					t.Fatalf("status = %v, want %s (doc %v)", doc["status"], tt.wantStatus, doc)
+				}
+				if reason, ok := doc["failure_reason"]; tt.wantReason == "" && ok || tt.wantReason != "" && reason != tt.wantReason {
+					t.Fatalf("failure_reason = %v, want %q", reason, tt.wantReason)
+				}
+				if got := doc["items"]; tt.wantItems != (got != nil) || tt.wantItems && !reflect.DeepEqual(got, items) {
+					t.Fatalf("items = %v, want %v", got, tt.wantItems)
+				}
+				if amount, _ := doc["amount"].(int); amount != tt.wantAmount {
+					t.Fatalf("amount = %v, want %d", doc["amount"], tt.wantAmount)
+				}
+				first, last := eventTimes(events)
+				if doc["created_at"] != first || doc["updated_at"] != last {
+					t.Fatalf("created_at = %v updated_at = %v, want %v / %v", doc["created_at"], doc["updated_at"], first, last)
+				}
+			}
+		})
+	}
+}
+
+// projectOrder ส่ง Event ตามลำดับผ่าน Update Pipeline เดียวกับที่ updateOrder ส่งให้ MongoDB
+func projectOrder(t *testing.T, events []core.Envelope) bson.M {
+	t.Helper()
+	doc := bson.M{}
+	for _, env := range events {
+		facts, err := orderFacts(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stage := range orderPipeline(facts, env.Timestamp) {
+			doc = evalSet(doc, stage[0].Value.(bson.M))
+		}
+	}
+	return doc
+}
+
+func eventTimes(events []core.Envelope) (time.Time, time.Time) {
+	first, last := events[0].Timestamp, events[0].Timestamp
+	for _, env := range events {
+		if env.Timestamp.Before(first) {
+			first = env.Timestamp
+		}
+		if env.Timestamp.After(last) {
+			last = env.Timestamp
+		}
+	}
+	return first, last
+}
+
+// Aggregation Expression เท่าที่ orders_view ใช้ (ประเมินกับเอกสารก่อน Stage ทุก Field เหมือน $set ของ MongoDB)
+
+type missingField struct{}
+
+const removeField = "$$REMOVE"
+
+func evalSet(doc bson.M, set bson.M) bson.M {
+	out := bson.M{}
+	for field, value := range doc {
+		out[field] = value
+	}
+	for field, expr := range set {
+		value := evalExpr(doc, expr)
+		if value == removeField {
+			delete(out, field)
+			continue
+		}
+		out[field] = value
+	}
+	return out
+}
+
+func evalExpr(doc bson.M, expr any) any {
+	switch e := expr.(type) {
+	case string:
+		if e == removeField || !strings.HasPrefix(e, "$") {
+			return e
+		}
+		if value, ok := doc[e[1:]]; ok {
+			return value
+		}
+		return missingField{}
+	case bson.M:
+		for op, arg := range e {
+			args, _ := arg.(bson.A)
+			switch op {
+			case "$literal":
+				return arg
+			case "$min", "$max":
+				var best any = nil
+				for _, a := range args {
+					v := evalExpr(doc, a)
+					if isNull(v) {
+						continue
+					}
+					if best == nil || (op == "$min") == (compareValues(v, best) < 0) {
+						best = v
+					}
+				}
+				return best
+			case "$gt":
+				return compareValues(evalExpr(doc, args[0]), evalExpr(doc, args[1])) > 0
+			case "$eq":
+				return compareValues(evalExpr(doc, args[0]), evalExpr(doc, args[1])) == 0
+			case "$and":
+				for _, a := range args {
+					if !truthy(evalExpr(doc, a)) {
+						return false
+					}
+				}
+				return true
+			case "$not":
+				return !truthy(evalExpr(doc, args[0]))
+			case "$ifNull":
+				if v := evalExpr(doc, args[0]); !isNull(v) {
+					return v
+				}
+				return evalExpr(doc, args[1])
+			case "$switch":
+				for _, branch := range arg.(bson.M)["branches"].(bson.A) {
+					b := branch.(bson.M)
+					if truthy(evalExpr(doc, b["case"])) {
+						return evalExpr(doc, b["then"])
+					}
+				}
+				return evalExpr(doc, arg.(bson.M)["default"])
+			}
+			panic("unsupported aggregation operator " + op)
+		}
+	}
+	return expr
+}
+
+func isNull(v any) bool {
+	_, missing := v.(missingField)
+	return v == nil || missing
+}
+
+func truthy(v any) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case int:
+		return b != 0
+	}
+	return !isNull(v)
+}
+
+// compareValues เรียงแบบ BSON: ไม่มี Field < null < ตัวเลข < String < วันที่
+func compareValues(a, b any) int {
+	if ra, rb := bsonRank(a), bsonRank(b); ra != rb {
+		return cmp.Compare(ra, rb)
+	}
+	switch x := a.(type) {
+	case int:
+		return cmp.Compare(x, b.(int))
+	case string:
+		return strings.Compare(x, b.(string))
+	case time.Time:
+		return x.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+func bsonRank(v any) int {
+	switch v.(type) {
+	case missingField:
+		return 0
+	case nil:
+		return 1
+	case int:
+		return 2
+	case string:
+		return 3
+	case time.Time:
+		return 9
+	}
+	panic("unsupported value in orders_view expression")
+}
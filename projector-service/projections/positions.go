@@ -0,0 +1,36 @@
+package projections
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// positions อ่าน product_id -> last_version จาก View ที่เก็บ 1 เอกสารต่อ Stream
+// productIDs = nil คือทุกสินค้า
+func positions(ctx context.Context, view *mongo.Collection, productIDs ...string) (map[string]int, error) {
+	filter := bson.M{}
+	if productIDs != nil {
+		filter["product_id"] = bson.M{"$in": productIDs}
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 0, "product_id": 1, "last_version": 1})
+	cursor, err := view.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ProductID   string `bson:"product_id"`
+		LastVersion int    `bson:"last_version"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]int, len(docs))
+	for _, d := range docs {
+		out[d.ProductID] = d.LastVersion
+	}
+	return out, nil
+}
@@ -0,0 +1,364 @@
+package projections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// ErrVersionGap = Event มาก่อน Version ที่อยู่ก่อนหน้า และหา Version ที่ขาดใน Event Store ไม่เจอ
+// (Registry จะ Retry ตาม Policy แล้วส่งเข้า Dead Letter ถ้ายังขาดอยู่)
+var ErrVersionGap = errors.New("version gap")
+
+// ProductsView: events -> products_view
+// ยอดในคลัง / ที่ถูกจอง / ที่ขายไปแล้ว / ที่ขายได้ (Soft Check ของ Orchestrator อ่าน available_stock)
+type ProductsView struct {
+	Collection *mongo.Collection
+	Events     *mongo.Collection // Event Store: อ่าน Version ที่ขาดไปตรงๆ เมื่อเจอ Gap
+}
+
+var (
+	_ ports.Batched    = (*ProductsView)(nil)
+	_ ports.GapFilling = (*ProductsView)(nil)
+)
+
+func NewProductsView(db *mongo.Database) ports.Projection {
+	return &ProductsView{Collection: db.Collection("products_view"), Events: db.Collection("events")}
+}
+
+func (p *ProductsView) Name() string         { return "products_view" }
+func (p *ProductsView) CheckpointID() string { return "main_projector" } // ID เดิมตอนยังมี Projection เดียว
+func (p *ProductsView) Sources() []string    { return []string{p.Events.Name()} }
+func (p *ProductsView) View() string         { return p.Collection.Name() }
+
+// Filter = ทุก Event ของ Stream: last_version ต้องขยับทีละ 1 ถ้ากรอง Type ไหนทิ้งจะกลายเป็น Gap ถาวร
+func (p *ProductsView) Filter() bson.D { return nil }
+
+// Positions คือ Version ล่าสุดของแต่ละสินค้าที่ View มี (ใช้ตอน Recover)
+func (p *ProductsView) Positions(ctx context.Context) (map[string]int, error) {
+	return positions(ctx, p.Collection)
+}
+
+// FillsGaps: apply อ่าน Version ที่ขาดจาก events มาใส่ก่อนเสมอ
+func (p *ProductsView) FillsGaps() {}
+
+// Shadow เขียนลง Collection อื่นด้วย Logic เดียวกัน (ใช้ตอน Rebuild)
+func (p *ProductsView) Shadow(ctx context.Context, collection string) (ports.Projection, error) {
+	view := p.Collection.Database().Collection(collection)
+	_, err := view.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "product_id", Value: 1}}, Options: options.Index().SetUnique(true)})
+	if err != nil {
+		return nil, err
+	}
+	return &ProductsView{Collection: view, Events: p.Events}, nil
+}
+
+// Handle อัปเดตยอดใน products_view ทีละ Version เท่านั้น (Idempotent + ไม่ข้าม Version)
+func (p *ProductsView) Handle(ctx context.Context, env core.Envelope) error {
+	var event core.StockEvent
+	if err := env.Decode(&event); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return apply(ctx, p, event, true)
+}
+
+// stockVersions คือสิ่งที่ apply ใช้จาก View + Event Store (ProductsView ใช้ MongoDB, เทสใช้ของใน RAM)
+type stockVersions interface {
+	// advance เขียน Event ถ้า last_version = event.Version - 1 (Version 1 = Upsert) คืน false ถ้าไม่ Match
+	advance(ctx context.Context, event core.StockEvent) (bool, error)
+	lastVersion(ctx context.Context, productID string) (int, error)
+	// between คืน Event ที่ Version อยู่ระหว่าง (from, to) เรียงตาม Version
+	between(ctx context.Context, productID string, from, to int) ([]core.StockEvent, error)
+}
+
+var _ stockVersions = (*ProductsView)(nil)
+
+// apply ใช้ Conditional Update ครั้งเดียว: เขียนได้ก็ต่อเมื่อ last_version = event.Version - 1
+// (ไม่มีการอ่านก่อนเขียน -> Projector หลายตัว / Rebuild เขียนพร้อมกันก็ไม่นับซ้ำ)
+func apply(ctx context.Context, view stockVersions, event core.StockEvent, catchUp bool) error {
+	move := stockMove(event)
+
+	fmt.Printf("⚡ Processing Event: %s (v.%d) | Available: %+d | Reserved: %+d | Product: %s\n",
+		event.Type, event.Version, move.available(), move.Reserved, event.StreamID)
+
+	// Version 1 ที่ชน Unique Index = มีเอกสารอยู่แล้ว (เคย Process ไปแล้ว) ให้ไปตรวจ Version ด้านล่างเหมือนไม่ Match
+	ok, err := view.advance(ctx, event)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to update view: %w", err)
+	}
+	if err == nil && ok {
+		fmt.Println("   ✅ View Updated Successfully.")
+		return nil
+	}
+
+	// ไม่ Match: เคย Process ไปแล้ว หรือ Version ก่อนหน้ายังมาไม่ถึง (Gap)
+	current, err := view.lastVersion(ctx, event.StreamID)
+	if err != nil {
+		return err
+	}
+	if current >= event.Version {
+		// กฎเหล็ก: ห้ามถอยหลังลงคลอง
+		log.Printf("   ⚠️ Skipped: Event v.%d is older/equal to DB v.%d\n", event.Version, current)
+		return nil
+	}
+	if !catchUp {
+		return fmt.Errorf("%w: %s has v.%d, cannot apply v.%d", ErrVersionGap, event.StreamID, current, event.Version)
+	}
+
+	// Gap: อ่าน Version ที่ขาด (current+1 .. event.Version-1) จาก Event Store มาใส่ก่อน แล้วค่อยใส่ตัวนี้
+	if err := fillGap(ctx, view, event.StreamID, current, event.Version); err != nil {
+		return err
+	}
+	return apply(ctx, view, event, false)
+}
+
+// fillGap อ่าน Event ที่ Version อยู่ระหว่าง (from, to) ของ Stream นี้ แล้ว Apply ตามลำดับ
+// (หาไม่ครบ = Version ที่ขาดตัวแรกหรือ Event ที่มาก่อนจะคืน ErrVersionGap)
+func fillGap(ctx context.Context, view stockVersions, streamID string, from, to int) error {
+	missing, err := view.between(ctx, streamID, from, to)
+	if err != nil {
+		return fmt.Errorf("read missing versions: %w", err)
+	}
+
+	log.Printf("   🩹 Gap on %s: view at v.%d, got v.%d -> applying %d missing event(s) from the event store\n",
+		streamID, from, to, len(missing))
+	for _, e := range missing {
+		if err := apply(ctx, view, e, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ProductsView) advance(ctx context.Context, event core.StockEvent) (bool, error) {
+	filter := bson.M{"product_id": event.StreamID, "last_version": event.Version - 1}
+	update := bson.M{
+		"$inc": stockMove(event).inc(event.Qty),
+		"$set": bson.M{"last_version": event.Version, "updated_at": event.Timestamp},
+	}
+	// Version 1 = สินค้าใหม่ ยังไม่มีเอกสาร -> Upsert สร้าง (ถ้ามีอยู่แล้วจะชน Unique Index)
+	res, err := p.Collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(event.Version == 1))
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount+res.UpsertedCount > 0, nil
+}
+
+func (p *ProductsView) between(ctx context.Context, productID string, from, to int) ([]core.StockEvent, error) {
+	filter := bson.M{"stream_id": productID, "version": bson.M{"$gt": from, "$lt": to}}
+	cursor, err := p.Events.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "version", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var events []core.StockEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ErrBatchConflict = มีตัวอื่นเขียน View ระหว่าง Batch (เช่น Rebuild) บาง Update ไม่ Match
+// Registry จะ Apply ทีละ Event แทน (ตัวที่เขียนไปแล้วถูกข้ามตาม Version)
+var ErrBatchConflict = errors.New("products view changed during batch")
+
+// HandleBatch Apply หลาย Event ด้วย Round Trip คงที่ แทนที่จะเป็น 1-2 ครั้งต่อ Event
+//  1. อ่าน last_version ของทุกสินค้าในกลุ่มด้วย Find เดียว
+//  2. รวม Event ที่ต่อจาก Version นั้นพอดีของแต่ละสินค้าเป็น $inc เดียว (Conditional เหมือน apply: last_version ต้องไม่ขยับ)
+//  3. BulkWrite ทีเดียว แล้วค่อย Apply ตัวที่ต่อไม่ติด (Gap) ทีละตัว
+func (p *ProductsView) HandleBatch(ctx context.Context, envs []core.Envelope) error {
+	events := make([]core.StockEvent, 0, len(envs))
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, env := range envs {
+		var event core.StockEvent
+		if err := env.Decode(&event); err != nil {
+			return err
+		}
+		events = append(events, event)
+		if !seen[event.StreamID] {
+			seen[event.StreamID] = true
+			ids = append(ids, event.StreamID)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	current, err := positions(ctx, p.Collection, ids...)
+	if err != nil {
+		return fmt.Errorf("read versions: %w", err)
+	}
+	deltas, rest := coalesce(events, current)
+
+	if len(deltas) > 0 {
+		models := make([]mongo.WriteModel, 0, len(deltas))
+		for _, d := range deltas {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"product_id": d.ProductID, "last_version": d.From}).
+				SetUpdate(bson.M{
+					"$inc": d.Inc,
+					"$set": bson.M{"last_version": d.To, "updated_at": d.UpdatedAt},
+				}).
+				SetUpsert(d.From == 0)) // Version 1 อยู่ในกลุ่ม = สินค้าใหม่
+		}
+		res, err := p.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return fmt.Errorf("bulk update view: %w", err)
+		}
+		if res.MatchedCount+res.UpsertedCount < int64(len(models)) {
+			return fmt.Errorf("%w: %d of %d updates matched", ErrBatchConflict, res.MatchedCount+res.UpsertedCount, len(models))
+		}
+	}
+	fmt.Printf("⚡ Processing Batch: %d event(s) -> %d product update(s), %d skipped, %d out of order\n",
+		len(events), len(deltas), len(events)-len(rest)-applied(deltas), len(rest))
+
+	for _, event := range rest {
+		if err := apply(ctx, p, event, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// productDelta คือยอดรวมของ Event ที่ต่อเนื่องกันของสินค้า 1 ตัว (Apply ด้วย Update เดียว)
+type productDelta struct {
+	ProductID string
+	From      int // last_version ที่ View ต้องเป็นอยู่ก่อน (0 = ยังไม่มีเอกสาร)
+	To        int // last_version หลัง Apply
+	Inc       bson.M
+	UpdatedAt time.Time
+	Events    int
+}
+
+func applied(deltas []productDelta) int {
+	n := 0
+	for _, d := range deltas {
+		n += d.Events
+	}
+	return n
+}
+
+// coalesce รวม Event ตามสินค้า (ลำดับในกลุ่มของแต่ละสินค้าคงเดิม)
+//   - Version <= ที่ View มี หรือซ้ำในกลุ่ม -> ข้าม (Idempotent)
+//   - ต่อจาก Version ล่าสุดพอดี -> รวมเข้า Delta ของสินค้านั้น
+//   - ต่อไม่ติด (Gap) -> ตัวนั้นและตัวหลังจากนั้นของสินค้าเดียวกันคืนเป็น rest ให้ Apply ทีละตัว
+func coalesce(events []core.StockEvent, current map[string]int) ([]productDelta, []core.StockEvent) {
+	var deltas []productDelta
+	index := map[string]int{} // product_id -> ตำแหน่งใน deltas
+	next := map[string]int{}  // product_id -> Version ล่าสุดที่รวมแล้ว
+	gap := map[string]bool{}
+	var rest []core.StockEvent
+
+	for _, event := range events {
+		id := event.StreamID
+		if gap[id] {
+			rest = append(rest, event)
+			continue
+		}
+		last, ok := next[id]
+		if !ok {
+			last = current[id]
+		}
+		switch {
+		case event.Version <= last:
+			continue
+		case event.Version > last+1:
+			gap[id] = true
+			rest = append(rest, event)
+			continue
+		}
+
+		i, ok := index[id]
+		if !ok {
+			i = len(deltas)
+			index[id] = i
+			deltas = append(deltas, productDelta{ProductID: id, From: last, Inc: bson.M{}})
+		}
+		d := &deltas[i]
+		for field, qty := range stockMove(event).inc(event.Qty) {
+			sum, _ := d.Inc[field].(int)
+			d.Inc[field] = sum + qty.(int)
+		}
+		d.To = event.Version
+		d.Events++
+		if event.Timestamp.After(d.UpdatedAt) {
+			d.UpdatedAt = event.Timestamp
+		}
+		next[id] = event.Version
+	}
+	return deltas, rest
+}
+
+func (p *ProductsView) lastVersion(ctx context.Context, productID string) (int, error) {
+	var doc struct {
+		LastVersion int `bson:"last_version"`
+	}
+	err := p.Collection.FindOne(ctx, bson.M{"product_id": productID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error finding document: %w", err)
+	}
+	return doc.LastVersion, nil
+}
+
+// stockMovement คือยอดที่เปลี่ยนจาก Event 1 ตัว (available_stock = on_hand - reserved เสมอ)
+type stockMovement struct {
+	OnHand    int
+	Reserved  int
+	Committed int
+	Reason    string // Key ใน totals ("" = ไม่นับ)
+}
+
+func (m stockMovement) available() int { return m.OnHand - m.Reserved }
+
+// inc คือ $inc ของ products_view (Field ที่เป็น 0 ก็ส่งไป ให้เอกสารใหม่มีครบทุก Field)
+func (m stockMovement) inc(qty int) bson.M {
+	inc := bson.M{
+		"on_hand":         m.OnHand,
+		"reserved":        m.Reserved,
+		"committed":       m.Committed,
+		"available_stock": m.available(),
+	}
+	if m.Reason != "" {
+		inc["totals."+m.Reason] = qty
+	}
+	return inc
+}
+
+// stockMove แปลง Event เป็นยอดที่เปลี่ยน
+//
+//	StockAdded     -> ของเข้าคลัง
+//	StockReserved  -> ถูกจอง (ยังอยู่ในคลัง แต่ขายไม่ได้)
+//	StockReleased  -> คืนที่จอง
+//	StockCommitted -> ที่จองถูกขายจริง ออกจากคลัง (ยอดที่ขายได้ไม่เปลี่ยน)
+func stockMove(event core.StockEvent) stockMovement {
+	switch event.Type {
+	case "StockAdded":
+		return stockMovement{OnHand: event.Qty, Reason: "added"}
+	case "StockReserved":
+		return stockMovement{Reserved: event.Qty, Reason: "reserved"}
+	case "StockReleased":
+		return stockMovement{Reserved: -event.Qty, Reason: "released"}
+	case "StockCommitted":
+		return stockMovement{OnHand: -event.Qty, Reserved: -event.Qty, Committed: event.Qty, Reason: "committed"}
+	case "StockReservationRejected":
+		// ยอดไม่เปลี่ยน แต่ต้องขยับ last_version ให้ตาม Stream (Consistency Token อ้างถึง Version นี้ได้)
+		return stockMovement{Reason: "rejected"}
+	default:
+		// Event ที่ไม่รู้จัก: ไม่เปลี่ยนยอด แต่ยังต้องขยับ Version ไม่งั้น Version ถัดไปจะติด Gap
+		log.Printf("   ℹ️ Unknown event type %q (v.%d): version advanced without stock change\n", event.Type, event.Version)
+		return stockMovement{}
+	}
+}
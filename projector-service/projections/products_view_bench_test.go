@@ -0,0 +1,131 @@
+package projections
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	mongoAdapter "projector-service/adapters/mongo"
+	"projector-service/core"
+)
+
+// Benchmark เทียบการไล่ Event ทีละตัวกับโหมด Batch บน MongoDB จริง (ต่อไม่ได้ = ข้าม)
+// นับรวมการบันทึก Checkpoint ด้วย: ทีละตัว = ทุก Event, Batch = ครั้งเดียวต่อกลุ่ม
+//
+//	cd projector-service && go test -run '^$' -bench ProductsViewReplay -benchtime 3x ./projections/ > /tmp/bench.txt
+//
+// (ProductsView พิมพ์ Log ทุก Event จึงควร Redirect Output ไปไฟล์แล้วดูบรรทัดท้าย)
+func BenchmarkProductsViewReplay(b *testing.B) {
+	db := benchDB(b)
+	events := benchEvents(b, 5000, 50)
+
+	for _, size := range []int{1, 100, 1000} {
+		name := "single"
+		if size > 1 {
+			name = fmt.Sprintf("batch-%d", size)
+		}
+		b.Run(name, func(b *testing.B) {
+			ctx := context.Background()
+			checkpoints := mongoAdapter.NewMongoCheckpointStore(db)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				p := freshProductsView(b, db)
+				b.StartTimer()
+
+				for start := 0; start < len(events); start += size {
+					chunk := events[start:min(start+size, len(events))]
+					if err := replay(ctx, p, chunk); err != nil {
+						b.Fatal(err)
+					}
+					if err := checkpoints.Save(ctx, "bench_products_view", chunkToken(start), chunk[len(chunk)-1].Timestamp); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			b.ReportMetric(float64(len(events)*b.N)/b.Elapsed().Seconds(), "events/s")
+		})
+	}
+}
+
+func replay(ctx context.Context, p *ProductsView, events []core.Envelope) error {
+	if len(events) == 1 {
+		return p.Handle(ctx, events[0])
+	}
+	return p.HandleBatch(ctx, events)
+}
+
+func benchDB(b *testing.B) *mongo.Database {
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017/?directConnection=true"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err == nil {
+		err = client.Ping(ctx, nil)
+	}
+	if err != nil {
+		b.Skipf("MongoDB not available at %s: %v", uri, err)
+	}
+	db := client.Database("projector_bench")
+	b.Cleanup(func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return db
+}
+
+// freshProductsView ล้าง View ให้ทุกรอบเริ่มจาก Replay ทั้งหมดเหมือนกัน
+func freshProductsView(b *testing.B, db *mongo.Database) *ProductsView {
+	ctx := context.Background()
+	if err := db.Collection("products_view").Drop(ctx); err != nil {
+		b.Fatal(err)
+	}
+	p := &ProductsView{Collection: db.Collection("products_view"), Events: db.Collection("events")}
+	shadow, err := p.Shadow(ctx, "products_view") // สร้าง Unique Index เหมือนของจริง
+	if err != nil {
+		b.Fatal(err)
+	}
+	return shadow.(*ProductsView)
+}
+
+// benchEvents สร้าง Event ของสินค้า products ตัวสลับกันไป (เหมือน Oplog จริงที่หลาย Stream ปนกัน)
+func benchEvents(b *testing.B, n, products int) []core.Envelope {
+	types := []string{"StockReserved", "StockCommitted", "StockReserved", "StockReleased"}
+	events := make([]core.Envelope, 0, n)
+	for i := 0; i < n; i++ {
+		version := i/products + 1
+		eventType := "StockAdded"
+		if version > 1 {
+			eventType = types[(version-2)%len(types)]
+		}
+		raw, err := bson.Marshal(bson.M{
+			"stream_id": fmt.Sprintf("product-%03d", i%products),
+			"type":      eventType,
+			"qty":       1,
+			"version":   version,
+			"timestamp": time.Unix(int64(i), 0),
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		env, err := core.NewEnvelope("events", raw)
+		if err != nil {
+			b.Fatal(err)
+		}
+		events = append(events, env)
+	}
+	return events
+}
+
+func chunkToken(i int) bson.Raw {
+	raw, _ := bson.Marshal(bson.M{"i": i})
+	return raw
+}
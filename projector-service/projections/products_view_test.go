@@ -0,0 +1,165 @@
+package projections
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"projector-service/core"
+)
+
+func TestStockMoveKeepsAvailableEqualToOnHandMinusReserved(t *testing.T) {
+	events := []core.StockEvent{
+		{Type: "StockAdded", Qty: 10},
+		{Type: "StockReserved", Qty: 3},  // Order A
+		{Type: "StockReserved", Qty: 2},  // Order B
+		{Type: "StockCommitted", Qty: 3}, // A ตัดเงินผ่าน
+		{Type: "StockReleased", Qty: 2},  // B ตัดเงินไม่ผ่าน
+		{Type: "StockReservationRejected", Qty: 50},
+	}
+
+	var onHand, reserved, committed, available int
+	totals := map[string]int{}
+	for _, e := range events {
+		m := stockMove(e)
+		onHand += m.OnHand
+		reserved += m.Reserved
+		committed += m.Committed
+		available += m.available()
+		totals[m.Reason] += e.Qty
+	}
+
+	if onHand != 7 || reserved != 0 || committed != 3 || available != 7 {
+		t.Fatalf("on_hand=%d reserved=%d committed=%d available=%d, want 7/0/3/7", onHand, reserved, committed, available)
+	}
+	want := map[string]int{"added": 10, "reserved": 5, "committed": 3, "released": 2, "rejected": 50}
+	for reason, qty := range want {
+		if totals[reason] != qty {
+			t.Fatalf("totals[%s] = %d, want %d", reason, totals[reason], qty)
+		}
+	}
+}
+
+func TestCoalesceMergesContiguousVersionsPerProduct(t *testing.T) {
+	events := []core.StockEvent{
+		{StreamID: "iphone-15", Type: "StockReserved", Qty: 1, Version: 4}, // View มีแล้ว -> ข้าม
+		{StreamID: "iphone-15", Type: "StockReserved", Qty: 2, Version: 5},
+		{StreamID: "ipad-air", Type: "StockAdded", Qty: 10, Version: 1}, // สินค้าใหม่
+		{StreamID: "iphone-15", Type: "StockCommitted", Qty: 2, Version: 6},
+		{StreamID: "iphone-15", Type: "StockCommitted", Qty: 2, Version: 6}, // ซ้ำในกลุ่ม -> ข้าม
+		{StreamID: "macbook-pro", Type: "StockAdded", Qty: 5, Version: 9},   // Gap (View อยู่ v.7)
+		{StreamID: "macbook-pro", Type: "StockReserved", Qty: 1, Version: 10},
+	}
+	current := map[string]int{"iphone-15": 4, "macbook-pro": 7}
+
+	deltas, rest := coalesce(events, current)
+
+	if len(deltas) != 2 {
+		t.Fatalf("want 2 deltas, got %+v", deltas)
+	}
+	iphone, ipad := deltas[0], deltas[1]
+	if iphone.ProductID != "iphone-15" || iphone.From != 4 || iphone.To != 6 || iphone.Events != 2 {
+		t.Fatalf("unexpected iphone delta: %+v", iphone)
+	}
+	// จอง 2 แล้วขาย 2: ที่จองกลับเป็นเดิม ของออกจากคลัง 2
+	if iphone.Inc["reserved"] != 0 || iphone.Inc["on_hand"] != -2 || iphone.Inc["committed"] != 2 || iphone.Inc["available_stock"] != -2 {
+		t.Fatalf("unexpected iphone inc: %v", iphone.Inc)
+	}
+	if iphone.Inc["totals.reserved"] != 2 || iphone.Inc["totals.committed"] != 2 {
+		t.Fatalf("unexpected iphone totals: %v", iphone.Inc)
+	}
+	if ipad.From != 0 || ipad.To != 1 || ipad.Inc["on_hand"] != 10 {
+		t.Fatalf("unexpected ipad delta: %+v", ipad)
+	}
+	// ต่อไม่ติดทั้ง Stream -> Apply ทีละตัว (เติม Gap จาก Event Store)
+	if len(rest) != 2 || rest[0].Version != 9 || rest[1].Version != 10 {
+		t.Fatalf("unexpected rest: %+v", rest)
+	}
+}
+
+// memoryVersions คือ products_view + Event Store ใน RAM ที่ตอบแบบ MongoDB
+// (Version 1 ของสินค้าที่มีเอกสารแล้ว = Upsert ชน Unique Index)
+type memoryVersions struct {
+	view    map[string]int               // product_id -> last_version
+	events  map[string][]core.StockEvent // Event Store ของแต่ละสินค้า
+	applied []int                        // Version ที่เขียนลง View ตามลำดับ
+}
+
+func (m *memoryVersions) advance(ctx context.Context, event core.StockEvent) (bool, error) {
+	last, exists := m.view[event.StreamID]
+	if last != event.Version-1 || (event.Version == 1 && exists) {
+		if event.Version == 1 {
+			return false, mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "duplicate key"}}}
+		}
+		return false, nil
+	}
+	m.view[event.StreamID] = event.Version
+	m.applied = append(m.applied, event.Version)
+	return true, nil
+}
+
+func (m *memoryVersions) lastVersion(ctx context.Context, productID string) (int, error) {
+	return m.view[productID], nil
+}
+
+func (m *memoryVersions) between(ctx context.Context, productID string, from, to int) ([]core.StockEvent, error) {
+	var out []core.StockEvent
+	for _, e := range m.events[productID] {
+		if e.Version > from && e.Version < to {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func stockEvents(versions ...int) []core.StockEvent {
+	events := make([]core.StockEvent, 0, len(versions))
+	for _, v := range versions {
+		events = append(events, core.StockEvent{StreamID: "iphone-15", Type: "StockReserved", Qty: 1, Version: v})
+	}
+	return events
+}
+
+func TestApplyKeepsVersionsContiguous(t *testing.T) {
+	tests := []struct {
+		name        string
+		viewAt      int // 0 = ยังไม่มีเอกสาร
+		store       []int
+		event       int
+		wantErr     error
+		wantApplied []int
+		wantVersion int
+	}{
+		{name: "next version applies", viewAt: 3, event: 4, wantApplied: []int{4}, wantVersion: 4},
+		{name: "new product upserts v1", event: 1, wantApplied: []int{1}, wantVersion: 1},
+		{name: "already applied version is skipped", viewAt: 3, event: 2, wantVersion: 3},
+		{name: "v1 duplicate key is skipped", viewAt: 3, event: 1, wantVersion: 3},
+		{name: "gap is filled from the event store first", viewAt: 1, store: []int{2, 3}, event: 4, wantApplied: []int{2, 3, 4}, wantVersion: 4},
+		{name: "missing first version is a gap", viewAt: 1, store: []int{3}, event: 4, wantErr: ErrVersionGap, wantVersion: 1},
+		{name: "missing version in the middle is a gap", viewAt: 1, store: []int{2, 4}, event: 5, wantErr: ErrVersionGap, wantApplied: []int{2}, wantVersion: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := &memoryVersions{view: map[string]int{}, events: map[string][]core.StockEvent{"iphone-15": stockEvents(tt.store...)}}
+			if tt.viewAt > 0 {
+				view.view["iphone-15"] = tt.viewAt
+			}
+
+			err := apply(context.Background(), view, stockEvents(tt.event)[0], true)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(view.applied, tt.wantApplied) {
+				t.Fatalf("applied %v, want %v", view.applied, tt.wantApplied)
+			}
+			if got := view.view["iphone-15"]; got != tt.wantVersion {
+				t.Fatalf("last_version = %d, want %d", got, tt.wantVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package projections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// ช่วงเวลาของ Bucket ใน sales_analytics
+const (
+	GranularityHour = "hour"
+	GranularityDay  = "day"
+)
+
+// SalesAnalytics: events + payment_events -> sales_analytics (ยอดต่อสินค้าต่อชั่วโมง / ต่อวัน ตาม UTC)
+//
+// 1 Event บวกเข้า 2 Bucket (ชั่วโมง + วันของ Timestamp) ด้วย $inc
+// $inc ไม่ Idempotent เอง จึงบันทึก Key ของ Event ลง sales_analytics_applied (Key เป็น _id = Unique)
+// ใน Transaction เดียวกับ $inc: Key ชน = บวกไปแล้ว (Replay / Reset / Event ซ้ำหลัง Restart = ไม่นับซ้ำ)
+//
+// Key ผูกกับ Generation ของ View (เอกสาร _id "generation" ใน View เอง) ไม่ใช่ชื่อ Collection
+// Rebuild จึง Swap ชุด Key ไปพร้อม View ใน Rename เดียว: View ใหม่มี Generation ใหม่ที่ยังไม่มี Key ของ Event หลัง Swap
+type SalesAnalytics struct {
+	Collection *mongo.Collection
+	Applied    *mongo.Collection // Key ของ Event ที่บวกแล้ว ใช้ร่วมกันทุก Generation
+	Events     *mongo.Collection // ใช้หาสินค้าของ Order ให้ Event การจ่ายเงิน
+}
+
+var (
+	_ ports.Rebuildable = (*SalesAnalytics)(nil)
+	_ salesBuckets      = (*SalesAnalytics)(nil)
+)
+
+const (
+	salesAppliedCollection = "sales_analytics_applied"
+	generationID           = "generation"
+)
+
+// errAlreadyApplied: Event นี้ถูกบวกเข้า View นี้ไปแล้ว (Abort Transaction แล้วข้าม)
+var errAlreadyApplied = errors.New("sales event already applied")
+
+func NewSalesAnalytics(db *mongo.Database) ports.Projection {
+	return &SalesAnalytics{
+		Collection: db.Collection("sales_analytics"),
+		Applied:    db.Collection(salesAppliedCollection),
+		Events:     db.Collection("events"),
+	}
+}
+
+// ExpireSalesApplied ตั้ง TTL ให้ Key ใน sales_analytics_applied (ไม่เรียก = เก็บตลอดไป)
+// มี TTL เดิมอยู่แล้ว = เปลี่ยนเวลาด้วย collMod
+func ExpireSalesApplied(ctx context.Context, db *mongo.Database, ttl time.Duration) error {
+	seconds := max(int32(ttl/time.Second), 1)
+	applied := db.Collection(salesAppliedCollection)
+	_, err := applied.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "applied_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(seconds),
+	})
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == 85 { // IndexOptionsConflict
+		err = db.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: applied.Name()},
+			{Key: "index", Value: bson.M{"keyPattern": bson.M{"applied_at": 1}, "expireAfterSeconds": seconds}},
+		}).Err()
+	}
+	return err
+}
+
+func (p *SalesAnalytics) Name() string         { return "sales_analytics" }
+func (p *SalesAnalytics) CheckpointID() string { return "sales_analytics" }
+func (p *SalesAnalytics) Sources() []string    { return []string{"events", "payment_events"} }
+func (p *SalesAnalytics) View() string         { return p.Collection.Name() }
+
+func (p *SalesAnalytics) Filter() bson.D {
+	return bson.D{{Key: "fullDocument.type", Value: bson.M{"$in": bson.A{
+		"StockReserved", "StockReleased", "StockCommitted", "StockReservationRejected",
+		"PaymentProcessed", "PaymentFailed",
+	}}}}
+}
+
+// Shadow เขียนลง Collection อื่นด้วย Logic เดียวกัน (ใช้ตอน Rebuild)
+func (p *SalesAnalytics) Shadow(ctx context.Context, collection string) (ports.Projection, error) {
+	view := p.Collection.Database().Collection(collection)
+	_, err := view.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "granularity", Value: 1}, {Key: "bucket", Value: 1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Key ของ Generation อื่น (View ที่ถูก Swap ทับไปแล้ว / Shadow ที่ค้างจาก Rebuild ก่อน) ไม่มีใครใช้อีก
+	// Shadow ใหม่จะได้ Generation ใหม่ตอนบวก Event แรก
+	live, err := p.generation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.Applied.DeleteMany(ctx, bson.M{"generation": bson.M{"$ne": live}}); err != nil {
+		return nil, err
+	}
+	return &SalesAnalytics{Collection: view, Applied: p.Applied, Events: p.Events}, nil
+}
+
+func (p *SalesAnalytics) Handle(ctx context.Context, env core.Envelope) error {
+	delta, err := salesDelta(env)
+	if err != nil || delta == nil {
+		return err // Type ที่ไม่นับ ข้ามไป
+	}
+	key, err := eventKey(env)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	productID := env.StreamID
+	if env.Source == "payment_events" {
+		if productID, err = p.productOf(ctx, env.OrderID); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("⚡ Processing Event: %s/%s | Product: %s\n", env.Source, env.Type, productID)
+	session, err := p.Collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, applySale(sc, p, productID, env.Timestamp, key, delta)
+	})
+	if errors.Is(err, errAlreadyApplied) {
+		fmt.Printf("⏭️  Skip: %s already counted\n", key)
+		return nil
+	}
+	return err
+}
+
+// salesBuckets คือสิ่งที่ applySale ใช้จาก View (แยกไว้ให้ Test ด้วย Fake ได้)
+type salesBuckets interface {
+	generation(ctx context.Context) (string, error)
+	markApplied(ctx context.Context, generation, key string) error
+	add(ctx context.Context, productID, granularity string, bucket time.Time, delta bson.M) error
+}
+
+// applySale บันทึก Key ของ Event ใน Generation ปัจจุบัน แล้วบวก delta เข้า Bucket ชั่วโมง + วัน
+// Key ชน = errAlreadyApplied (ต้องรันใน Transaction: Key กับ $inc ต้องลงด้วยกันหรือไม่ลงเลย)
+func applySale(ctx context.Context, view salesBuckets, productID string, at time.Time, key string, delta bson.M) error {
+	generation, err := view.generation(ctx)
+	if err != nil {
+		return err
+	}
+	if err := view.markApplied(ctx, generation, key); mongo.IsDuplicateKeyError(err) {
+		return errAlreadyApplied
+	} else if err != nil {
+		return err
+	}
+
+	at = at.UTC()
+	for _, b := range []struct {
+		granularity string
+		start       time.Time
+	}{
+		{GranularityHour, at.Truncate(time.Hour)},
+		{GranularityDay, time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)},
+	} {
+		if err := view.add(ctx, productID, b.granularity, b.start, delta); err != nil {
+			return fmt.Errorf("failed to update %s bucket: %w", b.granularity, err)
+		}
+	}
+	return nil
+}
+
+// generation คืน Generation ของ View (ยังไม่มี = สร้างใหม่ เช่น Shadow ที่เพิ่งสร้าง)
+func (p *SalesAnalytics) generation(ctx context.Context) (string, error) {
+	var doc struct {
+		Value string `bson:"value"`
+	}
+	err := p.Collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": generationID},
+		bson.M{"$setOnInsert": bson.M{"value": primitive.NewObjectID().Hex(), "created_at": time.Now().UTC()}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	return doc.Value, err
+}
+
+// markApplied บันทึก Key (ซ้ำ = Duplicate Key Error จาก _id)
+func (p *SalesAnalytics) markApplied(ctx context.Context, generation, key string) error {
+	_, err := p.Applied.InsertOne(ctx, bson.M{
+		"_id":        generation + "|" + key,
+		"generation": generation,
+		"applied_at": time.Now().UTC(),
+	})
+	return err
+}
+
+// add บวก delta เข้า Bucket เดียว (ยังไม่มี Bucket = Upsert สร้าง)
+func (p *SalesAnalytics) add(ctx context.Context, productID, granularity string, bucket time.Time, delta bson.M) error {
+	id := fmt.Sprintf("%s|%s|%s", productID, granularity, bucket.Format(time.RFC3339))
+	_, err := p.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$inc":         delta,
+		"$set":         bson.M{"updated_at": time.Now().UTC()},
+		"$setOnInsert": bson.M{"product_id": productID, "granularity": granularity, "bucket": bucket},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+// productOf หาสินค้าของ Order จากการจองสต็อก (Event การจ่ายเงินไม่มี product_id)
+// ยังไม่เจอ = คืน Error ให้ Retry / Dead Letter (การจองต้องมาก่อนการจ่ายเงินเสมอ)
+func (p *SalesAnalytics) productOf(ctx context.Context, orderID string) (string, error) {
+	if orderID == "" {
+		return "", errors.New("payment event without order_id")
+	}
+	var reservation struct {
+		ProductID string `bson:"stream_id"`
+	}
+	err := p.Events.FindOne(ctx, bson.M{"order_id": orderID, "type": "StockReserved"},
+		options.FindOne().SetProjection(bson.M{"stream_id": 1})).Decode(&reservation)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", fmt.Errorf("no stock reservation for order %s", orderID)
+	}
+	return reservation.ProductID, err
+}
+
+// salesDelta คือตัวนับที่ Event นี้บวกเพิ่ม (nil = Event ที่ไม่นับ)
+func salesDelta(env core.Envelope) (bson.M, error) {
+	switch env.Source {
+	case "events":
+		var event core.StockEvent
+		if err := env.Decode(&event); err != nil {
+			return nil, err
+		}
+		switch event.Type {
+		case "StockReserved":
+			return bson.M{"reserved": event.Qty}, nil
+		case "StockReleased":
+			return bson.M{"released": event.Qty}, nil
+		case "StockCommitted":
+			return bson.M{"sold": event.Qty}, nil
+		case "StockReservationRejected":
+			return bson.M{"rejected": event.Qty}, nil
+		}
+	case "payment_events":
+		var event core.PaymentEvent
+		if err := env.Decode(&event); err != nil {
+			return nil, err
+		}
+		switch event.Type {
+		case "PaymentProcessed":
+			return bson.M{"orders_paid": 1, "revenue": event.Amount}, nil
+		case "PaymentFailed":
+			return bson.M{"payments_failed": 1}, nil
+		}
+	}
+	return nil, nil
+}
+
+// eventKey ระบุ Event แบบไม่ซ้ำข้าม Source: Stream สินค้าใช้ stream_id + Version ส่วน Source อื่นใช้ _id
+func eventKey(env core.Envelope) (string, error) {
+	if env.Version > 0 {
+		return fmt.Sprintf("%s:%s:%d", env.Source, env.StreamID, env.Version), nil
+	}
+	id, err := env.Document.LookupErr("_id")
+	if err != nil {
+		return "", fmt.Errorf("%s event without _id", env.Source)
+	}
+	if oid, ok := id.ObjectIDOK(); ok {
+		return env.Source + ":" + oid.Hex(), nil
+	}
+	if s, ok := id.StringValueOK(); ok {
+		return env.Source + ":" + s, nil
+	}
+	return env.Source + ":" + id.String(), nil
+}
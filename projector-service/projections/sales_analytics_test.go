@@ -0,0 +1,136 @@
+package projections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"projector-service/core"
+)
+
+func envelope(t *testing.T, source string, doc bson.M) core.Envelope {
+	t.Helper()
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := core.NewEnvelope(source, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+func TestSalesDeltaCountsStockAndPaymentEvents(t *testing.T) {
+	at := time.Date(2026, 3, 1, 10, 15, 0, 0, time.UTC)
+	cases := []struct {
+		source string
+		doc    bson.M
+		want   bson.M
+	}{
+		{"events", bson.M{"stream_id": "iphone-15", "type": "StockReserved", "qty": 2, "version": 3, "timestamp": at}, bson.M{"reserved": 2}},
+		{"events", bson.M{"stream_id": "iphone-15", "type": "StockReleased", "qty": 2, "version": 4, "timestamp": at}, bson.M{"released": 2}},
+		{"events", bson.M{"stream_id": "iphone-15", "type": "StockCommitted", "qty": 1, "version": 5, "timestamp": at}, bson.M{"sold": 1}},
+		{"events", bson.M{"stream_id": "iphone-15", "type": "StockReservationRejected", "qty": 9, "version": 6, "timestamp": at}, bson.M{"rejected": 9}},
+		{"events", bson.M{"stream_id": "iphone-15", "type": "StockAdded", "qty": 50, "version": 1, "timestamp": at}, nil},
+		{"payment_events", bson.M{"order_id": "o-1", "type": "PaymentProcessed", "amount": 35000, "timestamp": at}, bson.M{"orders_paid": 1, "revenue": 35000}},
+		{"payment_events", bson.M{"order_id": "o-2", "type": "PaymentFailed", "amount": 35000, "timestamp": at}, bson.M{"payments_failed": 1}},
+	}
+	for _, c := range cases {
+		got, err := salesDelta(envelope(t, c.source, c.doc))
+		if err != nil {
+			t.Fatalf("%s: %v", c.doc["type"], err)
+		}
+		if len(got) != len(c.want) {
+			t.Fatalf("%s: delta = %v, want %v", c.doc["type"], got, c.want)
+		}
+		for field, n := range c.want {
+			if got[field] != n {
+				t.Fatalf("%s: delta[%s] = %v, want %v", c.doc["type"], field, got[field], n)
+			}
+		}
+	}
+}
+
+func TestEventKeyIsStableAcrossDeliveries(t *testing.T) {
+	stock := bson.M{"stream_id": "iphone-15", "type": "StockReserved", "qty": 1, "version": 7}
+	if got, _ := eventKey(envelope(t, "events", stock)); got != "events:iphone-15:7" {
+		t.Fatalf("stock key = %q", got)
+	}
+
+	id := primitive.NewObjectID()
+	payment := bson.M{"_id": id, "order_id": "o-1", "type": "PaymentProcessed"}
+	first, _ := eventKey(envelope(t, "payment_events", payment))
+	again, _ := eventKey(envelope(t, "payment_events", payment))
+	if first != "payment_events:"+id.Hex() || again != first {
+		t.Fatalf("payment keys = %q / %q", first, again)
+	}
+
+	if _, err := eventKey(envelope(t, "payment_events", bson.M{"order_id": "o-1", "type": "PaymentFailed"})); err == nil {
+		t.Fatal("expected error for payment event without _id")
+	}
+}
+
+// memorySales คือ sales_analytics + sales_analytics_applied ใน RAM (Key ซ้ำ = Duplicate Key เหมือน _id)
+type memorySales struct {
+	current string                    // Generation ของ View ปัจจุบัน
+	applied map[string]bool           // <generation>|<key>
+	buckets map[string]map[string]int // <product>|<granularity>|<bucket> -> Counter
+}
+
+func (m *memorySales) generation(ctx context.Context) (string, error) { return m.current, nil }
+
+func (m *memorySales) markApplied(ctx context.Context, generation, key string) error {
+	if m.applied[generation+"|"+key] {
+		return mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "duplicate key"}}}
+	}
+	m.applied[generation+"|"+key] = true
+	return nil
+}
+
+func (m *memorySales) add(ctx context.Context, productID, granularity string, bucket time.Time, delta bson.M) error {
+	id := fmt.Sprintf("%s|%s|%s", productID, granularity, bucket.Format(time.RFC3339))
+	if m.buckets[id] == nil {
+		m.buckets[id] = map[string]int{}
+	}
+	for field, n := range delta {
+		m.buckets[id][field] += n.(int)
+	}
+	return nil
+}
+
+func TestApplySaleCountsEachEventOncePerGeneration(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 10, 15, 0, 0, time.UTC)
+	hour, day := "iphone-15|hour|2026-03-01T10:00:00Z", "iphone-15|day|2026-03-01T00:00:00Z"
+	view := &memorySales{current: "g1", applied: map[string]bool{}, buckets: map[string]map[string]int{}}
+
+	if err := applySale(ctx, view, "iphone-15", at, "events:iphone-15:3", bson.M{"reserved": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applySale(ctx, view, "iphone-15", at, "events:iphone-15:4", bson.M{"reserved": 1}); err != nil {
+		t.Fatal(err)
+	}
+	// Replay / Reset ลง View เดิม: Key ชน -> ไม่บวกซ้ำ
+	if err := applySale(ctx, view, "iphone-15", at, "events:iphone-15:3", bson.M{"reserved": 2}); !errors.Is(err, errAlreadyApplied) {
+		t.Fatalf("replayed event: err = %v, want errAlreadyApplied", err)
+	}
+	if view.buckets[hour]["reserved"] != 3 || view.buckets[day]["reserved"] != 3 {
+		t.Fatalf("reserved hour/day = %d/%d, want 3/3", view.buckets[hour]["reserved"], view.buckets[day]["reserved"])
+	}
+
+	// View ใหม่หลัง Swap มี Generation ของตัวเอง: Key ของ View เก่าไม่ทำให้ Event หายจาก View ใหม่
+	rebuilt := &memorySales{current: "g2", applied: view.applied, buckets: map[string]map[string]int{}}
+	if err := applySale(ctx, rebuilt, "iphone-15", at, "events:iphone-15:3", bson.M{"reserved": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt.buckets[hour]["reserved"] != 2 || view.buckets[hour]["reserved"] != 3 {
+		t.Fatalf("reserved rebuilt/old = %d/%d, want 2/3", rebuilt.buckets[hour]["reserved"], view.buckets[hour]["reserved"])
+	}
+}
@@ -0,0 +1,232 @@
+package projections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"projector-service/core"
+	"projector-service/ports"
+)
+
+// ErrLevelConflict = มีตัวอื่นเขียน stock_levels ของสินค้านี้ไปก่อน (Registry จะ Retry แล้ว Version นั้นถูกข้าม)
+var ErrLevelConflict = errors.New("stock level changed concurrently")
+
+// StockAlerts: events -> stock_levels + stock_alerts แล้วส่ง Alert ออกไปที่ Sink
+//
+// stock_levels เก็บยอดที่ขายได้ + ระดับล่าสุดของแต่ละสินค้า (ขยับทีละ Version เหมือน products_view)
+// ระดับแย่ลงข้ามเส้น = Alert 1 เอกสารใน stock_alerts (_id มาจาก Version ที่ข้ามเส้น -> Replay ไม่สร้างซ้ำ)
+// ส่งครบทุก Sink แล้วจึงเป็น SENT ส่งไม่ผ่าน = คืน Error ให้ Registry Retry (ส่งเฉพาะ Sink ที่ยังไม่ได้)
+type StockAlerts struct {
+	Levels *mongo.Collection
+	Alerts *mongo.Collection
+	Events *mongo.Collection // Event Store: อ่าน Version ที่ขาดไปเมื่อเจอ Gap
+	Points core.ReorderPoints
+	Sinks  []ports.Notifier
+	MaxAge time.Duration // Alert ของ Event ที่เก่ากว่านี้ (ไล่ History ครั้งแรก / Reset) ไม่ส่ง (0 = ส่งทุกตัว)
+}
+
+var _ ports.GapFilling = (*StockAlerts)(nil)
+
+func NewStockAlerts(db *mongo.Database, points core.ReorderPoints, sinks []ports.Notifier, maxAge time.Duration) ports.Projection {
+	return &StockAlerts{
+		Levels: db.Collection("stock_levels"),
+		Alerts: db.Collection("stock_alerts"),
+		Events: db.Collection("events"),
+		Points: points,
+		Sinks:  sinks,
+		MaxAge: maxAge,
+	}
+}
+
+func (p *StockAlerts) Name() string         { return "stock_alerts" }
+func (p *StockAlerts) CheckpointID() string { return "stock_alerts" }
+func (p *StockAlerts) Sources() []string    { return []string{p.Events.Name()} }
+
+// Filter = ทุก Event ของ Stream (ยอดต้องขยับทีละ Version เหมือน products_view)
+func (p *StockAlerts) Filter() bson.D { return nil }
+
+func (p *StockAlerts) Positions(ctx context.Context) (map[string]int, error) {
+	return positions(ctx, p.Levels)
+}
+
+// FillsGaps: Handle อ่าน Version ที่ขาดจาก events มาคำนวณระดับก่อนเสมอ
+func (p *StockAlerts) FillsGaps() {}
+
+// stockLevel คือเอกสารใน stock_levels
+type stockLevel struct {
+	ProductID    string    `bson:"product_id"`
+	Available    int       `bson:"available_stock"`
+	Level        string    `bson:"level"`
+	ReorderPoint int       `bson:"reorder_point"`
+	LastVersion  int       `bson:"last_version"`
+	UpdatedAt    time.Time `bson:"updated_at"`
+}
+
+func (p *StockAlerts) Handle(ctx context.Context, env core.Envelope) error {
+	var event core.StockEvent
+	if err := env.Decode(&event); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	current, err := p.level(ctx, event.StreamID)
+	if err != nil {
+		return err
+	}
+	if event.Version <= current.LastVersion {
+		// เคยนับแล้ว: อาจค้างส่ง Alert จากรอบก่อน (Sink ล่ม / Restart ระหว่างส่ง)
+		return p.deliver(ctx, event.StreamID)
+	}
+
+	events := []core.StockEvent{event}
+	if event.Version > current.LastVersion+1 {
+		missing, err := p.missing(ctx, event.StreamID, current.LastVersion, event.Version)
+		if err != nil {
+			return err
+		}
+		events = append(missing, event)
+	}
+
+	next, alerts := detectAlerts(current, events, p.Points.For(event.StreamID))
+	// บันทึก Alert ก่อนขยับ Version: พังระหว่างนี้ Retry จะได้ Alert _id เดิม (ไม่ซ้ำ ไม่หาย)
+	for _, alert := range alerts {
+		if err := p.record(ctx, alert); err != nil {
+			return err
+		}
+	}
+	if err := p.save(ctx, current.LastVersion, next); err != nil {
+		return err
+	}
+	return p.deliver(ctx, event.StreamID)
+}
+
+// detectAlerts ไล่ Event (Version ต่อเนื่องจาก current) แล้วคืนระดับใหม่ + Alert ของทุกครั้งที่ข้ามเส้น
+func detectAlerts(current stockLevel, events []core.StockEvent, reorderPoint int) (stockLevel, []core.StockAlert) {
+	next := current
+	next.ReorderPoint = reorderPoint
+	var alerts []core.StockAlert
+	for _, e := range events {
+		next.ProductID = e.StreamID
+		next.Available += stockMove(e).available()
+		level := core.StockLevelOf(next.Available, reorderPoint)
+		if alertType := core.Crossing(next.Level, level); alertType != "" {
+			alerts = append(alerts, core.NewStockAlert(alertType, e.StreamID, next.Available, reorderPoint, e))
+		}
+		next.Level = level
+		next.LastVersion = e.Version
+		next.UpdatedAt = e.Timestamp
+	}
+	return next, alerts
+}
+
+func (p *StockAlerts) level(ctx context.Context, productID string) (stockLevel, error) {
+	var doc stockLevel
+	err := p.Levels.FindOne(ctx, bson.M{"product_id": productID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return stockLevel{ProductID: productID}, nil
+	}
+	if err != nil {
+		return doc, fmt.Errorf("read stock level: %w", err)
+	}
+	return doc, nil
+}
+
+// missing อ่าน Event ที่ Version อยู่ระหว่าง (from, to) ต้องได้ครบทุก Version ไม่งั้นยอดจะผิด
+func (p *StockAlerts) missing(ctx context.Context, productID string, from, to int) ([]core.StockEvent, error) {
+	filter := bson.M{"stream_id": productID, "version": bson.M{"$gt": from, "$lt": to}}
+	cursor, err := p.Events.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "version", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("read missing versions: %w", err)
+	}
+	var events []core.StockEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("read missing versions: %w", err)
+	}
+	if len(events) != to-from-1 {
+		return nil, fmt.Errorf("%w: %s has v.%d, found %d of %d event(s) before v.%d", ErrVersionGap, productID, from, len(events), to-from-1, to)
+	}
+	log.Printf("   🩹 Gap on %s: level at v.%d, got v.%d -> applying %d missing event(s)\n", productID, from, to, len(events))
+	return events, nil
+}
+
+// record สร้าง Alert ถ้ายังไม่มี (มีแล้ว = เคยบันทึกจากรอบก่อน ไม่แตะสถานะการส่งเดิม)
+func (p *StockAlerts) record(ctx context.Context, alert core.StockAlert) error {
+	alert.CreatedAt = time.Now().UTC()
+	if p.MaxAge > 0 && time.Since(alert.EventAt) > p.MaxAge {
+		alert.Status = core.AlertSkipped
+	}
+	_, err := p.Alerts.InsertOne(ctx, alert)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("record alert: %w", err)
+	}
+	if alert.Status == core.AlertSkipped {
+		log.Printf("   ⏭️ %s for %s at v.%d is older than %s: recorded, not sent\n", alert.Type, alert.ProductID, alert.Version, p.MaxAge)
+	}
+	return nil
+}
+
+// save ขยับ stock_levels จาก Version ที่อ่านมา (ตัวอื่นเขียนไปก่อน = ไม่ Match / ชน Unique Index)
+func (p *StockAlerts) save(ctx context.Context, fromVersion int, next stockLevel) error {
+	filter := bson.M{"product_id": next.ProductID, "last_version": fromVersion}
+	res, err := p.Levels.ReplaceOne(ctx, filter, next, options.Replace().SetUpsert(fromVersion == 0))
+	if mongo.IsDuplicateKeyError(err) || (err == nil && res.MatchedCount+res.UpsertedCount == 0) {
+		return fmt.Errorf("%w: %s was at v.%d", ErrLevelConflict, next.ProductID, fromVersion)
+	}
+	if err != nil {
+		return fmt.Errorf("save stock level: %w", err)
+	}
+	return nil
+}
+
+// deliver ส่ง Alert ที่ยังค้างของสินค้านี้ตามลำดับ Version ไปทุก Sink ที่ยังไม่ได้รับ
+func (p *StockAlerts) deliver(ctx context.Context, productID string) error {
+	filter := bson.M{"product_id": productID, "status": core.AlertPending}
+	cursor, err := p.Alerts.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "version", Value: 1}}))
+	if err != nil {
+		return fmt.Errorf("read pending alerts: %w", err)
+	}
+	var pending []core.StockAlert
+	if err := cursor.All(ctx, &pending); err != nil {
+		return fmt.Errorf("read pending alerts: %w", err)
+	}
+
+	for _, alert := range pending {
+		for _, sink := range p.Sinks {
+			if alert.Delivered(sink.Name()) {
+				continue
+			}
+			if err := sink.Notify(ctx, alert); err != nil {
+				notifyErr := fmt.Errorf("notify %s via %s: %w", alert.ID, sink.Name(), err)
+				// บันทึกไว้ให้ Operator ดู (บันทึกไม่ได้ก็รายงานด้วย แต่ยังคืน Error ของ Sink ให้ Retry)
+				_, errSave := p.Alerts.UpdateOne(ctx, bson.M{"_id": alert.ID}, bson.M{"$set": bson.M{"last_error": sink.Name() + ": " + err.Error()}})
+				if errSave != nil {
+					return errors.Join(notifyErr, fmt.Errorf("record alert error: %w", errSave))
+				}
+				return notifyErr
+			}
+			if _, err := p.Alerts.UpdateOne(ctx, bson.M{"_id": alert.ID}, bson.M{"$addToSet": bson.M{"sent_to": sink.Name()}}); err != nil {
+				return fmt.Errorf("mark alert sent: %w", err)
+			}
+		}
+		_, err := p.Alerts.UpdateOne(ctx, bson.M{"_id": alert.ID}, bson.M{
+			"$set":   bson.M{"status": core.AlertSent, "sent_at": time.Now().UTC()},
+			"$unset": bson.M{"last_error": ""},
+		})
+		if err != nil {
+			return fmt.Errorf("mark alert sent: %w", err)
+		}
+		fmt.Printf("   🔔 %s sent for %s (v.%d)\n", alert.Type, alert.ProductID, alert.Version)
+	}
+	return nil
+}
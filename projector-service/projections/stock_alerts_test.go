@@ -0,0 +1,40 @@
+package projections
+
+import (
+	"testing"
+
+	"projector-service/core"
+)
+
+func TestDetectAlertsOncePerCrossing(t *testing.T) {
+	events := []core.StockEvent{
+		{StreamID: "iphone-15", Type: "StockAdded", Qty: 10, Version: 1},
+		{StreamID: "iphone-15", Type: "StockReserved", Qty: 6, Version: 2},  // 4 < 5 -> LOW
+		{StreamID: "iphone-15", Type: "StockReserved", Qty: 1, Version: 3},  // ยัง LOW -> ไม่แจ้งซ้ำ
+		{StreamID: "iphone-15", Type: "StockReserved", Qty: 3, Version: 4},  // 0 -> DEPLETED
+		{StreamID: "iphone-15", Type: "StockReleased", Qty: 3, Version: 5},  // 3 -> LOW (ดีขึ้น ไม่แจ้ง)
+		{StreamID: "iphone-15", Type: "StockAdded", Qty: 20, Version: 6},    // 23 -> OK
+		{StreamID: "iphone-15", Type: "StockReserved", Qty: 19, Version: 7}, // 4 -> LOW อีกรอบ
+	}
+
+	level, alerts := detectAlerts(stockLevel{}, events, 5)
+	if level.Available != 4 || level.Level != core.StockLow || level.LastVersion != 7 {
+		t.Fatalf("level = %+v, want 4 LOW v.7", level)
+	}
+	want := []string{"iphone-15|LowStockDetected|v.2", "iphone-15|StockDepleted|v.4", "iphone-15|LowStockDetected|v.7"}
+	if len(alerts) != len(want) {
+		t.Fatalf("alerts = %+v, want %v", alerts, want)
+	}
+	for i, id := range want {
+		if alerts[i].ID != id {
+			t.Fatalf("alerts[%d] = %s, want %s", i, alerts[i].ID, id)
+		}
+	}
+
+	// Apply ต่อจากระดับที่บันทึกไว้ (เช่นหลัง Restart) ได้ผลเหมือนไล่รวดเดียว
+	first, early := detectAlerts(stockLevel{}, events[:3], 5)
+	_, late := detectAlerts(first, events[3:], 5)
+	if len(early)+len(late) != len(want) || late[0].ID != want[1] {
+		t.Fatalf("split alerts = %+v / %+v", early, late)
+	}
+}
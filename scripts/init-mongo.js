@@ -26,6 +26,10 @@ db.createCollection("events");
 db.events.createIndex({ "stream_id": 1, "version": 1 }, { unique: true });
 print("✅ Index created: events (stream_id + version)");
 
+// 🔥 สร้าง Index: หา Event ล่าสุดที่ Order ทำให้เกิด (ใช้ออก Consistency Token)
+db.events.createIndex({ "order_id": 1, "version": -1 }, { partialFilterExpression: { order_id: { $exists: true } } });
+print("✅ Index created: events (order_id + version)");
+
 // 📝 Mock Data: เติมสต็อกเริ่มต้น (Seed Data)
 // เราจะจำลองว่ามีการเติมของเข้ามาแล้ว (StockAdded)
 db.events.insertMany([
@@ -55,26 +59,164 @@ db.createCollection("products_view");
 db.products_view.createIndex({ "product_id": 1 }, { unique: true });
 print("✅ Index created: products_view (product_id)");
 
-// 📝 Mock Data: เติมข้อมูลให้ตรงกับ Event
-// (เพื่อให้ API อ่านได้เลยโดยไม่ต้องรอ Projector รันครั้งแรก)
-db.products_view.insertMany([
+// ไม่ใส่ Mock Data: Projector สร้างจาก events เอง (ไม่มี Checkpoint = ย้อนอ่านตั้งแต่ต้น)
+// ถ้าใส่ไว้ล่วงหน้า ยอดจะถูกนับซ้ำกับ StockAdded ที่ Projector Replay
+// สร้างใหม่ทั้งก้อนได้ด้วย: projector-service rebuild products_view
+
+// ==========================================
+// C. Collection: checkpoints (Projector State)
+// ==========================================
+db.createCollection("checkpoints");
+print("✅ Collection created: checkpoints");
+
+// ==========================================
+// D. Collection: saga_interventions (Manual Intervention Queue)
+// ==========================================
+db.createCollection("saga_interventions");
+
+// 🔥 สร้าง Index: ให้ Admin ดึงเคสที่ยังค้าง (status = OPEN) เรียงตามเวลาได้เร็ว
+db.saga_interventions.createIndex({ "status": 1, "created_at": 1 });
+print("✅ Index created: saga_interventions (status + created_at)");
+
+// ==========================================
+// E. Collection: order_events (Order Event Store)
+// ==========================================
+db.createCollection("order_events");
+
+// 🔥 สร้าง Index: ห้าม Version ซ้ำใน Order เดียวกัน (Optimistic Locking)
+db.order_events.createIndex({ "stream_id": 1, "version": 1 }, { unique: true });
+print("✅ Index created: order_events (stream_id + version)");
+
+// ==========================================
+// F. Collection: catalog_events (Product Catalog / ราคา)
+// ==========================================
+db.createCollection("catalog_events");
+
+// 🔥 สร้าง Index: ห้าม Version ซ้ำในสินค้าตัวเดิม (Optimistic Locking)
+db.catalog_events.createIndex({ "stream_id": 1, "version": 1 }, { unique: true });
+print("✅ Index created: catalog_events (stream_id + version)");
+
+// 📝 Mock Data: ราคาเริ่มต้น (Payment จำลองจะปฏิเสธยอด > 10000 -> macbook-pro ใช้ทดสอบ Compensation)
+db.catalog_events.insertMany([
   {
-    product_id: "iphone-15",
-    available_stock: 100,
-    last_version: 1
+    stream_id: "iphone-15",
+    type: "ProductCreated",
+    name: "iPhone 15",
+    price: 10000,
+    version: 1,
+    timestamp: new Date()
   },
   {
-    product_id: "macbook-pro",
-    available_stock: 50,
-    last_version: 1
+    stream_id: "macbook-pro",
+    type: "ProductCreated",
+    name: "MacBook Pro",
+    price: 45000,
+    version: 1,
+    timestamp: new Date()
   }
 ]);
-print("✅ Mock Data inserted: products_view");
+print("✅ Mock Data inserted: catalog_events (ProductCreated)");
 
 // ==========================================
-// C. Collection: checkpoints (Projector State)
+// G. Collection: catalog_view (Read Model ของราคา)
 // ==========================================
-db.createCollection("checkpoints");
-print("✅ Collection created: checkpoints");
+db.createCollection("catalog_view");
+
+// 🔥 สร้าง Index: ห้าม Product ID ซ้ำ (Projector ใช้ Upsert แบบมีเงื่อนไข Version)
+db.catalog_view.createIndex({ "product_id": 1 }, { unique: true });
+print("✅ Index created: catalog_view (product_id)");
+
+db.catalog_view.insertMany([
+  { product_id: "iphone-15", name: "iPhone 15", price: 10000, last_version: 1 },
+  { product_id: "macbook-pro", name: "MacBook Pro", price: 45000, last_version: 1 }
+]);
+print("✅ Mock Data inserted: catalog_view");
+
+// ==========================================
+// H. Collection: payment_events (Payment Event Store)
+// ==========================================
+db.createCollection("payment_events");
+
+// 🔥 สร้าง Index: Payment Reactor (Choreography) เช็คว่า Order นี้เคยตัดเงินแล้วหรือยัง
+db.payment_events.createIndex({ "order_id": 1 });
+print("✅ Index created: payment_events (order_id)");
+
+// ==========================================
+// I. Collection: inventory_outbox / payment_outbox (Transactional Outbox)
+// ==========================================
+// ต้องสร้างไว้ก่อน: Transaction ของ AppendEvent เขียนลงทั้ง Event Store และ Outbox
+["inventory_outbox", "payment_outbox"].forEach((name) => {
+  db.createCollection(name);
+
+  // 🔥 สร้าง Index: Relay ดึงข้อความที่ยังไม่ได้ส่ง (status = PENDING) ตามลำดับที่เขียน
+  db.getCollection(name).createIndex({ "status": 1, "_id": 1 });
+  print(`✅ Index created: ${name} (status + _id)`);
+});
+
+// ==========================================
+// J. Collection: projection_dead_letters (Event ที่ Projection Handle ไม่ผ่าน)
+// ==========================================
+db.createCollection("projection_dead_letters");
+
+// 🔥 สร้าง Index: Operator ดึงเคสที่ยังเปิด (status = OPEN) ของแต่ละ Projection เรียงตามเวลา
+db.projection_dead_letters.createIndex({ "status": 1, "projection": 1, "created_at": 1 });
+print("✅ Index created: projection_dead_letters (status + projection + created_at)");
+
+// ==========================================
+// K. Collection: orders_view (Read Model ของ Order: รวม events + payment_events ตาม order_id)
+// ==========================================
+db.createCollection("orders_view");
+
+// 🔥 สร้าง Index: 1 เอกสารต่อ Order (Upsert พร้อมกันต้องชนกันแทนที่จะสร้างซ้ำ)
+db.orders_view.createIndex({ "order_id": 1 }, { unique: true });
+// 🔥 สร้าง Index: GET /orders กรองตาม status / สินค้า แล้วเรียงใหม่สุดก่อน (Keyset Pagination)
+db.orders_view.createIndex({ "status": 1, "created_at": -1, "order_id": -1 });
+db.orders_view.createIndex({ "items.product_id": 1, "created_at": -1, "order_id": -1 });
+db.orders_view.createIndex({ "created_at": -1, "order_id": -1 });
+print("✅ Index created: orders_view (order_id, status/product/created_at)");
+
+// ==========================================
+// L. Collection: projector_leases (Lease ของ Projector หลาย Instance)
+// ==========================================
+db.createCollection("projector_leases");
+
+// 🔥 สร้าง Index: นับ Instance / Lease ที่ยังไม่หมดอายุ
+db.projector_leases.createIndex({ "expires_at": 1 });
+print("✅ Index created: projector_leases (expires_at)");
+
+// ==========================================
+// M. Collection: projector_controls (Pause / Resume จาก Admin API ของ Projector)
+// ==========================================
+db.createCollection("projector_controls");
+print("✅ Collection created: projector_controls");
+
+// ==========================================
+// N. Collection: sales_analytics (Read Model ยอดขายรายชั่วโมง / รายวันต่อสินค้า)
+// ==========================================
+db.createCollection("sales_analytics");
+
+// 🔥 สร้าง Index: GET /products/:id/sales อ่านช่วงเวลาของสินค้าเดียวตาม granularity
+db.sales_analytics.createIndex({ "product_id": 1, "granularity": 1, "bucket": 1 });
+print("✅ Index created: sales_analytics (product_id + granularity + bucket)");
+
+// Key ของ Event ที่นับแล้ว (_id = <generation>|<key> กันนับซ้ำ) แยกจาก Bucket ไม่ให้เอกสาร Bucket โตไม่จำกัด
+db.createCollection("sales_analytics_applied");
+
+// 🔥 สร้าง Index: Rebuild ลบ Key ของ Generation เก่าทิ้ง
+// (TTL บน applied_at สร้างโดย Projector เมื่อตั้ง SALES_ANALYTICS_APPLIED_TTL)
+db.sales_analytics_applied.createIndex({ "generation": 1 });
+print("✅ Index created: sales_analytics_applied (generation)");
+
+// ==========================================
+// O. Collection: stock_levels / stock_alerts (Alert สต็อกต่ำ: ระดับล่าสุดต่อสินค้า + การแจ้งเตือนแต่ละครั้ง)
+// ==========================================
+db.createCollection("stock_levels");
+db.createCollection("stock_alerts");
+
+// 🔥 สร้าง Index: 1 เอกสารต่อสินค้า (สินค้าใหม่ Upsert พร้อมกันต้องชนกันแทนที่จะสร้างซ้ำ)
+db.stock_levels.createIndex({ "product_id": 1 }, { unique: true });
+// 🔥 สร้าง Index: หา Alert ที่ยังส่งไม่ครบของสินค้าตามลำดับ Version
+db.stock_alerts.createIndex({ "product_id": 1, "status": 1, "version": 1 });
+print("✅ Index created: stock_levels (product_id), stock_alerts (product_id + status + version)");
 
 print("🎉 Database Initialization Completed!");
\ No newline at end of file